@@ -2,14 +2,32 @@ package agent
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 type Config struct {
@@ -19,10 +37,36 @@ type Config struct {
 	ID string
 	// Url of Control Plane API
 	ControlPlaneUrl string
-	// Token to authenticate with Control Plane API
-	ControlPlaneAuthToken string
+	// Token to authenticate with Control Plane API, e.g. read from a mounted secret so it can be rotated
+	ControlPlaneAuthToken utils.TokenFunc
+	// TLS config of the connections to the Control Plane API (e.g. the client certificate for mutual TLS)
+	ControlPlaneTLSConfig *tls.Config
+	// Proxy of the connections to the Control Plane API
+	ControlPlaneProxy func(*http.Request) (*url.URL, error)
+	// Compress the requests to the Control Plane API with gzip
+	ControlPlaneCompression bool
+	// Reconcile every VersionTracker at its own phase of the interval
+	Stagger bool
+	// Random delay added to the interval, as a fraction of the interval (e.g. 0.1)
+	Jitter float64
 	// Tags
 	Tags map[string]string
+	// Watch the tracked resources and report their versions when they change
+	Watch bool
+	// Export the running versions as metrics instead of reporting them to the Control Plane
+	Standalone bool
+	// Label selector of the VersionTrackers to reconcile. All the VersionTrackers are reconciled when it is nil
+	Selector labels.Selector
+	// Log the reports instead of sending them to the Control Plane
+	DryRun bool
+	// File the reports are appended to in dry run
+	DryRunOutput string
+	// Fields dropped from the reports to shrink them (see ReportFields)
+	DropFields []string
+	// Number of consecutive failed reports after which the agent is unready. 0 disables the check
+	MaxReportFailures int
+	// The API server version subject
+	ClusterVersion ClusterVersionConfig
 }
 
 // VersionTrackerReconciler reconciles a VersionTracker object
@@ -31,26 +75,86 @@ type VersionTrackerReconciler struct {
 	Log    logr.Logger
 	Scheme *runtime.Scheme
 	Config *Config
+	// Clusters to track the resources of. The VersionTrackers are read from the cluster of the agent
+	Clusters []Cluster
+	// Stream to the control plane. The REST API is used when it is nil or disconnected
+	Stream *Streamer
+	// VersionTrackers to reconcile on the refresh requests of the control plane
+	Refresh chan event.GenericEvent
+	// Reports waiting for the control plane to be reachable. The reports are dropped when it is nil
+	Buffer *ReportBuffer
+	// Batches of reports sent in a single request. The reports are sent one by one when it is nil
+	Batcher *ReportBatcher
+	// Additional control planes the reports are also sent to, each with its own buffer
+	Mirrors []*Mirror
+	// VersionTrackers reconciled by the replica when they are sharded between several replicas. All the
+	// VersionTrackers are reconciled when it is nil
+	Shard *Shard
+	// Calls to the API servers allowed in every interval. The calls are not limited when it is nil
+	Budget *APIBudget
+	// Recorder of the events of the failures on the VersionTrackers. No event is recorded when it is nil
+	Recorder record.EventRecorder
+
+	// controller to add the watches of the tracked resources to
+	controller controller.Controller
+	// watched resource kinds by cluster
+	watches sync.Map
+	// last report of every subject, to only report the changes between the full reports
+	reported sync.Map
+	// running version series of every subject exported by the standalone agents
+	series sync.Map
+	// config with the config file applied
+	live atomic.Value
+	// outcome of the last reports
+	health reportHealth
+	// serializes the writes of the dry run output
+	dryRunMutex sync.Mutex
 }
 
 //+kubebuilder:rbac:groups=vt.skillz.com,resources=versiontrackers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=vt.skillz.com,resources=versiontrackers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=vt.skillz.com,resources=versiontrackers/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operators.coreos.com,resources=clusterserviceversions,verbs=get;list;watch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *VersionTrackerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("versiontracker", req.NamespacedName)
+	if !r.Shard.Owns(req.NamespacedName.String()) {
+		log.V(1).Info("skipping VersionTracker of another shard")
+		return ctrl.Result{}, nil
+	}
 	start := time.Now()
 
-	log.Info("starting reconciliation", "interval", r.Config.Interval)
+	log.Info("starting reconciliation", "interval", r.conf().Interval)
 	var v v1alpha1.VersionTracker
 	if err := r.Get(ctx, req.NamespacedName, &v); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forgetTrackerMetrics(req.NamespacedName.String())
+			if r.conf().Standalone {
+				r.forgetVersions(req.NamespacedName.String())
+			}
+		}
 		log.Error(err, "unable to fetch VersionTracker")
 		reconciliationErrorsTotal.Inc()
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if selector := r.conf().Selector; selector != nil && !selector.Matches(labels.Set(v.Labels)) {
+		log.V(1).Info("skipping VersionTracker not matching the selector of the agent")
+		r.forgetTrackerMetrics(req.NamespacedName.String())
+		if r.conf().Standalone {
+			r.forgetVersions(req.NamespacedName.String())
+		}
+		return ctrl.Result{}, nil
+	}
+	// the status is patched from the VersionTracker as read, before the defaults are set
+	original := v.DeepCopy()
+	status := newStatus(original)
 	// Set defaults
 	v.SetDefaults()
 	// Validate the VersionTracker
@@ -58,71 +162,197 @@ func (r *VersionTrackerReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	if err != nil {
 		log.Error(err, "failed to validate VersionTracker")
 		reconciliationErrorsTotal.Inc()
+		setReady(&status, false, ReasonInvalid, err.Error())
+		r.event(original, corev1.EventTypeWarning, ReasonInvalid, err.Error())
+		r.updateStatus(ctx, original, status)
 		return ctrl.Result{}, err
 	}
 
-	// Prepare options fro getting resources defined in the VersionTracker
-	var opts []client.ListOption
-	if len(v.Spec.Resources.Namespaces) > 0 {
-		for _, ns := range v.Spec.Resources.Namespaces {
-			opts = append(opts, client.InNamespace(ns))
-		}
-	}
 	selector, err := metav1.LabelSelectorAsSelector(v.Spec.Resources.Selector)
 	if err != nil {
 		log.Error(err, "failed to convert label selector to selector")
 		reconciliationErrorsTotal.Inc()
 		return ctrl.Result{}, err
 	}
-	opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	if v.Spec.Resources.Strategy == v1alpha1.HelmReleases {
+		// only the Secrets of the deployed Helm releases are listed
+		reqs, _ := v1alpha1.HelmReleaseSelector.Requirements()
+		selector = selector.Add(reqs...)
+	}
+	if v.Spec.Resources.Strategy == v1alpha1.ClusterServiceVersions {
+		reqs, _ := v1alpha1.ClusterServiceVersionSelector().Requirements()
+		selector = selector.Add(reqs...)
+	}
 
-	// Get the resource object type based on the resource strategy of the VersionTracker
-	resources, err := v.GetObjectList()
-	if err != nil {
-		log.Error(err, "failed to get resource ObjectList")
-		reconciliationErrorsTotal.Inc()
-		return ctrl.Result{}, err
+	// The resources are tracked in every cluster. A failing cluster does not stop the others. The first error is
+	// kept, so an exhausted API budget is not hidden by the error of another cluster
+	var clusterErr error
+	for _, cluster := range r.Clusters {
+		if err := r.reconcileCluster(ctx, v, selector, cluster, &status); err != nil && clusterErr == nil {
+			clusterErr = err
+		}
+	}
+	setReconciled(&status, clusterErr)
+	r.updateStatus(ctx, original, status)
+	if errors.Is(clusterErr, errAPIBudgetExhausted) {
+		log.Info("API call budget exhausted. the VersionTracker is reconciled in the next interval")
+		return ctrl.Result{RequeueAfter: r.Budget.ResetIn()}, nil
+	}
+	if clusterErr != nil {
+		return ctrl.Result{}, clusterErr
 	}
 
-	// Get all resources
-	err = r.List(ctx, resources, opts...)
+	elapsed := time.Since(start)
+	lastReconciliationTimestamp.SetToCurrentTime()
+	reconciliationDuration.Set(float64(elapsed.Milliseconds()))
+	log.Info("done reconciling", "interval", r.interval(v))
+	return ctrl.Result{
+		RequeueAfter: r.requeueAfter(req.NamespacedName.String(), r.interval(v)),
+	}, nil
+}
+
+// reconcileCluster extracts the versions of the resources of the VersionTracker in the cluster
+// and ships them to the control plane with the identifier of the cluster. The matched resources and the extracted
+// versions are added to the status
+func (r *VersionTrackerReconciler) reconcileCluster(ctx context.Context, v v1alpha1.VersionTracker, selector labels.Selector, cluster Cluster, status *v1alpha1.VersionTrackerStatus) error {
+	log := r.Log.WithValues("versiontracker", fmt.Sprintf("%s/%s", v.Namespace, v.Name), "cluster", cluster.ID)
+
+	// Get the namespaces to query for resources defined in the VersionTracker
+	namespaces, err := GetNamespaces(ctx, cluster.Client, v)
 	if err != nil {
+		log.Error(err, "failed to get namespaces")
 		reconciliationErrorsTotal.Inc()
-		log.Error(err, "failed to list pods")
-		return ctrl.Result{}, err
+		return err
 	}
 
-	// Get items based on the resource type
-	items := GetItems(resources)
-	if len(items) == 0 {
-		log.Info("no resources found")
+	if r.conf().Watch {
+		if err := r.watch(cluster, v); err != nil {
+			// the resources are still reported at every interval
+			log.Error(err, "failed to watch resources", "resource", v.GetResourceKind())
+			reconciliationErrorsTotal.Inc()
+		}
+	}
+
+	var items []interface{}
+	if v.GetCustomResource() != nil {
+		// Custom resources are listed with the dynamic client, with a call per namespace
+		if !r.Budget.Take(len(namespaces), r.conf().Interval) {
+			return errAPIBudgetExhausted
+		}
+		items, err = GetCustomResources(ctx, cluster.Dynamic, v, selector, namespaces)
+		if err != nil {
+			reconciliationErrorsTotal.Inc()
+			log.Error(err, "failed to list custom resources", "resource", v.GetResourceKind())
+			return err
+		}
 	} else {
-		// Extract versions from resources
-		sv := r.ExtractSubjectVersion(v, items)
+		for _, ns := range namespaces {
+			// Get the resource object type based on the resource strategy of the VersionTracker
+			resources, err := v.GetObjectList()
+			if err != nil {
+				log.Error(err, "failed to get resource ObjectList")
+				reconciliationErrorsTotal.Inc()
+				return err
+			}
 
-		// Ship the version information to the Control Plane
-		if len(sv.Versions) > 0 && r.Config.ControlPlaneUrl != "" {
-			err := r.ShipToControlPlane(sv)
+			// Get all resources of the namespace. The Secrets are not cached, so they are read from the API server
+			reader := cluster.Client
+			if _, ok := resources.(*corev1.SecretList); ok && cluster.SecretsReader != nil {
+				if !r.Budget.Take(1, r.conf().Interval) {
+					return errAPIBudgetExhausted
+				}
+				reader = cluster.SecretsReader
+			}
+			err = reader.List(ctx, resources, client.InNamespace(ns), client.MatchingLabelsSelector{Selector: selector})
 			if err != nil {
-				log.Error(err, "failed to ship the version to control plane")
 				reconciliationErrorsTotal.Inc()
-				return ctrl.Result{}, err
+				log.Error(err, "failed to list resources", "namespace", ns)
+				return err
+			}
+
+			// Get items based on the resource type
+			if v.Spec.Resources.Strategy == v1alpha1.HelmReleases {
+				items = append(items, r.GetHelmReleases(resources.(*corev1.SecretList))...)
+			} else {
+				items = append(items, GetItems(resources)...)
 			}
 		}
 	}
+	if v.Spec.Resources.JobsLookback != nil {
+		items = FilterFinishedJobs(items, v.Spec.Resources.JobsLookback.Duration)
+	}
+	if v.Spec.Resources.FieldSelector != "" {
+		items, err = FilterByFields(items, v.Spec.Resources.FieldSelector)
+		if err != nil {
+			log.Error(err, "failed to filter resources", "fieldSelector", v.Spec.Resources.FieldSelector)
+			reconciliationErrorsTotal.Inc()
+			return err
+		}
+	}
+	key := seriesKey(cluster.ID, fmt.Sprintf("%s/%s", v.Namespace, v.Name))
+	status.MatchedResources += len(items)
+	trackerMatchedResources.WithLabelValues(fmt.Sprintf("%s/%s", v.Namespace, v.Name), cluster.ID).Set(float64(len(items)))
+	if len(items) == 0 {
+		log.Info("no resources found")
+		if r.conf().Standalone {
+			r.exportVersions(key)
+		}
+		return nil
+	}
 
-	elapsed := time.Since(start)
-	lastReconciliationTimestamp.SetToCurrentTime()
-	reconciliationDuration.Set(float64(elapsed.Milliseconds()))
-	log.Info("done reconciling", "interval", r.Config.Interval)
-	return ctrl.Result{
-		RequeueAfter: r.Config.Interval,
-	}, nil
+	// Extract versions from resources. The apps of the resources are separate subjects with the well known labels
+	// and the resources are grouped by their subject ID with a subject template
+	var subjects []SubjectVersion
+	if v.Spec.LocalVersion.Strategy == v1alpha1.WellKnownLabels {
+		subjects = r.ExtractWellKnownVersions(v, items)
+	} else if v.Spec.SubjectTemplate != "" {
+		subjects = r.ExtractTemplatedVersions(v, items)
+	} else {
+		subjects = []SubjectVersion{r.ExtractSubjectVersion(v, items)}
+	}
+	for i := range subjects {
+		subjects[i].AgentID = cluster.ID
+	}
+	addVersions(status, v, subjects)
+	if r.conf().Standalone {
+		r.exportVersions(key, subjects...)
+		status.LastReportTime = &metav1.Time{Time: time.Now()}
+	}
+
+	// Ship the version information to the Control Plane
+	for _, sv := range subjects {
+		if len(sv.Versions) == 0 || !r.reporting() {
+			continue
+		}
+		if !r.changed(sv, r.interval(v)) {
+			log.V(1).Info("versions unchanged since the last report", "subject", sv.ID)
+			continue
+		}
+		err := r.ShipToControlPlane(sv)
+		if err != nil {
+			log.Error(err, "failed to ship the version to control plane", "subject", sv.ID)
+			reconciliationErrorsTotal.Inc()
+			r.event(&v, corev1.EventTypeWarning, ReasonReportFailed, fmt.Sprintf("failed to report the versions of %s to the control plane: %v", sv.ID, err))
+			return err
+		}
+		r.recordReport(sv)
+		status.LastReportTime = &metav1.Time{Time: time.Now()}
+	}
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VersionTrackerReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.VersionTracker{}).
-		Complete(r)
+	// the updates of the status are not reconciled
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.VersionTracker{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})))
+	if r.Refresh != nil {
+		b = b.Watches(&source.Channel{Source: r.Refresh}, &handler.EnqueueRequestForObject{})
+	}
+	c, err := b.Build(r)
+	if err != nil {
+		return err
+	}
+	r.controller = c
+	return nil
 }
@@ -18,11 +18,19 @@ package v1alpha1
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
 
+	"github.com/hashicorp/go-version"
+	"github.com/skillz/opvic/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -32,11 +40,100 @@ type RemoteStrategy string
 const (
 	FieldSelection LocalStrategy = "FieldSelection"
 	ImageTag       LocalStrategy = "ImageTag"
+	NodeInfo       LocalStrategy = "NodeInfo"
+	// WellKnownLabels reports the version of every app of the resources from the app.kubernetes.io/version
+	// and Helm chart labels, without any extraction config
+	WellKnownLabels LocalStrategy = "WellKnownLabels"
 
-	HelmStrategyChartVersion RemoteStrategy = "chartVersion"
-	HelmStrategyAppVersion   RemoteStrategy = "appVersion"
-	GithubStrategyReleases   RemoteStrategy = "releases"
-	GithubStrategyTags       RemoteStrategy = "tags"
+	// HelmReleases is the resource strategy of the deployed Helm releases
+	HelmReleases = "HelmReleases"
+	// CustomResources is the resource strategy of the custom resources listed with the dynamic client
+	CustomResources = "CustomResources"
+	// ConfigMaps and Secrets are the resource strategies of the versions stored in the data of the config
+	ConfigMaps = "ConfigMaps"
+	Secrets    = "Secrets"
+	// ClusterServiceVersions is the resource strategy of the operators installed with OLM
+	ClusterServiceVersions = "ClusterServiceVersions"
+
+	HelmStrategyChartVersion    RemoteStrategy = "chartVersion"
+	HelmStrategyAppVersion      RemoteStrategy = "appVersion"
+	GithubStrategyReleases      RemoteStrategy = "releases"
+	GithubStrategyTags          RemoteStrategy = "tags"
+	GithubStrategyPackages      RemoteStrategy = "packages"
+	GithubStrategyLatestRelease RemoteStrategy = "latestRelease"
+	GithubStrategyBranches      RemoteStrategy = "branches"
+	GithubStrategyCommits       RemoteStrategy = "commits"
+	GithubStrategyFile          RemoteStrategy = "file"
+	GithubStrategyAssets        RemoteStrategy = "assets"
+	GithubStrategyDeployments   RemoteStrategy = "deployments"
+	GitlabStrategyReleases      RemoteStrategy = "releases"
+	GitlabStrategyTags          RemoteStrategy = "tags"
+
+	BitbucketStrategyTags      RemoteStrategy = "tags"
+	BitbucketStrategyDownloads RemoteStrategy = "downloads"
+
+	OCIStrategyTags RemoteStrategy = "tags"
+
+	ECRStrategyTags RemoteStrategy = "tags"
+
+	GARStrategyTags     RemoteStrategy = "tags"
+	GARStrategyVersions RemoteStrategy = "versions"
+
+	ACRStrategyTags RemoteStrategy = "tags"
+
+	QuayStrategyTags RemoteStrategy = "tags"
+
+	ArtifactHubStrategyVersions RemoteStrategy = "versions"
+
+	PyPIStrategyReleases RemoteStrategy = "releases"
+
+	NPMStrategyVersions RemoteStrategy = "versions"
+	NPMStrategyDistTags RemoteStrategy = "distTags"
+
+	MavenStrategyVersions RemoteStrategy = "versions"
+
+	CratesStrategyVersions RemoteStrategy = "versions"
+	CratesStrategyIndex    RemoteStrategy = "index"
+
+	HTMLStrategyRegex    RemoteStrategy = "regex"
+	HTMLStrategySelector RemoteStrategy = "selector"
+
+	GitStrategyTags RemoteStrategy = "tags"
+
+	AzureDevOpsStrategyTags     RemoteStrategy = "tags"
+	AzureDevOpsStrategyReleases RemoteStrategy = "releases"
+
+	S3StrategyObjects RemoteStrategy = "objects"
+
+	ArtifactoryStrategyVersions  RemoteStrategy = "versions"
+	ArtifactoryStrategyArtifacts RemoteStrategy = "artifacts"
+
+	NexusStrategyVersions RemoteStrategy = "versions"
+
+	NuGetStrategyVersions RemoteStrategy = "versions"
+
+	PackagistStrategyVersions RemoteStrategy = "versions"
+
+	APTStrategyVersions RemoteStrategy = "versions"
+
+	YUMStrategyVersions RemoteStrategy = "versions"
+
+	OLMStrategyChannels RemoteStrategy = "channels"
+
+	KubernetesStrategyStable RemoteStrategy = "stable"
+	KubernetesStrategyLatest RemoteStrategy = "latest"
+
+	FeedStrategyEntries RemoteStrategy = "entries"
+
+	GKEStrategyChannels RemoteStrategy = "channels"
+	GKEStrategyDefault  RemoteStrategy = "default"
+
+	// EKSStrategyVersions returns the Kubernetes versions supported by EKS, in standard or in extended support
+	EKSStrategyVersions RemoteStrategy = "versions"
+
+	SnapcraftStrategyChannels RemoteStrategy = "channels"
+
+	FlathubStrategyReleases RemoteStrategy = "releases"
 )
 
 var (
@@ -49,6 +146,90 @@ var (
 			},
 		},
 	}
+
+	// HelmReleaseDefaults extracts the chart version of the deployed Helm releases
+	HelmReleaseDefaults = LocalVersion{
+		FieldSelector: ".chart.metadata.version",
+		Extraction: Extraction{
+			Regex: Regex{
+				Pattern: `^(.*)$`,
+				Result:  "$1",
+			},
+		},
+	}
+
+	// KeyDefaults trims the whitespaces around the version stored in a key of a ConfigMap or Secret
+	KeyDefaults = Regex{
+		Pattern: `^\s*(.*?)\s*$`,
+		Result:  "$1",
+	}
+
+	// NodeComponentFieldSelectors are the fields of the node component versions reported in Node.status.nodeInfo
+	NodeComponentFieldSelectors = map[string]string{
+		"kubelet":          ".status.nodeInfo.kubeletVersion",
+		"kubeProxy":        ".status.nodeInfo.kubeProxyVersion",
+		"containerRuntime": ".status.nodeInfo.containerRuntimeVersion",
+		"kernel":           ".status.nodeInfo.kernelVersion",
+		"osImage":          ".status.nodeInfo.osImage",
+	}
+
+	// NodeComponentRegexes extract the versions of the node components,
+	// e.g. v1.21.2-eks-0389ca3, containerd://1.4.6 and 5.4.0-1045-aws. The OS image is reported as is
+	NodeComponentRegexes = map[string]Regex{
+		"kubelet":          {Pattern: `^v?([0-9]+\.[0-9]+\.[0-9]+).*$`, Result: "$1"},
+		"kubeProxy":        {Pattern: `^v?([0-9]+\.[0-9]+\.[0-9]+).*$`, Result: "$1"},
+		"containerRuntime": {Pattern: `^.*://v?([0-9]+\.[0-9]+\.[0-9]+).*$`, Result: "$1"},
+		"kernel":           {Pattern: `^([0-9]+\.[0-9]+\.[0-9]+).*$`, Result: "$1"},
+		"osImage":          {Pattern: `^(.*)$`, Result: "$1"},
+	}
+
+	// ClusterServiceVersionDefaults extracts the version of the operators installed with OLM
+	ClusterServiceVersionDefaults = LocalVersion{
+		FieldSelector: ".spec.version",
+		Extraction: Extraction{
+			Regex: Regex{
+				Pattern: `^(.*)$`,
+				Result:  "$1",
+			},
+		},
+	}
+
+	// ClusterServiceVersionResource is the resource of the OLM ClusterServiceVersions
+	ClusterServiceVersionResource = CustomResource{
+		Group:    "operators.coreos.com",
+		Version:  "v1alpha1",
+		Resource: "clusterserviceversions",
+	}
+
+	// ResourceDefaults are the local version defaults by resource strategy
+	ResourceDefaults = map[string]LocalVersion{
+		HelmReleases:           HelmReleaseDefaults,
+		ClusterServiceVersions: ClusterServiceVersionDefaults,
+	}
+
+	// HelmReleaseSelector selects the Secrets of the deployed Helm releases
+	HelmReleaseSelector = labels.SelectorFromSet(labels.Set{"owner": "helm", "status": "deployed"})
+
+	// ImageTagFieldSelectors are the fields of the first container image by resource strategy.
+	// The field of ImageTagDefaults is used for the pods
+	ImageTagFieldSelectors = map[string]string{
+		"Deployments":  ".spec.template.spec.containers[0].image",
+		"DaemonSets":   ".spec.template.spec.containers[0].image",
+		"StatefulSets": ".spec.template.spec.containers[0].image",
+		"ReplicaSets":  ".spec.template.spec.containers[0].image",
+		"Jobs":         ".spec.template.spec.containers[0].image",
+		"CronJobs":     ".spec.jobTemplate.spec.template.spec.containers[0].image",
+	}
+	// PodSpecFields are the fields of the pod spec by resource strategy
+	PodSpecFields = map[string]string{
+		"Pods":         ".spec",
+		"Deployments":  ".spec.template.spec",
+		"DaemonSets":   ".spec.template.spec",
+		"StatefulSets": ".spec.template.spec",
+		"ReplicaSets":  ".spec.template.spec",
+		"Jobs":         ".spec.template.spec",
+		"CronJobs":     ".spec.jobTemplate.spec.template.spec",
+	}
 )
 
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -70,13 +251,31 @@ type VersionTrackerSpec struct {
 
 	// +optional
 	RemoteVersion RemoteVersion `json:"remoteVersion"`
+
+	// Interval between the reconciliations and the full reports of the VersionTracker (e.g. 1m for the critical
+	// apps). Defaults to the interval of the agent
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Go template of the subject ID over the metadata of the resources (name, namespace, labels and annotations),
+	// e.g. {{ .namespace }}-{{ .labels.app }}. The rendered ID is escaped into a DNS label: it is lower cased and the
+	// characters other than alphanumerics and dashes are replaced with dashes. The resources are grouped by their
+	// subject ID and every subject is reported with its own versions. The resources whose template renders empty
+	// are reported under `name`
+	// +optional
+	SubjectTemplate string `json:"subjectTemplate,omitempty"`
 }
 
 type Resources struct {
 
 	// +kubebuilder:default=Pods
-	// +kubebuilder:validation:Enum = [Nodes, Pods, Deployments, DaemonSets, StatefulSets, ReplicaSets, CronJobs, Jobs]
-	// Specifies the strategy to find the resources to track.(Default: `Pods`)
+	// +kubebuilder:validation:Enum=Nodes;Pods;Deployments;DaemonSets;StatefulSets;ReplicaSets;CronJobs;Jobs;HelmReleases;CustomResources;ConfigMaps;Secrets;ClusterServiceVersions
+	// Specifies the strategy to find the resources to track.(Default: `Pods`).
+	// HelmReleases are the deployed Helm v3 releases. The chart version is extracted by default
+	// and other fields can be selected (e.g. .chart.metadata.appVersion).
+	// CustomResources are the resources of `custom`, e.g. the resources managed by an operator.
+	// The version of ConfigMaps and Secrets can be extracted from a key of their data with `localVersion.key`.
+	// ClusterServiceVersions are the operators installed with OLM. Their version is extracted by default
 	// +optional
 	Strategy string `json:"strategy"`
 
@@ -84,16 +283,95 @@ type Resources struct {
 	// +optional
 	Namespaces []string `json:"namespaces"`
 
-	// Label selector to use when querying for resources
+	// Label selector of the Namespaces to use when querying for resources
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// List of Namespaces to skip when querying for resources. Glob patterns are supported (e.g. preview-*)
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// Label selector to use when querying for resources. Both matchLabels and matchExpressions are supported
 	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Field selector the tracked resources must match (e.g. status.phase=Running,spec.nodeName!=node-1).
+	// The fields are the paths of the tracked items, i.e. of the release document for HelmReleases
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Only track the Jobs that are running or finished within the lookback (e.g. 24h) when strategy is Jobs.
+	// All the Jobs are tracked when unset
+	// +optional
+	JobsLookback *metav1.Duration `json:"jobsLookback,omitempty"`
+
+	// The resources to track when strategy is CustomResources
+	// +optional
+	Custom *CustomResource `json:"custom,omitempty"`
+}
+
+// CustomResource identifies the resources to list with the dynamic client
+type CustomResource struct {
+	// API group of the resource (e.g. acid.zalan.do). Empty for the core group
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// API version of the resource (e.g. v1)
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Version string `json:"version"`
+
+	// Plural name of the resource (e.g. postgresqls)
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Resource string `json:"resource"`
+}
+
+func (c CustomResource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: c.Group, Version: c.Version, Resource: c.Resource}
 }
 
 type LocalVersion struct {
-	// +kubebuilder:validation:Enum = ["ImageTag", "FieldSelection"]
+	// +kubebuilder:validation:Enum = ["ImageTag", "FieldSelection", "NodeInfo", "WellKnownLabels"]
 	// +kubebuilder:default=ImageTag
 	// +kubebuilder:validation:Required
 	Strategy LocalStrategy `json:"strategy"`
 
+	// +kubebuilder:validation:Enum=kubelet;kubeProxy;containerRuntime;kernel;osImage
+	// Node component to report the version of when strategy is NodeInfo
+	// +optional
+	NodeComponent string `json:"nodeComponent,omitempty"`
+
+	// Key of the data of the ConfigMaps or Secrets to extract the version from (e.g. version.txt).
+	// It is used instead of the fieldSelector
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Label of the resources to extract the version from (e.g. app.kubernetes.io/version).
+	// It is used instead of the fieldSelector
+	// +optional
+	Label string `json:"label,omitempty"`
+
+	// Annotation of the resources to extract the version from. It is used instead of the fieldSelector
+	// +optional
+	Annotation string `json:"annotation,omitempty"`
+
+	// Environment variable of the container to extract the version from (e.g. APP_VERSION), for the pods and the
+	// workloads. Only the variables with a value are supported. It is used instead of the fieldSelector
+	// +optional
+	Env string `json:"env,omitempty"`
+
+	// Container of the environment variable or the image. The first container is used when unset
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// Collect the images of the init containers too (e.g. istio-init) with the ImageTag strategy
+	// +optional
+	InitContainers bool `json:"initContainers,omitempty"`
+
+	// Collect the images of the ephemeral containers of the pods too with the ImageTag strategy
+	// +optional
+	EphemeralContainers bool `json:"ephemeralContainers,omitempty"`
+
 	// Jsonpath to extract the version from the resource
 	// +kubebuilder:Pattern=^.+$
 	// +optional
@@ -104,21 +382,22 @@ type LocalVersion struct {
 }
 
 type RemoteVersion struct {
-	// +kubebuilder:validation:Enum = ["github", "helm-repo"]
+	// +kubebuilder:validation:Enum = ["github", "gitlab", "helm", "bitbucket", "oci", "ecr", "gar", "acr", "quay", "artifacthub", "pypi", "npm", "maven", "crates", "html", "git", "azuredevops", "s3", "artifactory", "nexus", "nuget", "packagist", "apt", "yum", "olm", "kubernetes", "feed", "gke", "eks", "snapcraft", "flathub"]
 	// +kubebuilder:default=github
 	// +kubebuilder:validation:Required
 	Provider string `json:"provider"`
 
-	// +kubebuilder:validation:Enum = ["releases", "tags", "chartVersion", "appVersion"]
+	// +kubebuilder:validation:Enum = ["releases", "tags", "chartVersion", "appVersion", "downloads", "versions", "packages", "distTags", "index", "regex", "selector", "objects", "artifacts", "channels", "stable", "latest", "entries", "default", "latestRelease", "branches", "commits", "file", "assets", "deployments"]
 	// +kubebuilder:validation:Required
 	Strategy RemoteStrategy `json:"strategy"`
 
 	// Repository to get the remote version from.
-	// e.g owner/repo or https://charts.bitnami.com/bitnami
+	// e.g owner/repo, group/subgroup/project, ghcr.io/owner/image, helm/bitnami/nginx or https://charts.bitnami.com/bitnami
 	// +kubebuilder:validation:Required
 	Repo string `json:"repo"`
 
-	// Helm chart name to track. Required if `provider` is `helm-repo`
+	// Helm chart name to track. Required if `provider` is `helm`.
+	// Repo can be an OCI registry (e.g. oci://ghcr.io/owner/charts) when strategy is `chartVersion`
 	// +optional
 	Chart string `json:"chart,omitempty"`
 
@@ -127,6 +406,120 @@ type RemoteVersion struct {
 
 	// +optional
 	Constraint string `json:"constraint,omitempty"`
+
+	// Duration to cache the remote versions and the data of the repo with any provider (e.g. 5m for fast-moving
+	// repos or 6h for slow ones). Defaults to the cache expiration of the control plane. The remote versions are
+	// recomputed every cache reconciler interval, so TTLs shorter than it have no effect
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// Options of the html provider. Repo is the URL of the page to scrape
+	// +optional
+	HTML HTMLOptions `json:"html,omitempty"`
+
+	// Options of the github provider
+	// +optional
+	Github GithubOptions `json:"github,omitempty"`
+}
+
+type GithubOptions struct {
+	// Include pre-releases in the releases strategy. Defaults to true
+	// +kubebuilder:default=true
+	// +optional
+	IncludePrereleases *bool `json:"includePrereleases,omitempty"`
+
+	// Include draft releases in the releases strategy. Defaults to true
+	// +kubebuilder:default=true
+	// +optional
+	IncludeDrafts *bool `json:"includeDrafts,omitempty"`
+
+	// Branch to get the commits from in the commits strategy. Defaults to the default branch of the repo
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Format of the versions in the commits strategy. `date` is the commit date in the format of
+	// YYYYMMDD.HHMMSS which can be compared like a version and `sha` is the short commit SHA
+	// +kubebuilder:validation:Enum=date;sha
+	// +kubebuilder:default=date
+	// +optional
+	CommitFormat string `json:"commitFormat,omitempty"`
+
+	// Path of the file to extract the version from in the file strategy (e.g. VERSION or charts/app/Chart.yaml)
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Git reference (branch, tag or commit) to get the file from in the file strategy.
+	// Defaults to the default branch of the repo
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// JsonPath of the version in a JSON or YAML file in the file strategy (e.g. .version).
+	// The extraction regex is applied to the whole file content when empty
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Resolve the SHA256 digests of the assets of the latest version in the assets strategy
+	// from the checksum file published with the release (e.g. checksums.txt)
+	// +optional
+	AssetDigests bool `json:"assetDigests,omitempty"`
+
+	// Maximum number of pages of 100 releases or tags to request in the releases, tags and assets strategies.
+	// All the pages are requested when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPages int `json:"maxPages,omitempty"`
+
+	// Maximum number of the most recent releases or tags to get in the releases, tags and assets strategies.
+	// All of them are requested when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxItems int `json:"maxItems,omitempty"`
+
+	// Date in the format of YYYY-MM-DD or RFC3339. Releases created before the cutoff are ignored and no more
+	// pages are requested once one is reached in the releases and assets strategies
+	// +optional
+	Cutoff string `json:"cutoff,omitempty"`
+
+	// Only accept the tags whose tag object or commit signature is verified by Github in the tags and releases
+	// strategies. Verifications are requested for each matching tag and cached
+	// +optional
+	VerifiedOnly bool `json:"verifiedOnly,omitempty"`
+
+	// Deprecated: use the cacheTTL of the remote version, which takes precedence
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// Environment of the deployments in the deployments strategy (e.g. production). The version is extracted
+	// from the ref of the latest successful deployment
+	// +optional
+	Environment string `json:"environment,omitempty"`
+
+	// Type of the Github Packages package in the packages strategy. The versions are extracted from the tags
+	// of container packages and from the version names of the other types
+	// +kubebuilder:validation:Enum=container;npm;maven;rubygems;nuget
+	// +kubebuilder:default=container
+	// +optional
+	PackageType string `json:"packageType,omitempty"`
+}
+
+// PrereleasesIncluded returns true if the pre-releases should be included in the releases strategy
+func (o *GithubOptions) PrereleasesIncluded() bool {
+	return o.IncludePrereleases == nil || *o.IncludePrereleases
+}
+
+// DraftsIncluded returns true if the draft releases should be included in the releases strategy
+func (o *GithubOptions) DraftsIncluded() bool {
+	return o.IncludeDrafts == nil || *o.IncludeDrafts
+}
+
+type HTMLOptions struct {
+	// CSS selector of the elements to extract the versions from. Required if `strategy` is `selector`
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Attribute of the selected elements to extract the versions from (e.g. href). Defaults to the element text
+	// +optional
+	Attribute string `json:"attribute,omitempty"`
 }
 
 type Extraction struct {
@@ -145,12 +538,41 @@ type Regex struct {
 	Result string `json:"result"`
 }
 
+// ConditionReady is the condition of the VersionTrackers whose versions are extracted and reported
+const ConditionReady = "Ready"
+
 // VersionTrackerStatus defines the observed state of VersionTracker
 type VersionTrackerStatus struct {
+	// Conditions of the VersionTracker. Ready is false when the VersionTracker is invalid or its versions
+	// can not be extracted or reported
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Number of resources matched in the clusters of the agent at the last reconciliation
+	// +optional
+	MatchedResources int `json:"matchedResources"`
+
+	// Versions extracted at the last reconciliation
+	// +optional
+	Versions []string `json:"versions,omitempty"`
+
+	// Last time the versions were reported to the control plane or exported by a standalone agent
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// Generation of the VersionTracker observed at the last reconciliation
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Subject",type=string,JSONPath=`.spec.name`
+//+kubebuilder:printcolumn:name="Resources",type=integer,JSONPath=`.status.matchedResources`
+//+kubebuilder:printcolumn:name="Versions",type=string,JSONPath=`.status.versions`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Last Report",type=date,JSONPath=`.status.lastReportTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // VersionTracker is the Schema for the versiontrackers API
 type VersionTracker struct {
@@ -180,22 +602,220 @@ func (v *VersionTracker) GetName() string {
 
 // GetKind returns the kind of the resource based on local version strategy
 func (v *VersionTracker) GetResourceKind() string {
+	if v.Spec.Resources.Strategy == CustomResources && v.Spec.Resources.Custom != nil {
+		return v.Spec.Resources.Custom.GroupVersionResource().GroupResource().String()
+	}
 	return v.Spec.Resources.Strategy
 }
 
+// GetCustomResource returns the resource to list with the dynamic client based on the resource strategy
+// and nil if the resources are listed with the typed client
+func (v *VersionTracker) GetCustomResource() *CustomResource {
+	switch v.Spec.Resources.Strategy {
+	case CustomResources:
+		return v.Spec.Resources.Custom
+	case ClusterServiceVersions:
+		return &ClusterServiceVersionResource
+	default:
+		return nil
+	}
+}
+
+// ClusterServiceVersionSelector skips the copies of the ClusterServiceVersions made by OLM
+// in the namespaces watched by the operators
+func ClusterServiceVersionSelector() labels.Selector {
+	selector, _ := labels.Parse("!olm.copiedFrom")
+	return selector
+}
+
+// RemoteStrategies are the strategies supported by the providers of the remote versions
+var RemoteStrategies = []RemoteStrategy{"releases", "tags", "chartVersion", "appVersion", "downloads", "versions", "packages", "distTags", "index", "regex", "selector", "objects", "artifacts", "channels", "stable", "latest", "entries", "default", "latestRelease", "branches", "commits", "file", "assets", "deployments"}
+
 func (v *VersionTracker) Validate() error {
-	if v.Spec.LocalVersion.Strategy != ImageTag {
+	switch v.Spec.LocalVersion.Strategy {
+	case ImageTag, FieldSelection, NodeInfo, WellKnownLabels:
+	default:
+		return fmt.Errorf("unsupported localVersion strategy: %s", v.Spec.LocalVersion.Strategy)
+	}
+	if v.Spec.Resources.Strategy != CustomResources && v.GetCustomResource() == nil {
+		if _, err := v.GetObjectList(); err != nil {
+			return err
+		}
+	}
+	if err := v.Spec.RemoteVersion.Validate(); err != nil {
+		return err
+	}
+	if v.Spec.Interval != nil && v.Spec.Interval.Duration <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if v.Spec.SubjectTemplate != "" {
+		if v.Spec.LocalVersion.Strategy == WellKnownLabels {
+			return fmt.Errorf("subjectTemplate is not supported when strategy is WellKnownLabels")
+		}
+		if _, err := template.New("subject").Parse(v.Spec.SubjectTemplate); err != nil {
+			return fmt.Errorf("invalid subjectTemplate %s: %v", v.Spec.SubjectTemplate, err)
+		}
+	}
+	if v.Spec.LocalVersion.Strategy != ImageTag && v.Spec.LocalVersion.Strategy != WellKnownLabels {
 		if v.Spec.LocalVersion.FieldSelector == "" {
 			return fmt.Errorf("fieldSelector is required when strategy is not ImageTag")
 		}
 	}
+	lv := v.Spec.LocalVersion
+	if lv.Strategy == WellKnownLabels {
+		switch v.Spec.Resources.Strategy {
+		case "Nodes", HelmReleases, ClusterServiceVersions:
+			return fmt.Errorf("strategy WellKnownLabels is not supported when resources strategy is %s", v.Spec.Resources.Strategy)
+		}
+		if lv.FieldSelector != "" || lv.Key != "" || lv.Label != "" || lv.Annotation != "" || lv.Env != "" {
+			return fmt.Errorf("fieldSelector, key, label, annotation and env are not supported when strategy is WellKnownLabels")
+		}
+	}
+	set := 0
+	for _, field := range []string{lv.Key, lv.Label, lv.Annotation, lv.Env} {
+		if field != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("only one of key, label, annotation and env can be set")
+	}
+	if lv.Env != "" {
+		if _, ok := PodSpecFields[v.Spec.Resources.Strategy]; !ok {
+			return fmt.Errorf("env is not supported when resources strategy is %s", v.Spec.Resources.Strategy)
+		}
+		if lv.Strategy != FieldSelection {
+			return fmt.Errorf("localVersion strategy must be FieldSelection when env is set")
+		}
+		if lv.InitContainers || lv.EphemeralContainers {
+			return fmt.Errorf("initContainers and ephemeralContainers are not supported with env")
+		}
+	} else if lv.Container != "" || lv.InitContainers || lv.EphemeralContainers {
+		if _, ok := PodSpecFields[v.Spec.Resources.Strategy]; !ok || lv.Strategy != ImageTag {
+			return fmt.Errorf("container, initContainers and ephemeralContainers are only supported with env or the ImageTag strategy of the pods and the workloads")
+		}
+		if lv.EphemeralContainers && v.Spec.Resources.Strategy != "Pods" {
+			return fmt.Errorf("ephemeralContainers is only supported when resources strategy is Pods")
+		}
+	}
+	if lv.Extraction.Regex.Pattern != "" {
+		if _, err := regexp.Compile(lv.Extraction.Regex.Pattern); err != nil {
+			return fmt.Errorf("invalid extraction regex %s: %v", lv.Extraction.Regex.Pattern, err)
+		}
+	}
+	if v.Spec.LocalVersion.Strategy == NodeInfo {
+		if v.Spec.Resources.Strategy != "Nodes" {
+			return fmt.Errorf("resources strategy must be Nodes when localVersion strategy is NodeInfo")
+		}
+		if _, ok := NodeComponentFieldSelectors[v.Spec.LocalVersion.NodeComponent]; !ok {
+			return fmt.Errorf("unsupported node component: %s", v.Spec.LocalVersion.NodeComponent)
+		}
+	}
+	if v.Spec.Resources.Strategy == ConfigMaps || v.Spec.Resources.Strategy == Secrets {
+		if v.Spec.LocalVersion.Strategy != FieldSelection {
+			return fmt.Errorf("localVersion strategy must be FieldSelection when resources strategy is %s", v.Spec.Resources.Strategy)
+		}
+	} else if v.Spec.LocalVersion.Key != "" {
+		return fmt.Errorf("key is only supported when resources strategy is ConfigMaps or Secrets")
+	}
+	if v.Spec.Resources.FieldSelector != "" {
+		if _, err := fields.ParseSelector(v.Spec.Resources.FieldSelector); err != nil {
+			return fmt.Errorf("invalid resources fieldSelector %s: %v", v.Spec.Resources.FieldSelector, err)
+		}
+	}
+	if v.Spec.Resources.Strategy == CustomResources {
+		if v.Spec.Resources.Custom == nil || v.Spec.Resources.Custom.Version == "" || v.Spec.Resources.Custom.Resource == "" {
+			return fmt.Errorf("custom version and resource are required when resources strategy is CustomResources")
+		}
+		if v.Spec.LocalVersion.Strategy != FieldSelection {
+			return fmt.Errorf("localVersion strategy must be FieldSelection when resources strategy is CustomResources")
+		}
+	}
+	return nil
+}
+
+// Validate checks the strategy, the extraction regex and the constraint of the remote version
+func (rv RemoteVersion) Validate() error {
+	if rv.Strategy != "" {
+		supported := false
+		for _, strategy := range RemoteStrategies {
+			supported = supported || strategy == rv.Strategy
+		}
+		if !supported {
+			return fmt.Errorf("unsupported remoteVersion strategy: %s", rv.Strategy)
+		}
+	}
+	if rv.Extraction.Regex.Pattern != "" {
+		if _, err := regexp.Compile(rv.Extraction.Regex.Pattern); err != nil {
+			return fmt.Errorf("invalid remoteVersion extraction regex %s: %v", rv.Extraction.Regex.Pattern, err)
+		}
+	}
+	if rv.Constraint != "" {
+		var err error
+		if rv.Provider == "npm" {
+			// the npm provider filters the versions with npm-style ranges too
+			err = utils.ValidateNpmRange(rv.Constraint)
+		} else {
+			_, err = version.NewConstraint(rv.Constraint)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid remoteVersion constraint %s: %v", rv.Constraint, err)
+		}
+	}
 	return nil
 }
+
 func (v *VersionTracker) SetDefaults() VersionTracker {
 	lv := v.Spec.LocalVersion
-	if lv.Strategy == ImageTag {
+	if lv.Key != "" {
 		if lv.FieldSelector == "" {
+			// dots are escaped so the key is a single field
+			lv.FieldSelector = ".data." + escapeField(lv.Key)
+		}
+		if lv.Extraction.Regex.Pattern == "" {
+			lv.Extraction.Regex = KeyDefaults
+		}
+	} else if lv.Env != "" {
+		// the value is used as is unless an extraction regex is set
+		if podSpec, ok := PodSpecFields[v.Spec.Resources.Strategy]; ok && lv.FieldSelector == "" {
+			container := "[0]"
+			if lv.Container != "" {
+				container = fmt.Sprintf(`[?(@.name=="%s")]`, lv.Container)
+			}
+			lv.FieldSelector = fmt.Sprintf(`%s.containers%s.env[?(@.name=="%s")].value`, podSpec, container, lv.Env)
+		}
+	} else if lv.Label != "" || lv.Annotation != "" {
+		// the value is used as is unless an extraction regex is set
+		if lv.FieldSelector == "" && lv.Label != "" {
+			lv.FieldSelector = ".metadata.labels." + escapeField(lv.Label)
+		} else if lv.FieldSelector == "" {
+			lv.FieldSelector = ".metadata.annotations." + escapeField(lv.Annotation)
+		}
+	} else if defaults, ok := ResourceDefaults[v.Spec.Resources.Strategy]; ok {
+		if lv.FieldSelector == "" {
+			lv.FieldSelector = defaults.FieldSelector
+		}
+		if lv.Extraction.Regex.Pattern == "" {
+			lv.Extraction.Regex.Pattern = defaults.Extraction.Regex.Pattern
+		}
+		if lv.Extraction.Regex.Result == "" {
+			lv.Extraction.Regex.Result = defaults.Extraction.Regex.Result
+		}
+	} else if lv.Strategy == NodeInfo {
+		if lv.FieldSelector == "" {
+			lv.FieldSelector = NodeComponentFieldSelectors[lv.NodeComponent]
+		}
+		if lv.Extraction.Regex.Pattern == "" {
+			lv.Extraction.Regex = NodeComponentRegexes[lv.NodeComponent]
+		}
+	} else if lv.Strategy == ImageTag {
+		if podSpec, ok := PodSpecFields[v.Spec.Resources.Strategy]; ok && lv.FieldSelector == "" && lv.Container != "" {
+			lv.FieldSelector = fmt.Sprintf(`%s.containers[?(@.name=="%s")].image`, podSpec, lv.Container)
+		} else if lv.FieldSelector == "" {
 			lv.FieldSelector = ImageTagDefaults.FieldSelector
+			if fieldSelector, ok := ImageTagFieldSelectors[v.Spec.Resources.Strategy]; ok {
+				lv.FieldSelector = fieldSelector
+			}
 		}
 		if lv.Extraction.Regex.Pattern == "" {
 			lv.Extraction.Regex.Pattern = ImageTagDefaults.Extraction.Regex.Pattern
@@ -208,6 +828,33 @@ func (v *VersionTracker) SetDefaults() VersionTracker {
 	return *v
 }
 
+// escapeField escapes the dots of a map key so it is a single field of a jsonpath
+func escapeField(key string) string {
+	return strings.ReplaceAll(key, ".", `\.`)
+}
+
+// ImageFieldSelectors returns the fields of the images of the init containers and the ephemeral containers
+// collected in addition to the fieldSelector with the ImageTag strategy
+func (v *VersionTracker) ImageFieldSelectors() []string {
+	lv := v.Spec.LocalVersion
+	podSpec, ok := PodSpecFields[v.Spec.Resources.Strategy]
+	if !ok || lv.Strategy != ImageTag {
+		return nil
+	}
+	containers := "[*]"
+	if lv.Container != "" {
+		containers = fmt.Sprintf(`[?(@.name=="%s")]`, lv.Container)
+	}
+	var selectors []string
+	if lv.InitContainers {
+		selectors = append(selectors, fmt.Sprintf("%s.initContainers%s.image", podSpec, containers))
+	}
+	if lv.EphemeralContainers {
+		selectors = append(selectors, fmt.Sprintf("%s.ephemeralContainers%s.image", podSpec, containers))
+	}
+	return selectors
+}
+
 func (v *VersionTracker) GetLocalVersion() LocalVersion {
 	return v.Spec.LocalVersion
 }
@@ -232,6 +879,10 @@ func (v *VersionTracker) GetObjectList() (client.ObjectList, error) {
 		return &batchv1.CronJobList{}, nil
 	case "Jobs":
 		return &batchv1.JobList{}, nil
+	case HelmReleases, Secrets:
+		return &corev1.SecretList{}, nil
+	case ConfigMaps:
+		return &corev1.ConfigMapList{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported resource type: %s", v.Spec.Resources.Strategy)
 	}
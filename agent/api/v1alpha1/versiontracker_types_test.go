@@ -0,0 +1,31 @@
+package v1alpha1
+
+import "testing"
+
+func TestRemoteVersionValidateConstraint(t *testing.T) {
+	tests := []struct {
+		provider   string
+		constraint string
+		valid      bool
+	}{
+		{"github", ">= 1.0, < 2.0", true},
+		{"github", "^1.2.0", false},
+		{"npm", "^1.2.0", true},
+		{"npm", "~1.2", true},
+		{"npm", "1.x", true},
+		{"npm", "1.0.0 - 1.5.0", true},
+		{"npm", "^1.2.0 || ~2.0.0", true},
+		{"npm", "!= 1.2.3", true},
+		{"npm", "foo", false},
+	}
+	for _, tt := range tests {
+		rv := RemoteVersion{Provider: tt.provider, Strategy: "versions", Repo: "repo", Constraint: tt.constraint}
+		err := rv.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s constraint %q is invalid: %v", tt.provider, tt.constraint, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s constraint %q is valid", tt.provider, tt.constraint)
+		}
+	}
+}
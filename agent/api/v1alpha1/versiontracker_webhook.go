@@ -0,0 +1,57 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the validating webhook of the VersionTrackers, and the conversion webhook
+// (/convert) when the other versions are in the scheme of the manager
+func (v *VersionTracker) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(v).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-opvic-skillz-com-v1alpha1-versiontracker,mutating=false,failurePolicy=fail,sideEffects=None,groups=opvic.skillz.com,resources=versiontrackers,verbs=create;update,versions=v1alpha1,name=vversiontracker.opvic.skillz.com,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &VersionTracker{}
+
+// ValidateCreate rejects the invalid VersionTrackers instead of failing their reconciliation
+func (v *VersionTracker) ValidateCreate() error {
+	return v.validateWithDefaults()
+}
+
+// ValidateUpdate rejects the invalid VersionTrackers instead of failing their reconciliation
+func (v *VersionTracker) ValidateUpdate(old runtime.Object) error {
+	return v.validateWithDefaults()
+}
+
+// ValidateDelete allows the deletion of any VersionTracker
+func (v *VersionTracker) ValidateDelete() error {
+	return nil
+}
+
+// validateWithDefaults validates the VersionTracker with the defaults the agent sets before the reconciliation
+func (v *VersionTracker) validateWithDefaults() error {
+	tracker := v.DeepCopy()
+	tracker.SetDefaults()
+	return tracker.Validate()
+}
@@ -25,6 +25,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResource) DeepCopyInto(out *CustomResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResource.
+func (in *CustomResource) DeepCopy() *CustomResource {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Extraction) DeepCopyInto(out *Extraction) {
 	*out = *in
@@ -41,6 +56,51 @@ func (in *Extraction) DeepCopy() *Extraction {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubOptions) DeepCopyInto(out *GithubOptions) {
+	*out = *in
+	if in.IncludePrereleases != nil {
+		in, out := &in.IncludePrereleases, &out.IncludePrereleases
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IncludeDrafts != nil {
+		in, out := &in.IncludeDrafts, &out.IncludeDrafts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubOptions.
+func (in *GithubOptions) DeepCopy() *GithubOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTMLOptions) DeepCopyInto(out *HTMLOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTMLOptions.
+func (in *HTMLOptions) DeepCopy() *HTMLOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(HTMLOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalVersion) DeepCopyInto(out *LocalVersion) {
 	*out = *in
@@ -76,6 +136,13 @@ func (in *Regex) DeepCopy() *Regex {
 func (in *RemoteVersion) DeepCopyInto(out *RemoteVersion) {
 	*out = *in
 	out.Extraction = in.Extraction
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	out.HTML = in.HTML
+	in.Github.DeepCopyInto(&out.Github)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteVersion.
@@ -96,11 +163,31 @@ func (in *Resources) DeepCopyInto(out *Resources) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.JobsLookback != nil {
+		in, out := &in.JobsLookback, &out.JobsLookback
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = new(CustomResource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resources.
@@ -119,7 +206,7 @@ func (in *VersionTracker) DeepCopyInto(out *VersionTracker) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionTracker.
@@ -177,7 +264,12 @@ func (in *VersionTrackerSpec) DeepCopyInto(out *VersionTrackerSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
 	out.LocalVersion = in.LocalVersion
-	out.RemoteVersion = in.RemoteVersion
+	in.RemoteVersion.DeepCopyInto(&out.RemoteVersion)
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionTrackerSpec.
@@ -193,6 +285,22 @@ func (in *VersionTrackerSpec) DeepCopy() *VersionTrackerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionTrackerStatus) DeepCopyInto(out *VersionTrackerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReportTime != nil {
+		in, out := &in.LastReportTime, &out.LastReportTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionTrackerStatus.
@@ -0,0 +1,52 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &VersionTracker{}
+
+// ConvertTo converts the VersionTracker to the v1alpha1 hub version the agent reconciles
+func (src *VersionTracker) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.VersionTracker)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = v1alpha1.VersionTrackerStatus(src.Status)
+	return convertSpec(&src.Spec, &dst.Spec)
+}
+
+// ConvertFrom converts the VersionTracker from the v1alpha1 hub version
+func (dst *VersionTracker) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.VersionTracker)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = VersionTrackerStatus(src.Status)
+	return convertSpec(&src.Spec, &dst.Spec)
+}
+
+// convertSpec converts the spec between the versions through its JSON document. The fields of the specs of the
+// versions are the same so far and are converted as is
+func convertSpec(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
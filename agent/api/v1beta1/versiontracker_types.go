@@ -0,0 +1,371 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type LocalStrategy string
+type RemoteStrategy string
+
+// ConditionReady is the condition of the VersionTrackers whose versions are extracted and reported
+const ConditionReady = "Ready"
+
+// VersionTrackerSpec defines the desired state of VersionTracker
+type VersionTrackerSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	// Name of the app that is being tracked
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Required
+	Resources Resources `json:"resources"`
+
+	// +kubebuilder:validation:Required
+	LocalVersion LocalVersion `json:"localVersion"`
+
+	// +optional
+	RemoteVersion RemoteVersion `json:"remoteVersion"`
+
+	// Interval between the reconciliations and the full reports of the VersionTracker (e.g. 1m for the critical
+	// apps). Defaults to the interval of the agent
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Go template of the subject ID over the metadata of the resources (name, namespace, labels and annotations),
+	// e.g. {{ .namespace }}-{{ .labels.app }}. The rendered ID is escaped into a DNS label: it is lower cased and the
+	// characters other than alphanumerics and dashes are replaced with dashes. The resources are grouped by their
+	// subject ID and every subject is reported with its own versions. The resources whose template renders empty
+	// are reported under `name`
+	// +optional
+	SubjectTemplate string `json:"subjectTemplate,omitempty"`
+}
+
+type Resources struct {
+
+	// +kubebuilder:default=Pods
+	// +kubebuilder:validation:Enum=Nodes;Pods;Deployments;DaemonSets;StatefulSets;ReplicaSets;CronJobs;Jobs;HelmReleases;CustomResources;ConfigMaps;Secrets;ClusterServiceVersions
+	// Specifies the strategy to find the resources to track.(Default: `Pods`).
+	// HelmReleases are the deployed Helm v3 releases. The chart version is extracted by default
+	// and other fields can be selected (e.g. .chart.metadata.appVersion).
+	// CustomResources are the resources of `custom`, e.g. the resources managed by an operator.
+	// The version of ConfigMaps and Secrets can be extracted from a key of their data with `localVersion.key`.
+	// ClusterServiceVersions are the operators installed with OLM. Their version is extracted by default
+	// +optional
+	Strategy string `json:"strategy"`
+
+	// List of Namespaces to use when querying for resources (Default to query all namespaces)
+	// +optional
+	Namespaces []string `json:"namespaces"`
+
+	// Label selector of the Namespaces to use when querying for resources
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// List of Namespaces to skip when querying for resources. Glob patterns are supported (e.g. preview-*)
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// Label selector to use when querying for resources. Both matchLabels and matchExpressions are supported
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Field selector the tracked resources must match (e.g. status.phase=Running,spec.nodeName!=node-1).
+	// The fields are the paths of the tracked items, i.e. of the release document for HelmReleases
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Only track the Jobs that are running or finished within the lookback (e.g. 24h) when strategy is Jobs.
+	// All the Jobs are tracked when unset
+	// +optional
+	JobsLookback *metav1.Duration `json:"jobsLookback,omitempty"`
+
+	// The resources to track when strategy is CustomResources
+	// +optional
+	Custom *CustomResource `json:"custom,omitempty"`
+}
+
+// CustomResource identifies the resources to list with the dynamic client
+type CustomResource struct {
+	// API group of the resource (e.g. acid.zalan.do). Empty for the core group
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// API version of the resource (e.g. v1)
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Version string `json:"version"`
+
+	// Plural name of the resource (e.g. postgresqls)
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Resource string `json:"resource"`
+}
+
+type LocalVersion struct {
+	// +kubebuilder:validation:Enum = ["ImageTag", "FieldSelection", "NodeInfo", "WellKnownLabels"]
+	// +kubebuilder:default=ImageTag
+	// +kubebuilder:validation:Required
+	Strategy LocalStrategy `json:"strategy"`
+
+	// +kubebuilder:validation:Enum=kubelet;kubeProxy;containerRuntime;kernel;osImage
+	// Node component to report the version of when strategy is NodeInfo
+	// +optional
+	NodeComponent string `json:"nodeComponent,omitempty"`
+
+	// Key of the data of the ConfigMaps or Secrets to extract the version from (e.g. version.txt).
+	// It is used instead of the fieldSelector
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Label of the resources to extract the version from (e.g. app.kubernetes.io/version).
+	// It is used instead of the fieldSelector
+	// +optional
+	Label string `json:"label,omitempty"`
+
+	// Annotation of the resources to extract the version from. It is used instead of the fieldSelector
+	// +optional
+	Annotation string `json:"annotation,omitempty"`
+
+	// Environment variable of the container to extract the version from (e.g. APP_VERSION), for the pods and the
+	// workloads. Only the variables with a value are supported. It is used instead of the fieldSelector
+	// +optional
+	Env string `json:"env,omitempty"`
+
+	// Container of the environment variable or the image. The first container is used when unset
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// Collect the images of the init containers too (e.g. istio-init) with the ImageTag strategy
+	// +optional
+	InitContainers bool `json:"initContainers,omitempty"`
+
+	// Collect the images of the ephemeral containers of the pods too with the ImageTag strategy
+	// +optional
+	EphemeralContainers bool `json:"ephemeralContainers,omitempty"`
+
+	// Jsonpath to extract the version from the resource
+	// +kubebuilder:Pattern=^.+$
+	// +optional
+	FieldSelector string `json:"fieldSelector"`
+
+	// +optional
+	Extraction Extraction `json:"extraction"`
+}
+
+type RemoteVersion struct {
+	// +kubebuilder:validation:Enum = ["github", "gitlab", "helm", "bitbucket", "oci", "ecr", "gar", "acr", "quay", "artifacthub", "pypi", "npm", "maven", "crates", "html", "git", "azuredevops", "s3", "artifactory", "nexus", "nuget", "packagist", "apt", "yum", "olm", "kubernetes", "feed", "gke", "eks", "snapcraft", "flathub"]
+	// +kubebuilder:default=github
+	// +kubebuilder:validation:Required
+	Provider string `json:"provider"`
+
+	// +kubebuilder:validation:Enum = ["releases", "tags", "chartVersion", "appVersion", "downloads", "versions", "packages", "distTags", "index", "regex", "selector", "objects", "artifacts", "channels", "stable", "latest", "entries", "default", "latestRelease", "branches", "commits", "file", "assets", "deployments"]
+	// +kubebuilder:validation:Required
+	Strategy RemoteStrategy `json:"strategy"`
+
+	// Repository to get the remote version from.
+	// e.g owner/repo, group/subgroup/project, ghcr.io/owner/image, helm/bitnami/nginx or https://charts.bitnami.com/bitnami
+	// +kubebuilder:validation:Required
+	Repo string `json:"repo"`
+
+	// Helm chart name to track. Required if `provider` is `helm`.
+	// Repo can be an OCI registry (e.g. oci://ghcr.io/owner/charts) when strategy is `chartVersion`
+	// +optional
+	Chart string `json:"chart,omitempty"`
+
+	// +optional
+	Extraction Extraction `json:"extraction"`
+
+	// +optional
+	Constraint string `json:"constraint,omitempty"`
+
+	// Duration to cache the remote versions and the data of the repo with any provider (e.g. 5m for fast-moving
+	// repos or 6h for slow ones). Defaults to the cache expiration of the control plane. The remote versions are
+	// recomputed every cache reconciler interval, so TTLs shorter than it have no effect
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// Options of the html provider. Repo is the URL of the page to scrape
+	// +optional
+	HTML HTMLOptions `json:"html,omitempty"`
+
+	// Options of the github provider
+	// +optional
+	Github GithubOptions `json:"github,omitempty"`
+}
+
+type GithubOptions struct {
+	// Include pre-releases in the releases strategy. Defaults to true
+	// +kubebuilder:default=true
+	// +optional
+	IncludePrereleases *bool `json:"includePrereleases,omitempty"`
+
+	// Include draft releases in the releases strategy. Defaults to true
+	// +kubebuilder:default=true
+	// +optional
+	IncludeDrafts *bool `json:"includeDrafts,omitempty"`
+
+	// Branch to get the commits from in the commits strategy. Defaults to the default branch of the repo
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Format of the versions in the commits strategy. `date` is the commit date in the format of
+	// YYYYMMDD.HHMMSS which can be compared like a version and `sha` is the short commit SHA
+	// +kubebuilder:validation:Enum=date;sha
+	// +kubebuilder:default=date
+	// +optional
+	CommitFormat string `json:"commitFormat,omitempty"`
+
+	// Path of the file to extract the version from in the file strategy (e.g. VERSION or charts/app/Chart.yaml)
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Git reference (branch, tag or commit) to get the file from in the file strategy.
+	// Defaults to the default branch of the repo
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// JsonPath of the version in a JSON or YAML file in the file strategy (e.g. .version).
+	// The extraction regex is applied to the whole file content when empty
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Resolve the SHA256 digests of the assets of the latest version in the assets strategy
+	// from the checksum file published with the release (e.g. checksums.txt)
+	// +optional
+	AssetDigests bool `json:"assetDigests,omitempty"`
+
+	// Maximum number of pages of 100 releases or tags to request in the releases, tags and assets strategies.
+	// All the pages are requested when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPages int `json:"maxPages,omitempty"`
+
+	// Maximum number of the most recent releases or tags to get in the releases, tags and assets strategies.
+	// All of them are requested when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxItems int `json:"maxItems,omitempty"`
+
+	// Date in the format of YYYY-MM-DD or RFC3339. Releases created before the cutoff are ignored and no more
+	// pages are requested once one is reached in the releases and assets strategies
+	// +optional
+	Cutoff string `json:"cutoff,omitempty"`
+
+	// Only accept the tags whose tag object or commit signature is verified by Github in the tags and releases
+	// strategies. Verifications are requested for each matching tag and cached
+	// +optional
+	VerifiedOnly bool `json:"verifiedOnly,omitempty"`
+
+	// Deprecated: use the cacheTTL of the remote version, which takes precedence
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// Environment of the deployments in the deployments strategy (e.g. production). The version is extracted
+	// from the ref of the latest successful deployment
+	// +optional
+	Environment string `json:"environment,omitempty"`
+
+	// Type of the Github Packages package in the packages strategy. The versions are extracted from the tags
+	// of container packages and from the version names of the other types
+	// +kubebuilder:validation:Enum=container;npm;maven;rubygems;nuget
+	// +kubebuilder:default=container
+	// +optional
+	PackageType string `json:"packageType,omitempty"`
+}
+
+type HTMLOptions struct {
+	// CSS selector of the elements to extract the versions from. Required if `strategy` is `selector`
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Attribute of the selected elements to extract the versions from (e.g. href). Defaults to the element text
+	// +optional
+	Attribute string `json:"attribute,omitempty"`
+}
+
+type Extraction struct {
+	// Regex to extract the version from the field
+	// +optional
+	Regex Regex `json:"regex,omitempty"`
+}
+
+type Regex struct {
+	// Regex pattern to extract the version from the field
+	// +kubebuilder:validation:Required
+	Pattern string `json:"pattern"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:default=$1
+	Result string `json:"result"`
+}
+
+// VersionTrackerStatus defines the observed state of VersionTracker
+type VersionTrackerStatus struct {
+	// Conditions of the VersionTracker. Ready is false when the VersionTracker is invalid or its versions
+	// can not be extracted or reported
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Number of resources matched in the clusters of the agent at the last reconciliation
+	// +optional
+	MatchedResources int `json:"matchedResources"`
+
+	// Versions extracted at the last reconciliation
+	// +optional
+	Versions []string `json:"versions,omitempty"`
+
+	// Last time the versions were reported to the control plane or exported by a standalone agent
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// Generation of the VersionTracker observed at the last reconciliation
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Subject",type=string,JSONPath=`.spec.name`
+//+kubebuilder:printcolumn:name="Resources",type=integer,JSONPath=`.status.matchedResources`
+//+kubebuilder:printcolumn:name="Versions",type=string,JSONPath=`.status.versions`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Last Report",type=date,JSONPath=`.status.lastReportTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VersionTracker is the Schema for the versiontrackers API
+type VersionTracker struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VersionTrackerSpec   `json:"spec,omitempty"`
+	Status VersionTrackerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VersionTrackerList contains a list of VersionTracker
+type VersionTrackerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VersionTracker `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VersionTracker{}, &VersionTrackerList{})
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+)
+
+// ReportBatcher collects the reports of the subjects to send them to the control plane in batches. A batch is sent
+// at every interval or as soon as it is full. Only the latest report of a subject is kept in a batch
+type ReportBatcher struct {
+	size     int
+	interval time.Duration
+	mutex    sync.Mutex
+	reports  map[string]int
+	batch    []controlplane.AgentPayload
+	// keys of the subjects of the reports of the batch
+	subjects []string
+	full     chan struct{}
+}
+
+func NewReportBatcher(size int, interval time.Duration) *ReportBatcher {
+	return &ReportBatcher{
+		size:     size,
+		interval: interval,
+		reports:  map[string]int{},
+		full:     make(chan struct{}, 1),
+	}
+}
+
+// Add adds the report of the subject to the next batch in place of the previous report of the subject
+func (b *ReportBatcher) Add(subject string, payload controlplane.AgentPayload) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := reportKey(payload)
+	if i, ok := b.reports[key]; ok {
+		b.batch[i] = payload
+		b.subjects[i] = subject
+		return
+	}
+	b.reports[key] = len(b.batch)
+	b.batch = append(b.batch, payload)
+	b.subjects = append(b.subjects, subject)
+	if len(b.batch) >= b.size {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take returns the next batch with the keys of its subjects and starts a new one
+func (b *ReportBatcher) take() ([]controlplane.AgentPayload, []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	batch, subjects := b.batch, b.subjects
+	b.batch = nil
+	b.subjects = nil
+	b.reports = map[string]int{}
+	return batch, subjects
+}
+
+// SendBatches sends the batches of reports until the context is done. The reports of a batch that fails to be
+// sent are buffered like the reports sent one by one and their subjects are reported again
+func (r *VersionTrackerReconciler) SendBatches(ctx context.Context) error {
+	ticker := time.NewTicker(r.Batcher.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.Batcher.full:
+		}
+		r.sendNextBatch()
+	}
+}
+
+func (r *VersionTrackerReconciler) sendNextBatch() {
+	log := r.Log.WithName("batcher")
+	batch, subjects := r.Batcher.take()
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.sendBatch(log, batch); err != nil {
+		log.Error(err, "failed to ship a batch of version info to control plane", "count", len(batch))
+		reconciliationErrorsTotal.Inc()
+		// the subjects were recorded as reported when they were queued, so their next reconciliation reports them
+		// again rather than the next interval
+		for _, subject := range subjects {
+			r.forgetReport(subject)
+		}
+		if r.Buffer != nil {
+			for _, payload := range batch {
+				r.Buffer.Add(payload)
+			}
+			log.Info("control plane unreachable. buffered the version info", "buffered", r.Buffer.Len())
+		}
+		return
+	}
+	if r.Buffer != nil {
+		for _, payload := range batch {
+			r.Buffer.Remove(payload)
+		}
+		r.Buffer.Wake()
+	}
+}
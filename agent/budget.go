@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errAPIBudgetExhausted is returned when the API calls of a reconciliation do not fit in the API budget
+var errAPIBudgetExhausted = errors.New("API call budget of the interval exhausted")
+
+// APIBudget limits the number of calls the agent makes to the API servers in every interval, on top of the client
+// rate limits, so the agent does not hammer overloaded API servers. Only the calls that are not served by the
+// informers are counted (e.g. the lists of the custom resources, the API server version and the status updates)
+type APIBudget struct {
+	// Maximum number of calls per interval
+	Limit int
+
+	mutex sync.Mutex
+	// calls of the current window
+	used int
+	// end of the current window
+	resetAt time.Time
+}
+
+func NewAPIBudget(limit int) *APIBudget {
+	return &APIBudget{Limit: limit}
+}
+
+// Take reserves n calls in the budget of the current interval. It returns false when they do not fit in the budget.
+// The budget is unlimited when it is nil
+func (b *APIBudget) Take(n int, interval time.Duration) bool {
+	if b == nil {
+		return true
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	if !now.Before(b.resetAt) {
+		b.used = 0
+		b.resetAt = now.Add(interval)
+	}
+	if b.used+n > b.Limit {
+		apiBudgetExhaustedTotal.Inc()
+		return false
+	}
+	b.used += n
+	return true
+}
+
+// ResetIn returns the time until the budget of the next interval
+func (b *APIBudget) ResetIn() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if d := time.Until(b.resetAt); d > time.Second {
+		return d
+	}
+	return time.Second
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+)
+
+// bufferedReport is a report waiting to be sent. The generation tells a report apart from a newer report
+// of the same subject added while it was being sent
+type bufferedReport struct {
+	payload    controlplane.AgentPayload
+	generation uint64
+}
+
+// ReportBuffer queues the reports that could not be sent to the control plane. Only the latest report of a subject
+// is kept and the report of the least recently updated subject is dropped when the buffer is full
+type ReportBuffer struct {
+	size       int
+	mutex      sync.Mutex
+	generation uint64
+	reports    map[string]bufferedReport
+	// keys of the reports from the least to the most recently updated
+	order []string
+	wake  chan struct{}
+	// number of buffered reports
+	gauge prometheus.Gauge
+}
+
+func NewReportBuffer(size int) *ReportBuffer {
+	return &ReportBuffer{
+		size:    size,
+		reports: map[string]bufferedReport{},
+		wake:    make(chan struct{}, 1),
+		gauge:   bufferedReports,
+	}
+}
+
+func reportKey(payload controlplane.AgentPayload) string {
+	return fmt.Sprintf("%s/%s/%s", payload.AgentID, payload.Version.NameSpace, payload.Version.ID)
+}
+
+// Add queues the report in place of the previous report of the subject
+func (b *ReportBuffer) Add(payload controlplane.AgentPayload) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := reportKey(payload)
+	b.removeKey(key)
+	if len(b.order) >= b.size {
+		oldest := b.order[0]
+		b.removeKey(oldest)
+		bufferedReportsDroppedTotal.Inc()
+	}
+	b.generation++
+	b.reports[key] = bufferedReport{payload: payload, generation: b.generation}
+	b.order = append(b.order, key)
+	b.gauge.Set(float64(len(b.order)))
+}
+
+// Remove drops the buffered report of the subject of the payload
+func (b *ReportBuffer) Remove(payload controlplane.AgentPayload) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.removeKey(reportKey(payload))
+	b.gauge.Set(float64(len(b.order)))
+}
+
+func (b *ReportBuffer) removeKey(key string) {
+	if _, ok := b.reports[key]; !ok {
+		return
+	}
+	delete(b.reports, key)
+	for i, k := range b.order {
+		if k == key {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Len returns the number of buffered reports
+func (b *ReportBuffer) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.order)
+}
+
+// pending returns the buffered reports from the least to the most recently updated
+func (b *ReportBuffer) pending() []bufferedReport {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	reports := make([]bufferedReport, 0, len(b.order))
+	for _, key := range b.order {
+		reports = append(reports, b.reports[key])
+	}
+	return reports
+}
+
+// sent drops a report that was sent unless a newer report of the subject was added meanwhile
+func (b *ReportBuffer) sent(report bufferedReport) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := reportKey(report.payload)
+	if r, ok := b.reports[key]; ok && r.generation == report.generation {
+		b.removeKey(key)
+	}
+	b.gauge.Set(float64(len(b.order)))
+}
+
+// Wake flushes the buffer without waiting for the next interval, e.g. once the control plane is reachable again
+func (b *ReportBuffer) Wake() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// FlushReports sends the buffered reports at every interval, or when the buffer is woken up, until the context is
+// done. The flush stops at the first report that fails to be sent since the control plane is still unreachable
+func (r *VersionTrackerReconciler) FlushReports(ctx context.Context, interval time.Duration) error {
+	log := r.Log.WithName("buffer")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.Buffer.wake:
+		}
+		reports := r.Buffer.pending()
+		if len(reports) == 0 {
+			continue
+		}
+		log.Info("sending the buffered version info to the control plane", "buffered", len(reports))
+		for _, report := range reports {
+			if err := r.send(log, report.payload); err != nil {
+				log.Error(err, "control plane still unreachable", "buffered", r.Buffer.Len())
+				break
+			}
+			r.Buffer.sent(report)
+		}
+	}
+}
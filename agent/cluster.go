@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterVersionID is the ID of the subject of the API server version
+	ClusterVersionID = "kubernetes"
+	// ClusterVersionKind is the resource kind of the API server version
+	ClusterVersionKind = "APIServer"
+	// clusterVersionPattern extracts the version from the API server version (e.g. v1.21.2-eks-06eac09)
+	clusterVersionPattern = `^v?([0-9]+\.[0-9]+\.[0-9]+).*$`
+)
+
+// ClusterVersionConfig is the remote version of the API server version subject
+type ClusterVersionConfig struct {
+	// Report the API server version
+	Enabled bool
+	// Provider of the upstream versions (e.g. kubernetes, gke or eks)
+	Provider string
+	// Strategy of the provider (e.g. stable, channels or versions)
+	Strategy string
+	// Repo of the provider (e.g. a release bucket mirror, a GKE project/location or an EKS region)
+	Repo string
+}
+
+// GetClusterVersion returns the version of the API server of the cluster as a subject
+func (r *VersionTrackerReconciler) GetClusterVersion(cluster Cluster) (SubjectVersion, error) {
+	info, err := cluster.Discovery.ServerVersion()
+	if err != nil {
+		return SubjectVersion{}, err
+	}
+	conf := r.conf().ClusterVersion
+	sv := SubjectVersion{
+		AgentID:            cluster.ID,
+		ID:                 ClusterVersionID,
+		Namespace:          metav1.NamespaceSystem,
+		TotalResourceCount: 1,
+		RemoteVersion: v1alpha1.RemoteVersion{
+			Provider: conf.Provider,
+			Strategy: v1alpha1.RemoteStrategy(conf.Strategy),
+			Repo:     conf.Repo,
+			Extraction: v1alpha1.Extraction{
+				Regex: v1alpha1.Regex{
+					Pattern: clusterVersionPattern,
+					Result:  "$1",
+				},
+			},
+		},
+	}
+	version := GetResultsFromRegex(clusterVersionPattern, "$1", info.GitVersion)
+	if version != "" {
+		sv.UniqVersions = []string{version}
+		sv.Versions = []*Version{{
+			Version:       version,
+			ResourceCount: 1,
+			ResourceKind:  ClusterVersionKind,
+			ExtractedFrom: info.GitVersion,
+		}}
+	}
+	return sv, nil
+}
+
+// ReportClusterVersion ships the API server version to the control plane at every interval until the context is done.
+// It is run by the manager next to the reconciler so no VersionTracker is needed for the cluster version
+func (r *VersionTrackerReconciler) ReportClusterVersion(ctx context.Context) error {
+	log := r.Log.WithName("cluster-version")
+	interval := r.conf().Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// the interval can be changed by a reload of the config file
+		if conf := r.conf(); conf.Interval != interval {
+			interval = conf.Interval
+			ticker.Reset(interval)
+		}
+		for _, cluster := range r.Clusters {
+			if !r.Budget.Take(1, interval) {
+				log.Info("API call budget exhausted. the api server version is reported in the next interval", "cluster", cluster.ID)
+				continue
+			}
+			sv, err := r.GetClusterVersion(cluster)
+			if err != nil {
+				log.Error(err, "failed to get the api server version", "cluster", cluster.ID)
+				reconciliationErrorsTotal.Inc()
+			} else if len(sv.Versions) == 0 {
+				log.Error(fmt.Errorf("api server version did not match %s", clusterVersionPattern), "extraction failed", "cluster", cluster.ID)
+				reconciliationErrorsTotal.Inc()
+			} else if r.conf().Standalone {
+				r.exportVersions(seriesKey(cluster.ID, ClusterVersionID), sv)
+			} else if r.reporting() {
+				if err := r.ShipToControlPlane(sv); err != nil {
+					log.Error(err, "failed to ship the api server version to control plane", "cluster", cluster.ID)
+					reconciliationErrorsTotal.Inc()
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
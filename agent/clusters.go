@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+// Cluster is a cluster the agent tracks the resources of. The versions of each cluster are reported to the control
+// plane with the identifier of the cluster as the agent identifier
+type Cluster struct {
+	// Agent identifier of the cluster
+	ID string
+	// Client to list the resources. It reads from the informers of the cache
+	Client client.Reader
+	// SecretsReader lists the Secrets from the API server, so the Secrets of the whole cluster are not cached. The
+	// Client is used when it is not set
+	SecretsReader client.Reader
+	// Cache of the informers of the resources, which are watched for changes
+	Cache cache.Cache
+	// Dynamic client to list the custom resources
+	Dynamic dynamic.Interface
+	// Discovery client to get the API server version
+	Discovery discovery.ServerVersionInterface
+}
+
+// NewCluster creates the clients of a cluster from its rest config. The cache must be started (e.g. added to the
+// manager) before the resources are listed
+func NewCluster(id string, config *rest.Config, scheme *runtime.Scheme) (Cluster, error) {
+	c, err := cluster.New(config, func(o *cluster.Options) {
+		o.Scheme = scheme
+	})
+	if err != nil {
+		return Cluster{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return Cluster{}, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return Cluster{}, err
+	}
+	return Cluster{
+		ID:            id,
+		Client:        c.GetClient(),
+		SecretsReader: c.GetAPIReader(),
+		Cache:         c.GetCache(),
+		Dynamic:       dynamicClient,
+		Discovery:     discoveryClient,
+	}, nil
+}
+
+// NewRemoteCluster creates the clients of a cluster from a kubeconfig. The kubeconfig can be followed by the context
+// to use (e.g. /etc/opvic/kubeconfig#staging), otherwise the current context of the kubeconfig is used. The clients
+// are rate limited to qps requests per second with bursts of burst requests
+func NewRemoteCluster(id, kubeconfig string, scheme *runtime.Scheme, qps float32, burst int) (Cluster, error) {
+	path, context := kubeconfig, ""
+	if i := strings.LastIndex(kubeconfig, "#"); i >= 0 {
+		path, context = kubeconfig[:i], kubeconfig[i+1:]
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: path},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+	if err != nil {
+		return Cluster{}, fmt.Errorf("invalid kubeconfig of cluster %s: %v", id, err)
+	}
+	config.QPS, config.Burst = qps, burst
+	return NewCluster(id, config, scheme)
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// FileConfig is the configuration of the agent read from a file (e.g. a mounted ConfigMap). It overrides the flags
+// and is reloaded when the file changes, without restarting the agent. Unset fields keep the value of the flags
+type FileConfig struct {
+	// The interval between individual synchronizations
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Url of Control Plane API
+	ControlPlaneURL string `json:"controlPlaneURL,omitempty"`
+	// Tags of the agent
+	Tags map[string]string `json:"tags,omitempty"`
+	// Label selector of the VersionTrackers reconciled by the agent (e.g. team=platform,tier!=test)
+	Selector string `json:"selector,omitempty"`
+}
+
+// LoadConfigFile reads and validates a config file
+func LoadConfigFile(path string) (*FileConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fc FileConfig
+	if err := yaml.UnmarshalStrict(data, &fc); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	if fc.Interval != nil && fc.Interval.Duration <= 0 {
+		return nil, fmt.Errorf("invalid config file %s: interval must be positive", path)
+	}
+	if _, err := labels.Parse(fc.Selector); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return &fc, nil
+}
+
+// Apply returns the config with the fields set in the config file
+func (fc *FileConfig) Apply(conf Config) *Config {
+	if fc.Interval != nil {
+		conf.Interval = fc.Interval.Duration
+	}
+	// the standalone agents report nothing to the control plane
+	if fc.ControlPlaneURL != "" && !conf.Standalone {
+		conf.ControlPlaneUrl = fc.ControlPlaneURL
+	}
+	if fc.Tags != nil {
+		conf.Tags = fc.Tags
+	}
+	if fc.Selector != "" {
+		// validated when the file is loaded
+		conf.Selector, _ = labels.Parse(fc.Selector)
+	}
+	return &conf
+}
+
+// conf returns the current config of the agent, i.e. the config of the flags with the config file applied
+func (r *VersionTrackerReconciler) conf() *Config {
+	if conf, ok := r.live.Load().(*Config); ok {
+		return conf
+	}
+	return r.Config
+}
+
+// ApplyConfigFile applies a config file to the config of the flags
+func (r *VersionTrackerReconciler) ApplyConfigFile(fc *FileConfig) {
+	conf := fc.Apply(*r.Config)
+	previous := r.conf()
+	r.live.Store(conf)
+	if r.Stream != nil && conf.ControlPlaneUrl != previous.ControlPlaneUrl {
+		r.Stream.SetControlPlaneURL(conf.ControlPlaneUrl)
+	}
+}
+
+// WatchConfigFile reloads the config file when it changes until the context is done. An invalid config file is
+// ignored and the previous config is kept
+func (r *VersionTrackerReconciler) WatchConfigFile(ctx context.Context, path string, interval time.Duration) error {
+	log := r.Log.WithName("config").WithValues("file", path)
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Error(err, "failed to read the config file")
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+		fc, err := LoadConfigFile(path)
+		if err != nil {
+			log.Error(err, "failed to reload the config file. keeping the previous config")
+			reconciliationErrorsTotal.Inc()
+			continue
+		}
+		r.ApplyConfigFile(fc)
+		conf := r.conf()
+		log.Info("reloaded the config file", "interval", conf.Interval, "controlPlaneURL", conf.ControlPlaneUrl, "selector", fc.Selector)
+		// the VersionTrackers are reconciled again with the new config
+		if r.Refresh != nil {
+			go r.RefreshAll(ctx)
+		}
+	}
+}
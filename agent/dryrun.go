@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/go-logr/logr"
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+)
+
+// reporting returns true if the versions are reported to the control plane, or only logged in dry run
+func (r *VersionTrackerReconciler) reporting() bool {
+	conf := r.conf()
+	return conf.ControlPlaneUrl != "" || conf.DryRun
+}
+
+// dryRun logs the payload that would be sent to the control plane instead of sending it. The payload is
+// appended to the dry run output file as a JSON line when it is set
+func (r *VersionTrackerReconciler) dryRun(log logr.Logger, payload controlplane.AgentPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	log.Info("dry run. the version info is not sent to the control plane", "payload", string(data))
+	output := r.conf().DryRunOutput
+	if output == "" {
+		return nil
+	}
+	r.dryRunMutex.Lock()
+	defer r.dryRunMutex.Unlock()
+	f, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
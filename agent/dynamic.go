@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+)
+
+// GetCustomResources lists the custom resources of the VersionTracker with the dynamic client. The items are the
+// unstructured objects so the version can be extracted with any jsonpath (e.g. .spec.version)
+func GetCustomResources(ctx context.Context, dynamicClient dynamic.Interface, v v1alpha1.VersionTracker, selector labels.Selector, namespaces []string) ([]interface{}, error) {
+	resource := dynamicClient.Resource(v.GetCustomResource().GroupVersionResource())
+	opts := metav1.ListOptions{LabelSelector: selector.String()}
+	var items []interface{}
+	for _, ns := range namespaces {
+		list, err := resource.Namespace(ns).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			items = append(items, item.Object)
+		}
+	}
+	return items, nil
+}
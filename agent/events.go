@@ -0,0 +1,14 @@
+package agent
+
+import (
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+)
+
+// event records an event on the VersionTracker so the failures are visible with kubectl describe. The events are
+// only recorded when the reconciler has a recorder
+func (r *VersionTrackerReconciler) event(v *v1alpha1.VersionTracker, eventtype, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(v, eventtype, reason, message)
+}
@@ -3,16 +3,20 @@ package agent
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/skillz/opvic/agent/api/v1alpha1"
 	"github.com/skillz/opvic/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type SubjectVersion struct {
+	// Identifier of the agent of the cluster the versions are from. The agent identifier is used when empty
+	AgentID            string
 	ID                 string
 	Namespace          string
 	TotalResourceCount int
@@ -26,12 +30,16 @@ type Version struct {
 	ResourceKind  string
 	ExtractedFrom string
 	Version       string
+	// Repository and digest of the image when the version is extracted from an image
+	Image  string
+	Digest string
 }
 
 // ExtractSubjectVersion looks at the feild of each individuel resource and extracts the version
 // based on the extraction configuration in the VersionTracker
 func (r *VersionTrackerReconciler) ExtractSubjectVersion(v v1alpha1.VersionTracker, items []interface{}) SubjectVersion {
-	log := r.Log.WithName("extractor").WithValues("VersionTracker", fmt.Sprintf("%s/%s", v.ObjectMeta.Namespace, v.ObjectMeta.Name))
+	tracker := fmt.Sprintf("%s/%s", v.ObjectMeta.Namespace, v.ObjectMeta.Name)
+	log := r.Log.WithName("extractor").WithValues("VersionTracker", tracker)
 	var version string
 	var versions []string
 	uniqueVersions := []string{}
@@ -48,37 +56,72 @@ func (r *VersionTrackerReconciler) ExtractSubjectVersion(v v1alpha1.VersionTrack
 		RemoteVersion: v.Spec.RemoteVersion,
 	}
 
+	// the failures are summarized in a single event
+	failures := 0
+	var firstErr error
+	failed := func(err error) {
+		reconciliationErrorsTotal.Inc()
+		extractionFailuresTotal.WithLabelValues(tracker).Inc()
+		if failures++; firstErr == nil {
+			firstErr = err
+		}
+	}
+
 	log.V(1).Info("resource count", "count", len(items))
+	imageSelectors := v.ImageFieldSelectors()
 	for _, i := range items {
 		valueStrings, err := getFeilds(lv.FieldSelector, i)
 		if err != nil {
 			log.Error(err, "failed to get fields from the resource")
-			reconciliationErrorsTotal.Inc()
+			failed(err)
 			continue
 		}
-		if len(valueStrings) == 0 || len(valueStrings) > 1 {
-			log.Error(fmt.Errorf("jsonpath returned unexpected number of values: %d", len(valueStrings)), "unexpected number of values", "fieldSelector", lv.FieldSelector)
-			reconciliationErrorsTotal.Inc()
-			continue
+		if len(imageSelectors) > 0 {
+			valueStrings, err = getImages(valueStrings, imageSelectors, lv.Container != "", i)
+			if err != nil {
+				log.Error(err, "failed to get the images from the resource")
+				failed(err)
+				continue
+			}
 		}
-		fieldValue := valueStrings[0]
-		version = GetResultsFromRegex(lv.Extraction.Regex.Pattern, lv.Extraction.Regex.Result, fieldValue)
-		if version == "" {
-			log.Error(fmt.Errorf("failed to extract version from: %s", fieldValue), "extraction failed", "regex", lv.Extraction.Regex.Pattern, "result template", lv.Extraction.Regex.Result)
-			reconciliationErrorsTotal.Inc()
+		if len(valueStrings) == 0 || (len(valueStrings) > 1 && len(imageSelectors) == 0) {
+			err := fmt.Errorf("jsonpath returned unexpected number of values: %d", len(valueStrings))
+			log.Error(err, "unexpected number of values", "fieldSelector", lv.FieldSelector)
+			failed(err)
 			continue
 		}
+		// the versions of the images of a resource are counted once
+		var resourceVersions []string
+		for _, fieldValue := range valueStrings {
+			version = GetResultsFromRegex(lv.Extraction.Regex.Pattern, lv.Extraction.Regex.Result, fieldValue)
+			if version == "" {
+				err := fmt.Errorf("failed to extract version from: %s", fieldValue)
+				log.Error(err, "extraction failed", "regex", lv.Extraction.Regex.Pattern, "result template", lv.Extraction.Regex.Result)
+				failed(err)
+				continue
+			}
 
-		// add the version to the list of unique versions if it's not already there
-		if !utils.Contains(uniqueVersions, version) {
-			uniqueVersions = append(uniqueVersions, version)
-			appVersion.Versions = append(appVersion.Versions, &Version{
-				Version:       version,
-				ExtractedFrom: fieldValue,
-				ResourceKind:  v.GetResourceKind(),
-			})
-		}
-		versions = append(versions, version)
+			// add the version to the list of unique versions if it's not already there
+			if !utils.Contains(uniqueVersions, version) {
+				uniqueVersions = append(uniqueVersions, version)
+				ver := &Version{
+					Version:       version,
+					ExtractedFrom: fieldValue,
+					ResourceKind:  v.GetResourceKind(),
+				}
+				if lv.Strategy == v1alpha1.ImageTag {
+					ver.Image, ver.Digest = imageDigest(i, fieldValue)
+				}
+				appVersion.Versions = append(appVersion.Versions, ver)
+			}
+			if !utils.Contains(resourceVersions, version) {
+				resourceVersions = append(resourceVersions, version)
+			}
+		}
+		versions = append(versions, resourceVersions...)
+	}
+	if failures > 0 {
+		r.event(&v, corev1.EventTypeWarning, ReasonExtractionFailed, fmt.Sprintf("failed to extract %d version(s) of %s: %v", failures, v.Spec.Name, firstErr))
 	}
 	appVersion.TotalResourceCount = len(items)
 	appVersion.UniqVersions = uniqueVersions
@@ -101,6 +144,55 @@ func (r *VersionTrackerReconciler) ExtractSubjectVersion(v v1alpha1.VersionTrack
 	return *appVersion
 }
 
+// FilterFinishedJobs removes the Jobs that finished before the lookback. Running Jobs and the other
+// resources are kept
+func FilterFinishedJobs(items []interface{}, lookback time.Duration) []interface{} {
+	since := time.Now().Add(-lookback)
+	var filtered []interface{}
+	for _, item := range items {
+		job, ok := item.(batchv1.Job)
+		if !ok {
+			filtered = append(filtered, item)
+			continue
+		}
+		if finished := jobFinishedAt(job); finished == nil || finished.After(since) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// jobFinishedAt returns when the Job completed or failed and nil if it is still running
+func jobFinishedAt(job batchv1.Job) *time.Time {
+	if job.Status.CompletionTime != nil {
+		return &job.Status.CompletionTime.Time
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return &c.LastTransitionTime.Time
+		}
+	}
+	return nil
+}
+
+// secretItem returns the Secret with its data decoded so the versions can be extracted from the keys
+// like the ConfigMaps. The item is unstructured so the labels can be read like the ones of the typed resources
+func secretItem(secret corev1.Secret) map[string]interface{} {
+	data := make(map[string]interface{}, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	metadata, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&secret.ObjectMeta)
+	if err != nil {
+		metadata = map[string]interface{}{"name": secret.Name, "namespace": secret.Namespace}
+	}
+	return map[string]interface{}{
+		"metadata": metadata,
+		"type":     string(secret.Type),
+		"data":     data,
+	}
+}
+
 // Returns the list of items from the resources based on the resource type
 func GetItems(resources client.ObjectList) []interface{} {
 	var items []interface{}
@@ -153,6 +245,18 @@ func GetItems(resources client.ObjectList) []interface{} {
 			items[i] = item
 		}
 		return items
+	case *corev1.ConfigMapList:
+		items = make([]interface{}, len(resources.(*corev1.ConfigMapList).Items))
+		for i, item := range resources.(*corev1.ConfigMapList).Items {
+			items[i] = item
+		}
+		return items
+	case *corev1.SecretList:
+		items = make([]interface{}, len(resources.(*corev1.SecretList).Items))
+		for i, item := range resources.(*corev1.SecretList).Items {
+			items[i] = secretItem(item)
+		}
+		return items
 	}
 	return nil
 }
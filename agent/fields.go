@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// itemFields exposes the fields of a tracked item to a field selector. The fields are the dot separated paths of
+// the item (e.g. status.phase)
+type itemFields map[string]interface{}
+
+func (f itemFields) Has(field string) bool {
+	_, found, err := unstructured.NestedFieldNoCopy(f, strings.Split(field, ".")...)
+	return err == nil && found
+}
+
+func (f itemFields) Get(field string) string {
+	value, found, err := unstructured.NestedFieldNoCopy(f, strings.Split(field, ".")...)
+	if err != nil || !found || value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// toItemFields returns the fields of a typed resource or of an unstructured item. The unstructured items must only
+// hold JSON values (e.g. secretItem) for their nested fields to be found
+func toItemFields(item interface{}) (itemFields, error) {
+	if m, ok := item.(map[string]interface{}); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FilterByFields keeps the items matching the field selector. Unlike the field selectors of the API server, any
+// field of the items can be selected, which also works with the informers the resources are listed from
+func FilterByFields(items []interface{}, fieldSelector string) ([]interface{}, error) {
+	selector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	if selector.Empty() {
+		return items, nil
+	}
+	var filtered []interface{}
+	for _, item := range items {
+		f, err := toItemFields(item)
+		if err != nil {
+			return nil, err
+		}
+		if selector.Matches(f) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// statusError is the error of a request rejected by the control plane
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d status: %s", e.code, e.status)
+}
+
+// reportHealth tracks the outcome of the last reports sent to the control plane
+type reportHealth struct {
+	mutex sync.Mutex
+	// consecutive failed reports
+	failures int
+	// error of the last failed report
+	err error
+	// the last report was rejected by the authentication of the control plane
+	unauthorized bool
+}
+
+// record saves the outcome of a report
+func (h *reportHealth) record(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	defer func() { consecutiveReportFailures.Set(float64(h.failures)) }()
+	if err == nil {
+		h.failures, h.err, h.unauthorized = 0, nil, false
+		return
+	}
+	h.failures++
+	h.err = err
+	h.unauthorized = false
+	if se, ok := err.(*statusError); ok {
+		h.unauthorized = se.code == http.StatusUnauthorized || se.code == http.StatusForbidden
+	}
+}
+
+// ReadyzCheck fails when the authentication to the control plane is failing or when the last reports failed, so the
+// agent is reported unready while nothing gets to the control plane
+func (r *VersionTrackerReconciler) ReadyzCheck(_ *http.Request) error {
+	conf := r.conf()
+	if conf.ControlPlaneUrl == "" {
+		return nil
+	}
+	h := &r.health
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.unauthorized {
+		return fmt.Errorf("authentication to the control plane is failing: %v", h.err)
+	}
+	if conf.MaxReportFailures > 0 && h.failures >= conf.MaxReportFailures {
+		return fmt.Errorf("the last %d reports to the control plane failed: %v", h.failures, h.err)
+	}
+	return nil
+}
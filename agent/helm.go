@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gzip magic header of the compressed helm releases
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// decodeHelmRelease decodes a Helm v3 release stored in a Secret. The release is a base64 encoded and gzipped JSON
+// document with the name, namespace and revision of the release and the metadata of the chart
+// (e.g. .chart.metadata.version and .chart.metadata.appVersion)
+func decodeHelmRelease(secret corev1.Secret) (map[string]interface{}, error) {
+	data, err := base64.StdEncoding.DecodeString(string(secret.Data["release"]))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		data, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var release map[string]interface{}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// GetHelmReleases decodes the Helm releases of the Secrets. Secrets that are not valid releases are skipped
+func (r *VersionTrackerReconciler) GetHelmReleases(secrets *corev1.SecretList) []interface{} {
+	var items []interface{}
+	for _, secret := range secrets.Items {
+		release, err := decodeHelmRelease(secret)
+		if err != nil {
+			r.Log.Error(err, "failed to decode helm release", "secret", secret.Namespace+"/"+secret.Name)
+			reconciliationErrorsTotal.Inc()
+			continue
+		}
+		items = append(items, release)
+	}
+	return items
+}
@@ -0,0 +1,107 @@
+package host
+
+import (
+	"context"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// DefaultContainerdSocket is the socket of the containerd daemon
+const DefaultContainerdSocket = "/run/containerd/containerd.sock"
+
+// ContainerdClient lists the containers of a host from the containerd API, e.g. the containers of nerdctl. The
+// containers of all the namespaces are listed unless a namespace is set, so the containers of Kubernetes (k8s.io
+// namespace) are listed too when the host is a node
+type ContainerdClient struct {
+	client    *containerd.Client
+	namespace string
+}
+
+// NewContainerdClient connects to the socket of containerd. The containers of all the namespaces are listed when
+// namespace is empty
+func NewContainerdClient(socket, namespace string) (*ContainerdClient, error) {
+	client, err := containerd.New(socket, containerd.WithTimeout(10*time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerdClient{client: client, namespace: namespace}, nil
+}
+
+// Ping checks the socket serves the containerd API
+func (c *ContainerdClient) Ping(ctx context.Context) error {
+	_, err := c.client.Version(ctx)
+	return err
+}
+
+// ListContainers returns the running containers of the namespaces
+func (c *ContainerdClient) ListContainers(ctx context.Context) ([]Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	nss := []string{c.namespace}
+	if c.namespace == "" {
+		var err error
+		if nss, err = c.client.NamespaceService().List(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var containers []Container
+	for _, ns := range nss {
+		nsContainers, err := c.listNamespace(namespaces.WithNamespace(ctx, ns))
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, nsContainers...)
+	}
+	return containers, nil
+}
+
+// listNamespace returns the running containers of the namespace of the context. The containers without a task,
+// i.e. created but not started, or whose task is not running are skipped like the stopped Docker containers
+func (c *ContainerdClient) listNamespace(ctx context.Context) ([]Container, error) {
+	list, err := c.client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var containers []Container
+	for _, cont := range list {
+		info, err := cont.Info(ctx, containerd.WithoutRefreshedMetadata)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		task, err := cont.Task(ctx, nil)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		status, err := task.Status(ctx)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if status.Status != containerd.Running {
+			continue
+		}
+		container := Container{
+			ID:     info.ID,
+			Names:  []string{info.ID},
+			Image:  info.Image,
+			Labels: info.Labels,
+			State:  string(status.Status),
+		}
+		if image, err := c.client.GetImage(ctx, info.Image); err == nil {
+			container.ImageID = image.Target().Digest.String()
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
@@ -0,0 +1,82 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultDockerSocket is the socket of the Docker daemon
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// Container is a container listed by the Docker Engine API, or by containerd with the same fields
+type Container struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	ImageID string            `json:"ImageID"`
+	Labels  map[string]string `json:"Labels"`
+	State   string            `json:"State"`
+}
+
+// DockerClient lists the containers of a host from a socket serving the Docker Engine API, e.g. the socket of the
+// Docker daemon or the Docker compatible socket of Podman
+type DockerClient struct {
+	client *http.Client
+}
+
+func NewDockerClient(socket string) *DockerClient {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return &DockerClient{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Ping checks the socket serves the Docker Engine API
+func (c *DockerClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://docker/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// ListContainers returns the running containers
+func (c *DockerClient) ListContainers(ctx context.Context) ([]Container, error) {
+	// the host is ignored by the socket
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://docker/containers/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var containers []Container
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
@@ -0,0 +1,208 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/skillz/opvic/agent"
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Labels of the containers overriding the subject of their image and its remote version
+const (
+	NameLabel     = "opvic.skillz.com/name"
+	ProviderLabel = "opvic.skillz.com/provider"
+	StrategyLabel = "opvic.skillz.com/strategy"
+	RepoLabel     = "opvic.skillz.com/repo"
+	RegexLabel    = "opvic.skillz.com/regex"
+)
+
+const (
+	// Namespace of the subjects of the hosts
+	Namespace = "host"
+	// ResourceKind of the versions of the containers
+	ResourceKind = "Containers"
+	// DefaultRegex extracts a semantic version from the image tags (e.g. 1.21.3 of 1.21.3-alpine)
+	DefaultRegex = `^v?([0-9]+\.[0-9]+\.[0-9]+)`
+	// ociProvider is the provider of the tags of the image repositories. The providers of the control plane are not
+	// imported so their SDKs are not built in the host agent
+	ociProvider = "oci"
+)
+
+// Runtime lists the containers of a host, e.g. the Docker daemon or containerd
+type Runtime interface {
+	// Ping checks the socket serves the API of the runtime
+	Ping(ctx context.Context) error
+	// ListContainers returns the running containers
+	ListContainers(ctx context.Context) ([]Container, error)
+}
+
+// Config of the host reporter
+type Config struct {
+	// Agent identifier of the host
+	ID string
+	// Interval between the reports
+	Interval time.Duration
+	// Tags of the agent
+	Tags map[string]string
+	// Regex extracting the version from the image tags, unless set by the label of the container
+	Regex string
+}
+
+// Reporter reports the versions of the images of the containers of a host to the control plane, for the hosts out of
+// Kubernetes (e.g. bastions and CI runners). The containers are grouped by image repository and the remote versions
+// are the tags of the repository unless the labels of the containers set them
+type Reporter struct {
+	Config  Config
+	Runtime Runtime
+	Shipper *agent.Shipper
+	Log     logr.Logger
+}
+
+// Start reports the versions at every interval until the context is done
+func (r *Reporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Report(ctx); err != nil {
+			r.Log.Error(err, "failed to report the container versions")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Report lists the containers and sends their versions to the control plane in a single request
+func (r *Reporter) Report(ctx context.Context) error {
+	containers, err := r.Runtime.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the containers: %v", err)
+	}
+	payloads := r.Payloads(containers)
+	if len(payloads) == 0 {
+		r.Log.Info("no container versions to report", "containers", len(containers))
+		return nil
+	}
+	if err := r.Shipper.PostBatch(payloads); err != nil {
+		return err
+	}
+	r.Log.Info("successfully sent the container versions to the control plane", "subjects", len(payloads))
+	return nil
+}
+
+// Payloads returns the payloads of the subjects of the containers. The containers whose version can not be
+// extracted from the image tag are skipped
+func (r *Reporter) Payloads(containers []Container) []controlplane.AgentPayload {
+	subjects := map[string]*controlplane.SubjectVersion{}
+	for _, c := range containers {
+		repo, tag := splitImage(c.Image)
+		regex := r.Config.Regex
+		if c.Labels[RegexLabel] != "" {
+			regex = c.Labels[RegexLabel]
+		}
+		if _, err := regexp.Compile(regex); err != nil {
+			r.Log.Error(err, "invalid version regex", "container", c.ID, "regex", regex)
+			continue
+		}
+		version := utils.GetResultsFromRegex(regex, "$1", tag)
+		if version == "" {
+			r.Log.V(1).Info("no version in the image tag", "container", c.ID, "image", c.Image, "regex", regex)
+			continue
+		}
+		// the repositories of the same name on other registries or organizations are distinct subjects
+		id := c.Labels[NameLabel]
+		if id == "" {
+			id = repo
+		}
+		id = agent.SubjectID(id)
+		if id == "" {
+			r.Log.V(1).Info("no subject ID for the container", "container", c.ID, "image", c.Image)
+			continue
+		}
+		sv, ok := subjects[id]
+		if !ok {
+			sv = &controlplane.SubjectVersion{
+				ID:            id,
+				NameSpace:     Namespace,
+				RemoteVersion: remoteVersion(c, repo, regex),
+			}
+			subjects[id] = sv
+		}
+		sv.ResourceCount++
+		addVersion(sv, version, repo, c.Image)
+	}
+	ids := make([]string, 0, len(subjects))
+	for id := range subjects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var payloads []controlplane.AgentPayload
+	for _, id := range ids {
+		payloads = append(payloads, controlplane.AgentPayload{
+			AgentID:   r.Config.ID,
+			AgentTags: r.Config.Tags,
+			Version:   *subjects[id],
+		})
+	}
+	return payloads
+}
+
+// addVersion counts a container running the version
+func addVersion(sv *controlplane.SubjectVersion, version, repo, image string) {
+	for i := range sv.Versions {
+		if sv.Versions[i].RunningVersion == version {
+			sv.Versions[i].ResourceCount++
+			return
+		}
+	}
+	sv.RunningVersions = append(sv.RunningVersions, version)
+	sv.Versions = append(sv.Versions, controlplane.Version{
+		RunningVersion: version,
+		ResourceCount:  1,
+		ResourceKind:   ResourceKind,
+		ExtractedFrom:  image,
+		Image:          repo,
+	})
+}
+
+// remoteVersion returns the remote version of the subject of a container. The tags of the image repository are
+// used unless the provider is set by the labels of the container
+func remoteVersion(c Container, repo, regex string) v1alpha1.RemoteVersion {
+	rv := v1alpha1.RemoteVersion{
+		Provider: ociProvider,
+		Strategy: v1alpha1.OCIStrategyTags,
+		Repo:     repo,
+		Extraction: v1alpha1.Extraction{
+			Regex: v1alpha1.Regex{Pattern: regex, Result: "$1"},
+		},
+	}
+	if c.Labels[ProviderLabel] != "" {
+		rv.Provider = c.Labels[ProviderLabel]
+		rv.Strategy = v1alpha1.RemoteStrategy(c.Labels[StrategyLabel])
+		rv.Repo = c.Labels[RepoLabel]
+	}
+	return rv
+}
+
+// splitImage returns the repository and the tag of an image (e.g. nginx and 1.21.3 of nginx:1.21.3). The tag is
+// latest when the image has none and empty when it is pinned by digest
+func splitImage(image string) (repo, tag string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], ""
+	}
+	// the tag follows the last colon after the registry host and port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imageDigest returns the repository and the digest of the image of a resource. The digest is the one of an image
+// pinned by digest (e.g. nginx@sha256:...) or, for the pods, the digest of the image the container runs
+func imageDigest(item interface{}, image string) (repo string, digest string) {
+	repo = image
+	if i := strings.Index(repo, "@"); i >= 0 {
+		repo, digest = repo[:i], repo[i+1:]
+	}
+	// the tag follows the last colon after the registry host and port
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	if digest != "" {
+		return repo, digest
+	}
+	pod, ok := item.(corev1.Pod)
+	if !ok {
+		return repo, ""
+	}
+	names := map[string]bool{}
+	for _, c := range pod.Spec.Containers {
+		names[c.Name] = c.Image == image
+	}
+	for _, c := range pod.Spec.InitContainers {
+		names[c.Name] = c.Image == image
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		names[c.Name] = c.Image == image
+	}
+	statuses := append(append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...), pod.Status.EphemeralContainerStatuses...)
+	for _, status := range statuses {
+		// e.g. docker-pullable://nginx@sha256:... or docker.io/library/nginx@sha256:...
+		if i := strings.Index(status.ImageID, "@"); names[status.Name] && i >= 0 {
+			return repo, status.ImageID[i+1:]
+		}
+	}
+	return repo, ""
+}
+
+// getImages adds the images of the init containers and the ephemeral containers to the images of the containers.
+// Only the first image is kept when the container is selected by name since it is in one of the lists
+func getImages(images []string, selectors []string, named bool, item interface{}) ([]string, error) {
+	for _, selector := range selectors {
+		values, err := getFeilds(selector, item)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, values...)
+	}
+	if named && len(images) > 1 {
+		return images[:1], nil
+	}
+	return images, nil
+}
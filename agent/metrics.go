@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -35,12 +37,136 @@ var (
 			Help:      "Duration of last reconciliation",
 		},
 	)
+	bufferedReports = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "buffered_reports",
+			Help:      "Number of reports waiting for the control plane to be reachable",
+		},
+	)
+	bufferedReportsDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "buffered_reports_dropped_total",
+			Help:      "Number of buffered reports dropped because the buffer was full",
+		},
+	)
+	mirrorBufferedReports = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "mirror_buffered_reports",
+			Help:      "Number of reports waiting to be sent to an additional control plane",
+		},
+		[]string{"controlplane"},
+	)
+	mirrorReportFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "mirror_report_failures_total",
+			Help:      "Number of failed reports to an additional control plane",
+		},
+		[]string{"controlplane"},
+	)
+	versionResourceCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "version_resource_count",
+			Help:      "Number of resources running with a specific version. Only exported by the standalone agents",
+		},
+		[]string{"version_id", "namespace", "agent_id", "running_version", "resource_kind", "extracted_from"},
+	)
+	trackerMatchedResources = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "tracker_matched_resources",
+			Help:      "Number of resources matched by a VersionTracker in a cluster at the last reconciliation",
+		},
+		[]string{"versiontracker", "cluster"},
+	)
+	extractionFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "extraction_failures_total",
+			Help:      "Number of resources of a VersionTracker the version could not be extracted from",
+		},
+		[]string{"versiontracker"},
+	)
+	reportDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "report_duration_seconds",
+			Help:      "Duration of the reports to the control plane by transport (stream, rest or batch) and result (success or error)",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"transport", "result"},
+	)
+	reportPayloadBytes = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "report_payload_bytes",
+			Help:      "Size of the reports sent to the control plane, after compression",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		},
+	)
+	apiBudgetExhaustedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "api_budget_exhausted_total",
+			Help:      "Number of API calls deferred to the next interval because the API call budget was exhausted",
+		},
+	)
+	consecutiveReportFailures = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "consecutive_report_failures",
+			Help:      "Number of consecutive failed reports to the control plane",
+		},
+	)
 )
 
+// forgetTrackerMetrics deletes the series of a VersionTracker that is deleted or no longer reconciled by the agent
+func (r *VersionTrackerReconciler) forgetTrackerMetrics(tracker string) {
+	for _, cluster := range r.Clusters {
+		trackerMatchedResources.DeleteLabelValues(tracker, cluster.ID)
+	}
+	extractionFailuresTotal.DeleteLabelValues(tracker)
+}
+
 func init() {
 	metrics.Registry.MustRegister(
 		reconciliationErrorsTotal,
 		lastReconciliationTimestamp,
 		reconciliationDuration,
+		bufferedReports,
+		bufferedReportsDroppedTotal,
+		mirrorBufferedReports,
+		mirrorReportFailuresTotal,
+		versionResourceCount,
+		trackerMatchedResources,
+		extractionFailuresTotal,
+		reportDuration,
+		reportPayloadBytes,
+		consecutiveReportFailures,
+		apiBudgetExhaustedTotal,
 	)
 }
+
+// observeReport observes the duration of a report attempt, failed or not
+func observeReport(transport string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reportDuration.WithLabelValues(transport, result).Observe(time.Since(start).Seconds())
+}
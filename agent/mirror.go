@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+)
+
+// DefaultMirrorBufferSize is the number of reports kept for a mirror when the buffer of the agent is disabled
+const DefaultMirrorBufferSize = 500
+
+// Mirror is an additional control plane the reports are sent to, e.g. a global control plane next to the regional
+// one. The reports are queued in a buffer of the mirror and sent in batches, so an unreachable mirror is retried on
+// its own and does not hold back the reports to the control plane or to the other mirrors
+type Mirror struct {
+	URL      string
+	shipper  *Shipper
+	buffer   *ReportBuffer
+	interval time.Duration
+	log      logr.Logger
+}
+
+// NewMirror returns a mirror sending the reports with the shipper config. The failed reports are retried at every
+// interval
+func NewMirror(config ShipperConfig, bufferSize int, interval time.Duration, logger logr.Logger) *Mirror {
+	buffer := NewReportBuffer(bufferSize)
+	buffer.gauge = mirrorBufferedReports.WithLabelValues(config.URL)
+	return &Mirror{
+		URL:      config.URL,
+		shipper:  NewShipper(&config),
+		buffer:   buffer,
+		interval: interval,
+		log:      logger.WithValues("controlplane", config.URL),
+	}
+}
+
+// Add queues the report in place of the previous report of the subject
+func (m *Mirror) Add(payload controlplane.AgentPayload) {
+	m.buffer.Add(payload)
+	m.buffer.Wake()
+}
+
+// Start sends the queued reports until the context is done. The reports are sent as soon as they are queued unless
+// the last reports failed, which are retried at every interval
+func (m *Mirror) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-m.buffer.wake:
+			if failing {
+				continue
+			}
+		}
+		failing = m.flush() != nil
+	}
+}
+
+// flush sends the queued reports in a single request
+func (m *Mirror) flush() error {
+	reports := m.buffer.pending()
+	if len(reports) == 0 {
+		return nil
+	}
+	payloads := make([]controlplane.AgentPayload, len(reports))
+	for i, report := range reports {
+		payloads[i] = report.payload
+	}
+	if err := m.shipper.PostBatch(payloads); err != nil {
+		mirrorReportFailuresTotal.WithLabelValues(m.URL).Inc()
+		m.log.Error(err, "failed to send the version info to the control plane", "buffered", len(reports))
+		return err
+	}
+	for _, report := range reports {
+		m.buffer.sent(report)
+	}
+	m.log.Info("successfully sent version info to the control plane", "count", len(reports))
+	return nil
+}
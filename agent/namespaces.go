@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"path"
+
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetNamespaces returns the namespaces to query for the resources of the VersionTracker. The namespaces are listed
+// when a namespace selector or excluded namespaces are set, otherwise all the namespaces are queried at once unless
+// namespaces are set
+func GetNamespaces(ctx context.Context, c client.Reader, v v1alpha1.VersionTracker) ([]string, error) {
+	res := v.Spec.Resources
+	// nodes are not namespaced
+	if res.Strategy == "Nodes" {
+		return []string{metav1.NamespaceAll}, nil
+	}
+	if res.NamespaceSelector == nil && len(res.ExcludeNamespaces) == 0 {
+		if len(res.Namespaces) == 0 {
+			return []string{metav1.NamespaceAll}, nil
+		}
+		return res.Namespaces, nil
+	}
+	var opts []client.ListOption
+	if res.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(res.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	var list corev1.NamespaceList
+	if err := c.List(ctx, &list, opts...); err != nil {
+		return nil, err
+	}
+	namespaces := []string{}
+	for _, ns := range list.Items {
+		if len(res.Namespaces) > 0 && !utils.Contains(res.Namespaces, ns.Name) {
+			continue
+		}
+		if excluded(res.ExcludeNamespaces, ns.Name) {
+			continue
+		}
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+// excluded returns true if the namespace matches one of the excluded names or patterns
+func excluded(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, namespace); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+)
+
+// interval returns the interval of a VersionTracker, which defaults to the interval of the agent
+func (r *VersionTrackerReconciler) interval(v v1alpha1.VersionTracker) time.Duration {
+	if v.Spec.Interval != nil {
+		return v.Spec.Interval.Duration
+	}
+	return r.conf().Interval
+}
+
+// requeueAfter returns the delay until the next reconciliation of a VersionTracker. With staggering, every
+// VersionTracker of every agent is reconciled at its own phase of the interval, and the jitter adds a random delay
+// up to a fraction of the interval, so the agents do not report to the control plane at the same second
+func (r *VersionTrackerReconciler) requeueAfter(key string, interval time.Duration) time.Duration {
+	conf := r.conf()
+	if interval <= 0 {
+		return interval
+	}
+	delay := interval
+	if conf.Stagger {
+		offset := int64(phase(conf.ID+"/"+key) % uint64(interval))
+		delay = interval - time.Duration((time.Now().UnixNano()-offset)%int64(interval))
+		// the reconciliations triggered by the changes of the resources do not make the next one too close
+		if delay < interval/2 {
+			delay += interval
+		}
+	}
+	if conf.Jitter > 0 {
+		delay += time.Duration(rand.Float64() * conf.Jitter * float64(interval))
+	}
+	return delay
+}
+
+// phase returns the hash of a VersionTracker of an agent the phase of its reconciliations is derived from
+func phase(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
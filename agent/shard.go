@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// number of points of every replica on the hash ring, to spread the VersionTrackers evenly
+const shardVirtualNodes = 100
+
+// Shard is the part of the VersionTrackers an agent replica reconciles when the VersionTrackers are sharded between
+// several replicas. The VersionTrackers are assigned to the replicas with a hash ring of their namespaced name, so
+// only a few VersionTrackers move to another replica when the number of replicas changes
+type Shard struct {
+	// Index of the replica, from 0 to Count-1
+	Index int
+	// Number of replicas
+	Count int
+	// points of the replicas on the ring sorted by hash
+	ring []shardPoint
+}
+
+type shardPoint struct {
+	hash  uint32
+	index int
+}
+
+func NewShard(index, count int) (*Shard, error) {
+	if count < 1 || index < 0 || index >= count {
+		return nil, fmt.Errorf("invalid shard %d of %d shards", index, count)
+	}
+	s := &Shard{Index: index, Count: count}
+	for i := 0; i < count; i++ {
+		for v := 0; v < shardVirtualNodes; v++ {
+			s.ring = append(s.ring, shardPoint{hash: shardHash(fmt.Sprintf("shard-%d-%d", i, v)), index: i})
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool {
+		return s.ring[i].hash < s.ring[j].hash
+	})
+	return s, nil
+}
+
+func shardHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// Owns returns true if the key (e.g. the namespaced name of a VersionTracker) is assigned to the replica.
+// A nil shard owns every key
+func (s *Shard) Owns(key string) bool {
+	if s == nil || s.Count <= 1 {
+		return true
+	}
+	hash := shardHash(key)
+	// the key belongs to the first replica point after its hash
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= hash
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].index == s.Index
+}
@@ -2,34 +2,49 @@ package agent
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/go-logr/logr"
 	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
 )
 
 type ShipperConfig struct {
-	URL       string
-	Token     string
+	URL string
+	// Token of the requests, read on every request so it can be rotated
+	Token     utils.TokenFunc
 	TLSVerify bool
-	Timeout   time.Duration
+	TLSConfig *tls.Config
+	// Proxy of the requests. The requests are sent directly when it is nil
+	Proxy   func(*http.Request) (*url.URL, error)
+	Timeout time.Duration
+	// Compress the requests with gzip
+	Compress bool
 }
 
 type Shipper struct {
 	Client    *http.Client
 	BaseURL   string
-	AuthToken string
+	AuthToken utils.TokenFunc
+	Compress  bool
 }
 
 func NewShipper(config *ShipperConfig) *Shipper {
+	tlsConfig := &tls.Config{}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+	tlsConfig.InsecureSkipVerify = !config.TLSVerify
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: !config.TLSVerify,
-		},
+		TLSClientConfig: tlsConfig,
+		Proxy:           config.Proxy,
 	}
 	if config.Timeout > 0 {
 		tr.DialContext = (&net.Dialer{
@@ -42,28 +57,62 @@ func NewShipper(config *ShipperConfig) *Shipper {
 		},
 		BaseURL:   config.URL,
 		AuthToken: config.Token,
+		Compress:  config.Compress,
 	}
 }
 
 func (s *Shipper) Post(payload controlplane.AgentPayload) error {
+	return s.post(payload)
+}
+
+// PostBatch sends the payloads of several subjects in a single request
+func (s *Shipper) PostBatch(payloads []controlplane.AgentPayload) error {
+	return s.post(payloads)
+}
+
+// token returns the current auth token of the requests
+func (s *Shipper) token() (string, error) {
+	if s.AuthToken == nil {
+		return "", nil
+	}
+	return s.AuthToken()
+}
+
+func (s *Shipper) post(body interface{}) error {
 	agentsEndpoint := fmt.Sprintf("%s%s", s.BaseURL, controlplane.AgentsAPIEndpoint)
 	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+	if s.Compress {
+		zw := gzip.NewWriter(&buf)
+		if err := json.NewEncoder(zw).Encode(body); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	} else if err := json.NewEncoder(&buf).Encode(body); err != nil {
 		return err
 	}
+	reportPayloadBytes.Observe(float64(buf.Len()))
 	req, err := http.NewRequest("POST", agentsEndpoint, &buf)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.AuthToken))
+	if s.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	token, err := s.token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	resp, err := s.Client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusAlreadyReported {
-		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		return &statusError{code: resp.StatusCode, status: resp.Status}
 	}
 	return nil
 }
@@ -71,14 +120,51 @@ func (s *Shipper) Post(payload controlplane.AgentPayload) error {
 func (r *VersionTrackerReconciler) ShipToControlPlane(ver SubjectVersion) error {
 	log := r.Log.WithName("shipper").WithValues("VersionTracker", fmt.Sprintf("%s/%s", ver.Namespace, ver.ID))
 	log.Info("sending version info to the control plane")
-	shipperConf := &ShipperConfig{
-		URL:       r.Config.ControlPlaneUrl,
-		Token:     r.Config.ControlPlaneAuthToken,
-		Timeout:   time.Second * 10,
-		TLSVerify: true,
+	payload := r.PrepareThePayload(ver)
+	if r.conf().DryRun {
+		return r.dryRun(log, payload)
+	}
+	for _, m := range r.Mirrors {
+		m.Add(payload)
+	}
+	if r.Batcher != nil {
+		// the report is sent with the next batch
+		r.Batcher.Add(subjectKey(ver), payload)
+		return nil
+	}
+	if err := r.send(log, payload); err != nil {
+		if r.Buffer != nil {
+			// the report is sent when the control plane is reachable again
+			r.Buffer.Add(payload)
+			log.Info("control plane unreachable. buffered the version info", "buffered", r.Buffer.Len())
+		}
+		return err
+	}
+	// a buffered report of the subject is outdated
+	if r.Buffer != nil {
+		r.Buffer.Remove(payload)
+		r.Buffer.Wake()
+	}
+	return nil
+}
+
+// send sends a report on the stream, or with the REST API when there is no stream or it is down
+func (r *VersionTrackerReconciler) send(log logr.Logger, payload controlplane.AgentPayload) error {
+	if r.Stream != nil {
+		start := time.Now()
+		err := r.Stream.Send(payload)
+		observeReport("stream", start, err)
+		if err == nil {
+			r.health.record(nil)
+			log.Info("successfully streamed version info to the control plane")
+			return nil
+		}
+		log.V(1).Info("failed to stream version info, falling back to the REST API", "error", err.Error())
 	}
-	shipper := NewShipper(shipperConf)
-	err := shipper.Post(r.PrepareThePayload(ver))
+	start := time.Now()
+	err := r.shipper().Post(payload)
+	observeReport("rest", start, err)
+	r.health.record(err)
 	if err != nil {
 		return err
 	}
@@ -86,10 +172,79 @@ func (r *VersionTrackerReconciler) ShipToControlPlane(ver SubjectVersion) error
 	return nil
 }
 
+// sendBatch sends several reports in a single request, or one by one on the stream
+func (r *VersionTrackerReconciler) sendBatch(log logr.Logger, payloads []controlplane.AgentPayload) error {
+	if r.Stream != nil || len(payloads) == 1 {
+		for _, payload := range payloads {
+			if err := r.send(log, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := time.Now()
+	err := r.shipper().PostBatch(payloads)
+	observeReport("batch", start, err)
+	r.health.record(err)
+	if err != nil {
+		return err
+	}
+	log.Info("successfully sent a batch of version info to the control plane", "count", len(payloads))
+	return nil
+}
+
+func (r *VersionTrackerReconciler) shipper() *Shipper {
+	return NewShipper(&ShipperConfig{
+		URL:       r.conf().ControlPlaneUrl,
+		Token:     r.conf().ControlPlaneAuthToken,
+		Timeout:   time.Second * 10,
+		TLSVerify: true,
+		TLSConfig: r.conf().ControlPlaneTLSConfig,
+		Proxy:     r.conf().ControlPlaneProxy,
+		Compress:  r.conf().ControlPlaneCompression,
+	})
+}
+
+// Fields of the reports that can be dropped. Without them, only the version counts of the subjects are reported
+const (
+	ReportFieldExtractedFrom = "extractedFrom"
+	ReportFieldImage         = "image"
+	ReportFieldDigest        = "digest"
+	ReportFieldAgentTags     = "agentTags"
+)
+
+// ReportFields are the fields of the reports that can be dropped
+var ReportFields = []string{ReportFieldExtractedFrom, ReportFieldImage, ReportFieldDigest, ReportFieldAgentTags}
+
+// dropFields clears the fields of the payload dropped by the config
+func dropFields(payload *controlplane.AgentPayload, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case ReportFieldAgentTags:
+			payload.AgentTags = nil
+		case ReportFieldExtractedFrom, ReportFieldImage, ReportFieldDigest:
+			for i := range payload.Version.Versions {
+				v := &payload.Version.Versions[i]
+				switch field {
+				case ReportFieldExtractedFrom:
+					v.ExtractedFrom = ""
+				case ReportFieldImage:
+					v.Image = ""
+				case ReportFieldDigest:
+					v.Digest = ""
+				}
+			}
+		}
+	}
+}
+
 func (r *VersionTrackerReconciler) PrepareThePayload(sv SubjectVersion) controlplane.AgentPayload {
 	payload := controlplane.AgentPayload{}
-	payload.AgentID = r.Config.ID
-	payload.AgentTags = r.Config.Tags
+	payload.AgentID = r.conf().ID
+	if sv.AgentID != "" {
+		payload.AgentID = sv.AgentID
+	}
+	payload.AgentTags = r.conf().Tags
 	vers := []controlplane.Version{}
 	for _, v := range sv.Versions {
 		vers = append(vers, controlplane.Version{
@@ -97,6 +252,8 @@ func (r *VersionTrackerReconciler) PrepareThePayload(sv SubjectVersion) controlp
 			ResourceCount:  v.ResourceCount,
 			ResourceKind:   v.ResourceKind,
 			ExtractedFrom:  v.ExtractedFrom,
+			Image:          v.Image,
+			Digest:         v.Digest,
 		})
 	}
 	payload.Version = controlplane.SubjectVersion{
@@ -107,5 +264,6 @@ func (r *VersionTrackerReconciler) PrepareThePayload(sv SubjectVersion) controlp
 		Versions:        vers,
 		RemoteVersion:   sv.RemoteVersion,
 	}
+	dropFields(&payload, r.conf().DropFields)
 	return payload
 }
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// seriesKey identifies the series of the subject of a VersionTracker in a cluster
+func seriesKey(clusterID, tracker string) string {
+	return fmt.Sprintf("%s|%s", clusterID, tracker)
+}
+
+// exportVersions exports the running versions of the subjects of a VersionTracker as metrics, for the standalone
+// agents running without a control plane. The series of the versions that are no longer running are deleted
+func (r *VersionTrackerReconciler) exportVersions(key string, subjects ...SubjectVersion) {
+	var series [][]string
+	for _, sv := range subjects {
+		for _, v := range sv.Versions {
+			labels := []string{sv.ID, sv.Namespace, sv.AgentID, v.Version, v.ResourceKind, v.ExtractedFrom}
+			versionResourceCount.WithLabelValues(labels...).Set(float64(v.ResourceCount))
+			series = append(series, labels)
+		}
+	}
+	if previous, ok := r.series.Load(key); ok {
+		for _, labels := range previous.([][]string) {
+			if !containsSeries(series, labels) {
+				versionResourceCount.DeleteLabelValues(labels...)
+			}
+		}
+	}
+	r.series.Store(key, series)
+}
+
+// forgetVersions deletes the series of a deleted VersionTracker in every cluster
+func (r *VersionTrackerReconciler) forgetVersions(tracker string) {
+	r.series.Range(func(key, value interface{}) bool {
+		if strings.HasSuffix(key.(string), "|"+tracker) {
+			for _, labels := range value.([][]string) {
+				versionResourceCount.DeleteLabelValues(labels...)
+			}
+			r.series.Delete(key)
+		}
+		return true
+	})
+}
+
+func containsSeries(series [][]string, labels []string) bool {
+	for _, s := range series {
+		if strings.Join(s, "|") == strings.Join(labels, "|") {
+			return true
+		}
+	}
+	return false
+}
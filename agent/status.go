@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the Ready condition and of the events of the VersionTrackers
+const (
+	ReasonInvalid          = "Invalid"
+	ReasonReconcileFailed  = "ReconcileFailed"
+	ReasonNoResources      = "NoResources"
+	ReasonExtractionFailed = "ExtractionFailed"
+	ReasonReportFailed     = "ReportFailed"
+	ReasonReported         = "Reported"
+)
+
+// newStatus returns the status of a VersionTracker to reconcile. The conditions and the last report time
+// are kept from its current status
+func newStatus(v *v1alpha1.VersionTracker) v1alpha1.VersionTrackerStatus {
+	current := v.Status.DeepCopy()
+	return v1alpha1.VersionTrackerStatus{
+		Conditions:         current.Conditions,
+		LastReportTime:     current.LastReportTime,
+		ObservedGeneration: v.Generation,
+	}
+}
+
+// addVersions adds the versions extracted in a cluster to the status
+func addVersions(status *v1alpha1.VersionTrackerStatus, v v1alpha1.VersionTracker, subjects []SubjectVersion) {
+	for _, sv := range subjects {
+		for _, version := range sv.UniqVersions {
+			// the apps of the well known labels are told apart by their name
+			if v.Spec.LocalVersion.Strategy == v1alpha1.WellKnownLabels {
+				version = fmt.Sprintf("%s:%s", sv.ID, version)
+			}
+			if !utils.Contains(status.Versions, version) {
+				status.Versions = append(status.Versions, version)
+			}
+		}
+	}
+}
+
+// setReady sets the Ready condition of the status
+func setReady(status *v1alpha1.VersionTrackerStatus, ready bool, reason, message string) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: status.ObservedGeneration,
+	}
+	if ready {
+		condition.Status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// setReconciled sets the Ready condition of the status after the reconciliation of the clusters
+func setReconciled(status *v1alpha1.VersionTrackerStatus, err error) {
+	switch {
+	case err != nil:
+		setReady(status, false, ReasonReconcileFailed, err.Error())
+	case status.MatchedResources == 0:
+		setReady(status, false, ReasonNoResources, "no resources matched the VersionTracker")
+	case len(status.Versions) == 0:
+		setReady(status, false, ReasonExtractionFailed, "no version could be extracted from the resources")
+	default:
+		setReady(status, true, ReasonReported, fmt.Sprintf("%d version(s) extracted from %d resource(s)", len(status.Versions), status.MatchedResources))
+	}
+}
+
+// updateStatus patches the status of the VersionTracker when it changed. A failed update does not fail the
+// reconciliation since the versions are reported anyway
+func (r *VersionTrackerReconciler) updateStatus(ctx context.Context, v *v1alpha1.VersionTracker, status v1alpha1.VersionTrackerStatus) {
+	if reflect.DeepEqual(v.Status, status) {
+		return
+	}
+	// the status is updated at the next reconciliation
+	if !r.Budget.Take(1, r.conf().Interval) {
+		return
+	}
+	updated := v.DeepCopy()
+	updated.Status = status
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(v)); err != nil {
+		r.Log.Error(err, "failed to update the VersionTracker status", "versiontracker", fmt.Sprintf("%s/%s", v.Namespace, v.Name))
+	}
+}
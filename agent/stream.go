@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	controlplane "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/net/websocket"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// time to wait for the acknowledgement of a report before falling back to the REST API
+	streamAckTimeout = 10 * time.Second
+	// maximum delay between the reconnection attempts
+	streamMaxBackoff = time.Minute
+)
+
+// Streamer keeps a websocket open to the control plane to stream the reports and receive the acknowledgements
+// and the refresh requests of the control plane. The reports are sent one at a time: the next report is only sent
+// once the previous one is acknowledged
+type Streamer struct {
+	// url of the stream, guarded by the connMutex
+	url string
+	// token of the stream, read on every connection so it can be rotated
+	token     utils.TokenFunc
+	tlsConfig *tls.Config
+	// proxy of the connections to the control plane. The stream connects directly when it is nil
+	proxy func(*http.Request) (*url.URL, error)
+	log   logr.Logger
+	// called when the control plane requests a refresh
+	onRefresh func(ctx context.Context)
+
+	// serializes the reports
+	sendMutex sync.Mutex
+	seq       uint64
+	connMutex sync.RWMutex
+	conn      *websocket.Conn
+	acks      chan controlplane.StreamMessage
+}
+
+// streamURL returns the URL of the stream of the control plane, i.e.
+// http(s)://host -> ws(s)://host/api/v1alpha1/stream
+func streamURL(controlPlaneURL string) string {
+	return strings.Replace(strings.TrimSuffix(controlPlaneURL, "/"), "http", "ws", 1) + controlplane.StreamAPIEndpoint
+}
+
+func NewStreamer(controlPlaneURL string, token utils.TokenFunc, tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error), logger logr.Logger, onRefresh func(ctx context.Context)) *Streamer {
+	return &Streamer{
+		url:       streamURL(controlPlaneURL),
+		token:     token,
+		tlsConfig: tlsConfig,
+		proxy:     proxy,
+		log:       logger,
+		onRefresh: onRefresh,
+		acks:      make(chan controlplane.StreamMessage, 1),
+	}
+}
+
+// Start connects to the control plane and reconnects with a backoff until the context is done
+func (s *Streamer) Start(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		connected := time.Now()
+		err := s.run(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if time.Since(connected) > streamMaxBackoff {
+			backoff = time.Second
+		}
+		s.log.Error(err, "stream to the control plane closed. reconnecting", "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > streamMaxBackoff {
+			backoff = streamMaxBackoff
+		}
+	}
+}
+
+// run opens the stream and reads the messages of the control plane until the stream is closed
+func (s *Streamer) run(ctx context.Context) error {
+	s.connMutex.RLock()
+	url := s.url
+	s.connMutex.RUnlock()
+	config, err := websocket.NewConfig(url, url)
+	if err != nil {
+		return err
+	}
+	token, err := s.token()
+	if err != nil {
+		return err
+	}
+	config.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	config.TlsConfig = s.tlsConfig
+	conn, err := s.dial(config)
+	if err != nil {
+		return err
+	}
+	s.log.Info("connected to the control plane stream")
+	s.setConn(conn)
+	defer s.setConn(nil)
+	// close the stream when the context is done to stop the reads
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+			conn.Close()
+		}
+	}()
+	for {
+		var msg controlplane.StreamMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case controlplane.StreamMessageAck:
+			select {
+			case s.acks <- msg:
+			default:
+				// nobody waits for the acknowledgement of a report that timed out
+			}
+		case controlplane.StreamMessageRefresh:
+			s.log.Info("refresh requested by the control plane")
+			go s.onRefresh(ctx)
+		}
+	}
+}
+
+// SetControlPlaneURL reconnects the stream to another control plane
+func (s *Streamer) SetControlPlaneURL(controlPlaneURL string) {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	s.url = streamURL(controlPlaneURL)
+	if s.conn != nil {
+		// the stream is reconnected to the new url when it is closed
+		s.conn.Close()
+	}
+}
+
+func (s *Streamer) setConn(conn *websocket.Conn) {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	s.conn = conn
+}
+
+func (s *Streamer) getConn() *websocket.Conn {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+	return s.conn
+}
+
+// Send sends a report on the stream and waits for its acknowledgement
+func (s *Streamer) Send(payload controlplane.AgentPayload) error {
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
+	conn := s.getConn()
+	if conn == nil {
+		return fmt.Errorf("not connected to the control plane stream")
+	}
+	s.seq++
+	msg := controlplane.StreamMessage{Type: controlplane.StreamMessageReport, Seq: s.seq, Payload: &payload}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	reportPayloadBytes.Observe(float64(len(data)))
+	if err := websocket.Message.Send(conn, string(data)); err != nil {
+		return err
+	}
+	timeout := time.After(streamAckTimeout)
+	for {
+		select {
+		case ack := <-s.acks:
+			if ack.Seq != msg.Seq {
+				// late acknowledgement of a previous report
+				continue
+			}
+			if ack.Error != "" {
+				return fmt.Errorf("report rejected by the control plane: %s", ack.Error)
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for the acknowledgement of the control plane")
+		}
+	}
+}
+
+// RefreshAll requeues all the VersionTrackers so their subjects are reported again
+func (r *VersionTrackerReconciler) RefreshAll(ctx context.Context) {
+	// the unchanged subjects are reported too
+	r.forgetReports()
+	var list v1alpha1.VersionTrackerList
+	if err := r.List(ctx, &list); err != nil {
+		r.Log.Error(err, "failed to list VersionTrackers to refresh")
+		reconciliationErrorsTotal.Inc()
+		return
+	}
+	for i := range list.Items {
+		select {
+		case r.Refresh <- event.GenericEvent{Object: &list.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
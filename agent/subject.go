@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// invalidSubjectChars are the runs of characters not allowed in a subject ID
+var invalidSubjectChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// subjectData returns the metadata of a resource the subject template is rendered with
+func subjectData(item interface{}) map[string]interface{} {
+	data := map[string]interface{}{}
+	f, err := toItemFields(item)
+	if err != nil {
+		return data
+	}
+	data["name"], _, _ = unstructured.NestedString(f, "metadata", "name")
+	data["namespace"], _, _ = unstructured.NestedString(f, "metadata", "namespace")
+	labels, _, _ := unstructured.NestedStringMap(f, "metadata", "labels")
+	annotations, _, _ := unstructured.NestedStringMap(f, "metadata", "annotations")
+	data["labels"], data["annotations"] = labels, annotations
+	return data
+}
+
+// SubjectID escapes a subject ID into a DNS label: lower case alphanumerics and dashes, at most 63 characters. The
+// other characters (e.g. the / of namespace/name) are replaced with dashes
+func SubjectID(rendered string) string {
+	id := invalidSubjectChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(rendered)), "-")
+	if len(id) > validation.DNS1123LabelMaxLength {
+		id = id[:validation.DNS1123LabelMaxLength]
+	}
+	return strings.Trim(id, "-")
+}
+
+// ExtractTemplatedVersions groups the resources by the subject ID rendered with the subject template of the
+// VersionTracker and extracts the versions of every subject. The resources whose template renders empty or fails
+// are grouped under the name of the VersionTracker
+func (r *VersionTrackerReconciler) ExtractTemplatedVersions(v v1alpha1.VersionTracker, items []interface{}) []SubjectVersion {
+	log := r.Log.WithName("extractor").WithValues("VersionTracker", v.Namespace+"/"+v.Name)
+	// the missing labels and annotations render empty
+	tmpl, err := template.New("subject").Option("missingkey=zero").Parse(v.Spec.SubjectTemplate)
+	if err != nil {
+		log.Error(err, "invalid subject template", "template", v.Spec.SubjectTemplate)
+		reconciliationErrorsTotal.Inc()
+		return nil
+	}
+	groups := map[string][]interface{}{}
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, subjectData(item)); err != nil {
+			log.Error(err, "failed to render the subject template", "template", v.Spec.SubjectTemplate)
+			reconciliationErrorsTotal.Inc()
+			buf.Reset()
+		}
+		id := SubjectID(buf.String())
+		if id == "" {
+			id = v.Spec.Name
+		}
+		groups[id] = append(groups[id], item)
+	}
+	ids := make([]string, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var subjects []SubjectVersion
+	for _, id := range ids {
+		tracker := *v.DeepCopy()
+		tracker.Spec.Name = id
+		if sv := r.ExtractSubjectVersion(tracker, groups[id]); len(sv.Versions) > 0 {
+			subjects = append(subjects, sv)
+		}
+	}
+	return subjects
+}
@@ -2,8 +2,8 @@ package agent
 
 import (
 	"fmt"
-	"regexp"
 
+	"github.com/skillz/opvic/utils"
 	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/kubectl/pkg/cmd/get"
 )
@@ -30,9 +30,7 @@ func getFeilds(jsonPath string, resource interface{}) ([]string, error) {
 	return valueStrings, nil
 }
 
+// GetResultsFromRegex extracts the version like the remote versions. The content is returned as is without a pattern
 func GetResultsFromRegex(pattern, tmpl, content string) string {
-	regex := regexp.MustCompile(pattern)
-	matches := regex.FindStringSubmatchIndex(content)
-	result := regex.ExpandString([]byte{}, tmpl, content, matches)
-	return string(result)
+	return utils.GetResultsFromRegex(pattern, tmpl, content)
 }
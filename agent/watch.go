@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// lastReport is the last report of a subject sent to the control plane
+type lastReport struct {
+	versions string
+	sentAt   time.Time
+}
+
+// watch watches the resources of the VersionTracker in the cluster so the VersionTrackers of a resource are
+// reconciled when it changes. A resource kind is watched once per cluster, with the informers of the cache of the
+// cluster the resources are already listed from. Custom resources and Secrets are only reported at every interval
+func (r *VersionTrackerReconciler) watch(cluster Cluster, v v1alpha1.VersionTracker) error {
+	if r.controller == nil || cluster.Cache == nil || v.GetCustomResource() != nil {
+		return nil
+	}
+	list, err := v.GetObjectList()
+	if err != nil {
+		return err
+	}
+	// the Secrets are not watched so they are not all cached, they are reported at every interval
+	if _, ok := list.(*corev1.SecretList); ok {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(list, r.Scheme)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	key := fmt.Sprintf("%s/%s", cluster.ID, gvk.String())
+	if _, watched := r.watches.LoadOrStore(key, true); watched {
+		return nil
+	}
+	obj, err := r.Scheme.New(gvk)
+	if err != nil {
+		r.watches.Delete(key)
+		return err
+	}
+	src := source.NewKindWithCache(obj.(client.Object), cluster.Cache)
+	if err := r.controller.Watch(src, handler.EnqueueRequestsFromMapFunc(r.versionTrackersOf(v.Spec.Resources.Strategy))); err != nil {
+		r.watches.Delete(key)
+		return err
+	}
+	r.Log.Info("watching resources", "cluster", cluster.ID, "kind", gvk.Kind)
+	return nil
+}
+
+// versionTrackersOf returns the function mapping a resource to the VersionTrackers of the strategy tracking it.
+// The namespace selector is not checked so a VersionTracker can be reconciled for a resource it ignores, which
+// reports nothing new
+func (r *VersionTrackerReconciler) versionTrackersOf(strategy string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		var list v1alpha1.VersionTrackerList
+		if err := r.List(context.Background(), &list); err != nil {
+			r.Log.Error(err, "failed to list VersionTrackers of a resource", "resource", obj.GetNamespace()+"/"+obj.GetName())
+			reconciliationErrorsTotal.Inc()
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, v := range list.Items {
+			res := v.Spec.Resources
+			if res.Strategy != strategy || !r.Shard.Owns(fmt.Sprintf("%s/%s", v.Namespace, v.Name)) {
+				continue
+			}
+			if obj.GetNamespace() != "" {
+				if len(res.Namespaces) > 0 && !utils.Contains(res.Namespaces, obj.GetNamespace()) {
+					continue
+				}
+				if excluded(res.ExcludeNamespaces, obj.GetNamespace()) {
+					continue
+				}
+			}
+			if res.Selector != nil {
+				selector, err := metav1.LabelSelectorAsSelector(res.Selector)
+				if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+					continue
+				}
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: v.Namespace, Name: v.Name},
+			})
+		}
+		return requests
+	}
+}
+
+func subjectKey(sv SubjectVersion) string {
+	return fmt.Sprintf("%s/%s/%s", sv.AgentID, sv.Namespace, sv.ID)
+}
+
+// changed returns true if the versions of the subject changed since its last report, or if it was last reported
+// an interval ago so the control plane still gets a full report at every interval
+func (r *VersionTrackerReconciler) changed(sv SubjectVersion, interval time.Duration) bool {
+	last, ok := r.reported.Load(subjectKey(sv))
+	if !ok {
+		return true
+	}
+	report := last.(lastReport)
+	if time.Since(report.sentAt) >= interval {
+		return true
+	}
+	return fingerprint(sv) != report.versions
+}
+
+// recordReport saves the versions of a subject reported to the control plane
+func (r *VersionTrackerReconciler) recordReport(sv SubjectVersion) {
+	r.reported.Store(subjectKey(sv), lastReport{versions: fingerprint(sv), sentAt: time.Now()})
+}
+
+// forgetReport makes the next reconciliation report the subject, e.g. when its report failed to be sent
+func (r *VersionTrackerReconciler) forgetReport(key string) {
+	r.reported.Delete(key)
+}
+
+// fingerprint summarizes the versions of a subject regardless of the order the resources were listed in
+func fingerprint(sv SubjectVersion) string {
+	versions := []string{fmt.Sprintf("%d", sv.TotalResourceCount)}
+	for _, v := range sv.Versions {
+		versions = append(versions, fmt.Sprintf("%s=%d", v.Version, v.ResourceCount))
+	}
+	sort.Strings(versions[1:])
+	remote, _ := json.Marshal(sv.RemoteVersion)
+	return strings.Join(versions, ",") + string(remote)
+}
+
+// forgetReports makes the next reconciliations report every subject, even unchanged ones
+func (r *VersionTrackerReconciler) forgetReports() {
+	r.reported.Range(func(key, _ interface{}) bool {
+		r.reported.Delete(key)
+		return true
+	})
+}
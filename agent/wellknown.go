@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	appNameLabel    = "app.kubernetes.io/name"
+	appVersionLabel = "app.kubernetes.io/version"
+)
+
+var (
+	// helmChartLabels are the chart labels set by the Helm charts, e.g. helm.sh/chart: nginx-1.2.3
+	helmChartLabels = []string{"helm.sh/chart", "chart"}
+	// chartRegex splits a chart label into the name and the version of the chart
+	chartRegex = regexp.MustCompile(`^(.+?)-(v?[0-9]+\.[0-9]+.*)$`)
+)
+
+// wellKnownVersion returns the app of a resource and its version from the well known labels. The version is the
+// one of the app.kubernetes.io/version label, else the version of the Helm chart. The app is the
+// app.kubernetes.io/name label, else the name of the chart
+func wellKnownVersion(item interface{}) (app string, version string) {
+	f, err := toItemFields(item)
+	if err != nil {
+		return "", ""
+	}
+	labels, _, _ := unstructured.NestedStringMap(f, "metadata", "labels")
+	app, version = labels[appNameLabel], labels[appVersionLabel]
+	for _, label := range helmChartLabels {
+		match := chartRegex.FindStringSubmatch(labels[label])
+		if match == nil {
+			continue
+		}
+		if app == "" {
+			app = match[1]
+		}
+		if version == "" {
+			version = match[2]
+		}
+		break
+	}
+	return app, version
+}
+
+// ExtractWellKnownVersions extracts the versions of every app of the resources from their well known labels.
+// The apps are reported as separate subjects and the resources without the labels are skipped
+func (r *VersionTrackerReconciler) ExtractWellKnownVersions(v v1alpha1.VersionTracker, items []interface{}) []SubjectVersion {
+	apps := map[string][]interface{}{}
+	for _, item := range items {
+		app, version := wellKnownVersion(item)
+		if app == "" || version == "" {
+			continue
+		}
+		apps[app] = append(apps[app], map[string]interface{}{"version": version})
+	}
+	names := make([]string, 0, len(apps))
+	for app := range apps {
+		names = append(names, app)
+	}
+	sort.Strings(names)
+	var subjects []SubjectVersion
+	for _, app := range names {
+		tracker := *v.DeepCopy()
+		tracker.Spec.Name = app
+		tracker.Spec.LocalVersion.FieldSelector = ".version"
+		if sv := r.ExtractSubjectVersion(tracker, apps[app]); len(sv.Versions) > 0 {
+			subjects = append(subjects, sv)
+		}
+	}
+	return subjects
+}
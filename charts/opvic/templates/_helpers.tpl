@@ -132,7 +132,8 @@ Control Plane URL
 */}}
 {{- define "opvic.agent.controlPlaneURL" -}}
 {{- if and (not .Values.agent.controlPlaneURL) (.Values.controlplane.enabled) }}
-{{- default (printf "http://%s-control-plane" (include "opvic.fullname" .)) .Values.agent.controlPlaneURL }}
+{{- $scheme := ternary "https" "http" (not (empty .Values.controlplane.tls.secretName)) }}
+{{- printf "%s://%s-control-plane:%v" $scheme (include "opvic.fullname" .) .Values.controlplane.service.port }}
 {{- else }}
 {{- .Values.agent.controlPlaneURL }}
 {{- end }}
@@ -17,24 +17,35 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	zaplib "go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/skillz/opvic/agent"
 	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/agent/api/v1beta1"
 	"github.com/skillz/opvic/utils"
 	"gopkg.in/alecthomas/kingpin.v2"
 	//+kubebuilder:scaffold:imports
@@ -53,11 +64,55 @@ var (
 
 	metricsAddr           = kingpin.Flag("metrics-bind-address", "The address the metric endpoint binds to.").Envar("METRICS_BIND_ADDRESS").Default(":8081").String()
 	probeAddr             = kingpin.Flag("health-probe-bind-address", "The address the probe endpoint binds to.").Envar("HEALTH_PROBE_BIND_ADDRESS").Default(":8082").String()
+	kubeAPIQPS            = kingpin.Flag("kube-api-qps", "Maximum number of requests per second of the agent to the API servers").Envar("KUBE_API_QPS").Default("20").Float32()
+	kubeAPIBurst          = kingpin.Flag("kube-api-burst", "Maximum burst of requests of the agent to the API servers").Envar("KUBE_API_BURST").Default("30").Int()
+	apiBudget             = kingpin.Flag("agent.api-budget", "Maximum number of calls to the API servers not served by the informers (e.g. the lists of the custom resources and of the Secrets, and the status updates) in every interval. The VersionTrackers over budget are reconciled in the next interval. 0 disables the budget").Envar("AGENT_API_BUDGET").Default("0").Int()
+	leaderElect           = kingpin.Flag("leader-elect", "Elect a leader among the agent replicas. Only the leader reconciles and reports the versions, another replica takes over when it is gone").Envar("LEADER_ELECT").Default("false").Bool()
+	leaderElectionNS      = kingpin.Flag("leader-election-namespace", "Namespace of the leader election lock. Defaults to the namespace of the agent").Envar("LEADER_ELECTION_NAMESPACE").String()
+	leaderElectionID      = kingpin.Flag("leader-election-id", "Name of the leader election lock, shared by the replicas of an agent").Envar("LEADER_ELECTION_ID").Default("opvic-agent").String()
 	agentID               = kingpin.Flag("agent.identifier", "Agent unique identifier").Envar("AGENT_IDENTIFIER").Required().String()
 	agentInterval         = kingpin.Flag("agent.interval", "Agent reconciliation interval").Envar("AGENT_INTERVAL").Default("60s").Duration()
+	agentStagger          = kingpin.Flag("agent.stagger", "Reconcile every VersionTracker at its own phase of the interval, derived from the agent identifier and the VersionTracker, to spread the reports of the agents over the interval").Envar("AGENT_STAGGER").Default("false").Bool()
+	agentJitter           = kingpin.Flag("agent.jitter", "Random delay added to the interval between the reconciliations, as a fraction of the interval (from 0 to 1)").Envar("AGENT_JITTER").Default("0").Float64()
 	agentTags             = kingpin.Flag("agent.tags", "key:value pair to add to the agent tags. (you can pass this flag multiple times").Envar("AGENT_TAGS").PlaceHolder("KEY:VALUE").StringMap()
 	controlPlaneUrl       = kingpin.Flag("controlplane.url", "Control Plane URL").Envar("CONTROLPLANE_URL").PlaceHolder("http(s)://CONTROLPLANE-ADDRESS").String()
 	controlPlaneAuthToken = kingpin.Flag("controlplane.auth-token", "Control Plane Shared Auth Token").Envar("CONTROLPLANE_AUTH_TOKEN").String()
+	controlPlaneTokenFile = kingpin.Flag("controlplane.auth-token-file", "File containing the Control Plane Shared Auth Token (e.g. a mounted secret). It takes precedence over the token and is read again when it changes, so the token can be rotated without restarting").Envar("CONTROLPLANE_AUTH_TOKEN_FILE").String()
+	agentClusters         = kingpin.Flag("agent.cluster", "ID=KUBECONFIG pair of a remote cluster to track with its own agent identifier. The kubeconfig can be followed by #CONTEXT to use a context other than the current one. (you can pass this flag multiple times)").Envar("AGENT_CLUSTERS").PlaceHolder("ID=KUBECONFIG[#CONTEXT]").StringMap()
+	agentWatch            = kingpin.Flag("agent.watch", "Watch the tracked resources and report the versions within seconds of a change. The versions are still reported at every interval").Envar("AGENT_WATCH").Default("true").Bool()
+	agentSelector         = kingpin.Flag("agent.selector", "Label selector of the VersionTrackers to reconcile (e.g. team=platform). All the VersionTrackers are reconciled by default").Envar("AGENT_SELECTOR").String()
+	configFile            = kingpin.Flag("config.file", "YAML file of the agent config (interval, controlPlaneURL, tags and selector) overriding the flags. It is reloaded when it changes").Envar("AGENT_CONFIG_FILE").String()
+	configReloadInterval  = kingpin.Flag("config.reload-interval", "Interval to check the config file for changes").Envar("AGENT_CONFIG_RELOAD_INTERVAL").Default("10s").Duration()
+	dryRun                = kingpin.Flag("dry-run", "Evaluate the VersionTrackers and log the reports instead of sending them to the Control Plane, e.g. to validate new VersionTrackers").Envar("DRY_RUN").Default("false").Bool()
+	dryRunOutput          = kingpin.Flag("dry-run.output", "File the reports are appended to as JSON lines in dry run").Envar("DRY_RUN_OUTPUT").String()
+	agentStandalone       = kingpin.Flag("agent.standalone", "Export the running versions as Prometheus metrics instead of reporting them to the Control Plane, e.g. in air-gapped clusters").Envar("AGENT_STANDALONE").Default("false").Bool()
+	agentShards           = kingpin.Flag("agent.shards", "Number of agent replicas sharding the VersionTrackers between themselves").Envar("AGENT_SHARDS").Default("1").Int()
+	agentShard            = kingpin.Flag("agent.shard", "Shard of the replica, from 0 to the number of shards - 1. Defaults to the ordinal of the StatefulSet pod of the replica").Envar("AGENT_SHARD").Default("-1").Int()
+	agentLocalCluster     = kingpin.Flag("agent.local-cluster", "Track the resources of the cluster the agent runs in").Envar("AGENT_LOCAL_CLUSTER").Default("true").Bool()
+	controlPlaneCertFile  = kingpin.Flag("controlplane.tls.cert-file", "Client certificate file to authenticate with the Control Plane over mutual TLS. It is reloaded when it changes").Envar("CONTROLPLANE_TLS_CERT_FILE").String()
+	controlPlaneKeyFile   = kingpin.Flag("controlplane.tls.key-file", "Key file of the client certificate").Envar("CONTROLPLANE_TLS_KEY_FILE").String()
+	controlPlaneCAFile    = kingpin.Flag("controlplane.tls.ca-file", "CA bundle to verify the Control Plane certificate against in addition to the system roots").Envar("CONTROLPLANE_TLS_CA_FILE").String()
+	controlPlaneProxy     = kingpin.Flag("controlplane.proxy.url", "Forward proxy of the connections to the Control Plane (e.g. http://proxy:3128). Defaults to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables").Envar("CONTROLPLANE_PROXY_URL").String()
+	controlPlaneProxyUser = kingpin.Flag("controlplane.proxy.username", "Username of the authenticated proxy").Envar("CONTROLPLANE_PROXY_USERNAME").String()
+	controlPlaneProxyPass = kingpin.Flag("controlplane.proxy.password", "Password of the authenticated proxy").Envar("CONTROLPLANE_PROXY_PASSWORD").String()
+	controlPlaneMirrorURL = kingpin.Flag("controlplane.mirror-url", "URL of an additional Control Plane the reports are also sent to (e.g. a global control plane next to the regional one), with the client certificate of the agent. Every control plane has its own buffer and retries. (you can pass this flag multiple times)").Envar("CONTROLPLANE_MIRROR_URLS").PlaceHolder("http(s)://CONTROLPLANE-ADDRESS").Strings()
+	mirrorTokenFiles      = kingpin.Flag("controlplane.mirror-auth-token-file", "URL=FILE pair of the file containing the auth token of an additional Control Plane. The token of the Control Plane is not sent to the additional ones. (you can pass this flag multiple times)").Envar("CONTROLPLANE_MIRROR_AUTH_TOKEN_FILES").PlaceHolder("URL=FILE").StringMap()
+	mirrorCAFiles         = kingpin.Flag("controlplane.mirror-ca-file", "URL=FILE pair of the CA bundle to verify the certificate of an additional Control Plane against in addition to the system roots. (you can pass this flag multiple times)").Envar("CONTROLPLANE_MIRROR_CA_FILES").PlaceHolder("URL=FILE").StringMap()
+	controlPlaneStream    = kingpin.Flag("controlplane.stream", "Stream the reports to the control plane over a websocket and accept its refresh requests. The REST API is used while the stream is down").Envar("CONTROLPLANE_STREAM").Default("false").Bool()
+	controlPlaneGzip      = kingpin.Flag("controlplane.gzip", "Compress the reports sent to the Control Plane with gzip").Envar("CONTROLPLANE_GZIP").Default("false").Bool()
+	batchSize             = kingpin.Flag("agent.batch.size", "Maximum number of reports sent to the control plane in a single request. 0 sends the reports one by one").Envar("AGENT_BATCH_SIZE").Default("0").Int()
+	batchInterval         = kingpin.Flag("agent.batch.interval", "Maximum time to wait for a batch of reports to be full before sending it").Envar("AGENT_BATCH_INTERVAL").Default("5s").Duration()
+	reportDropFields      = kingpin.Flag("agent.report.drop-field", "Field to drop from the reports to shrink them for very large fleets: extractedFrom, image, digest or agentTags. The image digests are not resolved by the control plane without the image and digest. (you can pass this flag multiple times)").Envar("AGENT_REPORT_DROP_FIELDS").Enums(agent.ReportFields...)
+	reportCountsOnly      = kingpin.Flag("agent.report.counts-only", "Only report the version counts of the subjects, i.e. drop all the fields of --agent.report.drop-field").Envar("AGENT_REPORT_COUNTS_ONLY").Default("false").Bool()
+	maxReportFailures     = kingpin.Flag("agent.readiness.max-report-failures", "Number of consecutive failed reports to the Control Plane after which the agent is unready. 0 only fails the readiness when the authentication fails").Envar("AGENT_READINESS_MAX_REPORT_FAILURES").Default("3").Int()
+	bufferSize            = kingpin.Flag("agent.buffer.size", "Maximum number of reports to keep while the control plane is unreachable. Only the latest report of a subject is kept. 0 disables the buffer").Envar("AGENT_BUFFER_SIZE").Default("500").Int()
+	bufferFlushInterval   = kingpin.Flag("agent.buffer.flush-interval", "Interval to retry sending the buffered reports to the control plane").Envar("AGENT_BUFFER_FLUSH_INTERVAL").Default("15s").Duration()
+	clusterVersion        = kingpin.Flag("agent.cluster-version", "Report the API server version of the cluster").Envar("AGENT_CLUSTER_VERSION").Default("true").Bool()
+	clusterVersionProv    = kingpin.Flag("agent.cluster-version.provider", "Provider of the upstream versions of the API server (e.g. kubernetes, gke or eks)").Envar("AGENT_CLUSTER_VERSION_PROVIDER").Default("kubernetes").String()
+	clusterVersionStrat   = kingpin.Flag("agent.cluster-version.strategy", "Strategy of the provider of the upstream versions of the API server").Envar("AGENT_CLUSTER_VERSION_STRATEGY").Default("stable").String()
+	clusterVersionRepo    = kingpin.Flag("agent.cluster-version.repo", "Repo of the provider of the upstream versions of the API server (e.g. a GKE project/location or an EKS region)").Envar("AGENT_CLUSTER_VERSION_REPO").Default("kubernetes/kubernetes").String()
+	webhookEnabled        = kingpin.Flag("webhook.enabled", "Serve the validating admission webhook rejecting the invalid VersionTrackers and the conversion webhook of the VersionTracker versions").Envar("WEBHOOK_ENABLED").Default("false").Bool()
+	webhookCertDir        = kingpin.Flag("webhook.cert-dir", "Directory of the tls.crt and tls.key files of the webhook server").Envar("WEBHOOK_CERT_DIR").Default("/tmp/k8s-webhook-server/serving-certs").String()
 	logLevel              = kingpin.Flag("log.level", "The verbosity of the logging. Valid values are `debug`, `info`, `warn`, `error`").Envar("LOG_LEVEL").Default("info").String()
 )
 
@@ -65,6 +120,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -91,11 +147,19 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     *metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: *probeAddr,
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS, restConfig.Burst = *kubeAPIQPS, *kubeAPIBurst
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      *metricsAddr,
+		Port:                    9443,
+		CertDir:                 *webhookCertDir,
+		HealthProbeBindAddress:  *probeAddr,
+		LeaderElection:          *leaderElect,
+		LeaderElectionNamespace: *leaderElectionNS,
+		LeaderElectionID:        *leaderElectionID,
+		// the lock is released on shutdown so another replica takes over without waiting for the lease to expire
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start agent")
@@ -108,30 +172,267 @@ func main() {
 		setupLog.Error(err, "invalid agent identifier. it should not contain any special characters or spaces")
 		os.Exit(1)
 	}
+	if *agentJitter < 0 || *agentJitter > 1 {
+		setupLog.Error(fmt.Errorf("invalid jitter: %v", *agentJitter), "the jitter should be between 0 and 1")
+		os.Exit(1)
+	}
+	controlPlaneToken, err := utils.NewTokenFunc(*controlPlaneAuthToken, *controlPlaneTokenFile)
+	if err != nil {
+		setupLog.Error(err, "invalid control plane auth token file")
+		os.Exit(1)
+	}
+	controlPlaneTLSConfig, err := utils.NewClientTLSConfig(*controlPlaneCertFile, *controlPlaneKeyFile, *controlPlaneCAFile)
+	if err != nil {
+		setupLog.Error(err, "invalid control plane TLS configuration")
+		os.Exit(1)
+	}
+	var controlPlaneProxyURL *url.URL
+	if *controlPlaneProxy != "" {
+		controlPlaneProxyURL, err = url.Parse(*controlPlaneProxy)
+		if err != nil || controlPlaneProxyURL.Host == "" {
+			setupLog.Error(fmt.Errorf("invalid proxy url: %s", *controlPlaneProxy), "the proxy url should be like http(s)://host:port")
+			os.Exit(1)
+		}
+		if *controlPlaneProxyUser != "" {
+			controlPlaneProxyURL.User = url.UserPassword(*controlPlaneProxyUser, *controlPlaneProxyPass)
+		}
+	}
 	conf := &agent.Config{
-		Interval:              *agentInterval,
-		ID:                    *agentID,
-		ControlPlaneUrl:       *controlPlaneUrl,
-		ControlPlaneAuthToken: *controlPlaneAuthToken,
-		Tags:                  *agentTags,
-	}
-	if err = (&agent.VersionTrackerReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("opvic-agent"),
-		Scheme: mgr.GetScheme(),
-		Config: conf,
-	}).SetupWithManager(mgr); err != nil {
+		Interval:                *agentInterval,
+		ID:                      *agentID,
+		ControlPlaneUrl:         *controlPlaneUrl,
+		ControlPlaneAuthToken:   controlPlaneToken,
+		ControlPlaneTLSConfig:   controlPlaneTLSConfig,
+		ControlPlaneProxy:       agent.ProxyFunc(controlPlaneProxyURL),
+		ControlPlaneCompression: *controlPlaneGzip,
+		Stagger:                 *agentStagger,
+		Jitter:                  *agentJitter,
+		Tags:                    *agentTags,
+		Watch:                   *agentWatch,
+		Standalone:              *agentStandalone,
+		DryRun:                  *dryRun,
+		DryRunOutput:            *dryRunOutput,
+		DropFields:              *reportDropFields,
+		MaxReportFailures:       *maxReportFailures,
+		ClusterVersion: agent.ClusterVersionConfig{
+			Enabled:  *clusterVersion,
+			Provider: *clusterVersionProv,
+			Strategy: *clusterVersionStrat,
+			Repo:     *clusterVersionRepo,
+		},
+	}
+	if *agentSelector != "" {
+		conf.Selector, err = labels.Parse(*agentSelector)
+		if err != nil {
+			setupLog.Error(err, "invalid VersionTracker selector")
+			os.Exit(1)
+		}
+	}
+	if *reportCountsOnly {
+		conf.DropFields = agent.ReportFields
+	}
+	var fileConfig *agent.FileConfig
+	if *configFile != "" {
+		fileConfig, err = agent.LoadConfigFile(*configFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load the config file")
+			os.Exit(1)
+		}
+	}
+	if conf.Standalone {
+		// nothing is sent to the control plane
+		setupLog.Info("running standalone. the running versions are exported as metrics")
+		conf.ControlPlaneUrl = ""
+	}
+	// the stream, batches and buffer are set up for the control plane of the config file if it is set
+	controlPlaneURL := conf.ControlPlaneUrl
+	if fileConfig != nil {
+		controlPlaneURL = fileConfig.Apply(*conf).ControlPlaneUrl
+	}
+	if conf.DryRun {
+		// the reports are logged instead of being streamed, batched or buffered
+		setupLog.Info("running in dry run. the reports are not sent to the control plane", "output", conf.DryRunOutput)
+		controlPlaneURL = ""
+	}
+	var clusters []agent.Cluster
+	if *agentLocalCluster {
+		dynamicClient, err := dynamic.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create dynamic client")
+			os.Exit(1)
+		}
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create discovery client")
+			os.Exit(1)
+		}
+		clusters = append(clusters, agent.Cluster{
+			ID:            conf.ID,
+			Client:        mgr.GetClient(),
+			SecretsReader: mgr.GetAPIReader(),
+			Cache:         mgr.GetCache(),
+			Dynamic:       dynamicClient,
+			Discovery:     discoveryClient,
+		})
+	}
+	for id, kubeconfig := range *agentClusters {
+		if !regex.MatchString(id) || id == conf.ID {
+			setupLog.Error(fmt.Errorf("invalid cluster identifier: %s", id), "cluster identifiers should be unique and not contain any special characters or spaces")
+			os.Exit(1)
+		}
+		cluster, err := agent.NewRemoteCluster(id, kubeconfig, mgr.GetScheme(), *kubeAPIQPS, *kubeAPIBurst)
+		if err != nil {
+			setupLog.Error(err, "unable to create the clients of the cluster", "cluster", id)
+			os.Exit(1)
+		}
+		if err := mgr.Add(cluster.Cache); err != nil {
+			setupLog.Error(err, "unable to add the cache of the cluster", "cluster", id)
+			os.Exit(1)
+		}
+		clusters = append(clusters, cluster)
+	}
+	if len(clusters) == 0 {
+		setupLog.Error(fmt.Errorf("no clusters to track"), "the local cluster is disabled and no remote cluster is set")
+		os.Exit(1)
+	}
+	reconciler := &agent.VersionTrackerReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("opvic-agent"),
+		Scheme:   mgr.GetScheme(),
+		Config:   conf,
+		Clusters: clusters,
+		Recorder: mgr.GetEventRecorderFor("opvic-agent"),
+	}
+	if *apiBudget > 0 {
+		reconciler.Budget = agent.NewAPIBudget(*apiBudget)
+	}
+	if *agentShards > 1 {
+		if *leaderElect {
+			setupLog.Error(fmt.Errorf("sharding and leader election are exclusive"), "every shard reconciles its VersionTrackers without a leader")
+			os.Exit(1)
+		}
+		index := *agentShard
+		if index < 0 {
+			index, err = hostnameOrdinal()
+			if err != nil {
+				setupLog.Error(err, "unable to get the shard of the replica. set --agent.shard")
+				os.Exit(1)
+			}
+		}
+		reconciler.Shard, err = agent.NewShard(index, *agentShards)
+		if err != nil {
+			setupLog.Error(err, "invalid shard")
+			os.Exit(1)
+		}
+		setupLog.Info("sharding the VersionTrackers", "shard", index, "shards", *agentShards)
+	}
+	// the VersionTrackers are reconciled again on the refresh requests and the reloads of the config file
+	reconciler.Refresh = make(chan event.GenericEvent)
+	if fileConfig != nil {
+		reconciler.ApplyConfigFile(fileConfig)
+		watch := func(ctx context.Context) error {
+			return reconciler.WatchConfigFile(ctx, *configFile, *configReloadInterval)
+		}
+		if err := mgr.Add(manager.RunnableFunc(watch)); err != nil {
+			setupLog.Error(err, "unable to add the config file watcher")
+			os.Exit(1)
+		}
+	}
+	if *controlPlaneStream && controlPlaneURL != "" {
+		reconciler.Stream = agent.NewStreamer(controlPlaneURL, conf.ControlPlaneAuthToken, conf.ControlPlaneTLSConfig, conf.ControlPlaneProxy, ctrl.Log.WithName("stream"), reconciler.RefreshAll)
+		if err := mgr.Add(reconciler.Stream); err != nil {
+			setupLog.Error(err, "unable to add the control plane stream")
+			os.Exit(1)
+		}
+	}
+	if *bufferSize > 0 && controlPlaneURL != "" {
+		reconciler.Buffer = agent.NewReportBuffer(*bufferSize)
+		flush := func(ctx context.Context) error {
+			return reconciler.FlushReports(ctx, *bufferFlushInterval)
+		}
+		if err := mgr.Add(manager.RunnableFunc(flush)); err != nil {
+			setupLog.Error(err, "unable to add the report buffer")
+			os.Exit(1)
+		}
+	}
+	if controlPlaneURL != "" {
+		mirrorBufferSize := *bufferSize
+		if mirrorBufferSize <= 0 {
+			mirrorBufferSize = agent.DefaultMirrorBufferSize
+		}
+		for mirrorURL := range *mirrorTokenFiles {
+			if !utils.Contains(*controlPlaneMirrorURL, mirrorURL) {
+				setupLog.Error(fmt.Errorf("unknown mirror %s", mirrorURL), "the auth token file is not of an additional control plane")
+				os.Exit(1)
+			}
+		}
+		for mirrorURL := range *mirrorCAFiles {
+			if !utils.Contains(*controlPlaneMirrorURL, mirrorURL) {
+				setupLog.Error(fmt.Errorf("unknown mirror %s", mirrorURL), "the CA file is not of an additional control plane")
+				os.Exit(1)
+			}
+		}
+		for _, mirrorURL := range *controlPlaneMirrorURL {
+			// the mirrors are other control planes, so they don't get the token and the CA of the control plane
+			mirrorToken, err := utils.NewTokenFunc("", (*mirrorTokenFiles)[mirrorURL])
+			if err != nil {
+				setupLog.Error(err, "invalid auth token file of the control plane mirror", "url", mirrorURL)
+				os.Exit(1)
+			}
+			mirrorTLSConfig, err := utils.NewClientTLSConfig(*controlPlaneCertFile, *controlPlaneKeyFile, (*mirrorCAFiles)[mirrorURL])
+			if err != nil {
+				setupLog.Error(err, "invalid TLS configuration of the control plane mirror", "url", mirrorURL)
+				os.Exit(1)
+			}
+			mirror := agent.NewMirror(agent.ShipperConfig{
+				URL:       mirrorURL,
+				Token:     mirrorToken,
+				Timeout:   time.Second * 10,
+				TLSVerify: true,
+				TLSConfig: mirrorTLSConfig,
+				Proxy:     conf.ControlPlaneProxy,
+				Compress:  conf.ControlPlaneCompression,
+			}, mirrorBufferSize, *bufferFlushInterval, ctrl.Log.WithName("mirror"))
+			if err := mgr.Add(mirror); err != nil {
+				setupLog.Error(err, "unable to add the control plane mirror", "url", mirrorURL)
+				os.Exit(1)
+			}
+			reconciler.Mirrors = append(reconciler.Mirrors, mirror)
+		}
+	}
+	if *batchSize > 1 && controlPlaneURL != "" {
+		reconciler.Batcher = agent.NewReportBatcher(*batchSize, *batchInterval)
+		if err := mgr.Add(manager.RunnableFunc(reconciler.SendBatches)); err != nil {
+			setupLog.Error(err, "unable to add the report batcher")
+			os.Exit(1)
+		}
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VersionTracker")
 		os.Exit(1)
 	}
+	// a single replica reports the API server version
+	if conf.ClusterVersion.Enabled && reconciler.Shard.Owns(agent.ClusterVersionID) {
+		if err := mgr.Add(manager.RunnableFunc(reconciler.ReportClusterVersion)); err != nil {
+			setupLog.Error(err, "unable to add the cluster version reporter")
+			os.Exit(1)
+		}
+	}
 
+	if *webhookEnabled {
+		if err = (&v1alpha1.VersionTracker{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VersionTracker")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	// the agent is unready while its reports do not get to the control plane
+	if err := mgr.AddReadyzCheck("readyz", reconciler.ReadyzCheck); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
@@ -142,3 +443,20 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// hostnameOrdinal returns the ordinal of the StatefulSet pod from its hostname (e.g. 2 for opvic-agent-2)
+func hostnameOrdinal() (int, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("no ordinal in hostname %s", hostname)
+	}
+	ordinal, err := strconv.Atoi(hostname[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("no ordinal in hostname %s", hostname)
+	}
+	return ordinal, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	zaplib "go.uber.org/zap"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/skillz/opvic/agent"
+	"github.com/skillz/opvic/agent/host"
+	"github.com/skillz/opvic/utils"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	logLevelDebug = "debug"
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+
+	runtimeDocker     = "docker"
+	runtimeContainerd = "containerd"
+)
+
+var (
+	setupLog = ctrl.Log.WithName("opvic-host-agent-setup")
+
+	agentID               = kingpin.Flag("agent.identifier", "Agent unique identifier. Defaults to the hostname without its domain").Envar("AGENT_IDENTIFIER").String()
+	agentInterval         = kingpin.Flag("agent.interval", "Interval between the reports").Envar("AGENT_INTERVAL").Default("60s").Duration()
+	agentTags             = kingpin.Flag("agent.tags", "key:value pair to add to the agent tags. (you can pass this flag multiple times").Envar("AGENT_TAGS").PlaceHolder("KEY:VALUE").StringMap()
+	agentRegex            = kingpin.Flag("agent.regex", "Regex extracting the version from the image tags. The opvic.skillz.com/regex label of the containers overrides it").Envar("AGENT_REGEX").Default(host.DefaultRegex).String()
+	runtime               = kingpin.Flag("runtime", "Runtime to list the containers from, `docker` or `containerd`").Envar("RUNTIME").Default(runtimeDocker).Enum(runtimeDocker, runtimeContainerd)
+	dockerSocket          = kingpin.Flag("docker.socket", "Socket serving the Docker Engine API (e.g. the Docker daemon or the Docker compatible socket of Podman) for the docker runtime").Envar("DOCKER_SOCKET").Default(host.DefaultDockerSocket).String()
+	containerdSocket      = kingpin.Flag("containerd.socket", "Socket of containerd for the containerd runtime").Envar("CONTAINERD_SOCKET").Default(host.DefaultContainerdSocket).String()
+	containerdNamespace   = kingpin.Flag("containerd.namespace", "Namespace of the containers for the containerd runtime (e.g. default for nerdctl). The containers of all the namespaces are listed when empty").Envar("CONTAINERD_NAMESPACE").String()
+	controlPlaneUrl       = kingpin.Flag("controlplane.url", "Control Plane URL").Envar("CONTROLPLANE_URL").PlaceHolder("http(s)://CONTROLPLANE-ADDRESS").Required().String()
+	controlPlaneAuthToken = kingpin.Flag("controlplane.auth-token", "Control Plane Shared Auth Token").Envar("CONTROLPLANE_AUTH_TOKEN").String()
+	controlPlaneTokenFile = kingpin.Flag("controlplane.auth-token-file", "File containing the Control Plane Shared Auth Token (e.g. a mounted secret). It takes precedence over the token and is read again when it changes, so the token can be rotated without restarting").Envar("CONTROLPLANE_AUTH_TOKEN_FILE").String()
+	controlPlaneCertFile  = kingpin.Flag("controlplane.tls.cert-file", "Client certificate file to authenticate with the Control Plane over mutual TLS. It is reloaded when it changes").Envar("CONTROLPLANE_TLS_CERT_FILE").String()
+	controlPlaneKeyFile   = kingpin.Flag("controlplane.tls.key-file", "Key file of the client certificate").Envar("CONTROLPLANE_TLS_KEY_FILE").String()
+	controlPlaneCAFile    = kingpin.Flag("controlplane.tls.ca-file", "CA bundle to verify the Control Plane certificate against in addition to the system roots").Envar("CONTROLPLANE_TLS_CA_FILE").String()
+	controlPlaneProxy     = kingpin.Flag("controlplane.proxy.url", "Forward proxy of the connections to the Control Plane (e.g. http://proxy:3128). Defaults to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables").Envar("CONTROLPLANE_PROXY_URL").String()
+	controlPlaneProxyUser = kingpin.Flag("controlplane.proxy.username", "Username of the authenticated proxy").Envar("CONTROLPLANE_PROXY_USERNAME").String()
+	controlPlaneProxyPass = kingpin.Flag("controlplane.proxy.password", "Password of the authenticated proxy").Envar("CONTROLPLANE_PROXY_PASSWORD").String()
+	controlPlaneGzip      = kingpin.Flag("controlplane.gzip", "Compress the reports sent to the Control Plane with gzip").Envar("CONTROLPLANE_GZIP").Default("false").Bool()
+	logLevel              = kingpin.Flag("log.level", "The verbosity of the logging. Valid values are `debug`, `info`, `warn`, `error`").Envar("LOG_LEVEL").Default("info").String()
+)
+
+func main() {
+	kingpin.Version(fmt.Sprintf("%s\n%s", utils.VersionInfo(), utils.BuildContext()))
+	kingpin.Parse()
+
+	logger := zap.New(func(o *zap.Options) {
+		switch *logLevel {
+		case logLevelDebug:
+			o.Development = true
+		case logLevelInfo:
+			lvl := zaplib.NewAtomicLevelAt(zaplib.InfoLevel)
+			o.Level = &lvl
+		case logLevelWarn:
+			lvl := zaplib.NewAtomicLevelAt(zaplib.WarnLevel)
+			o.Level = &lvl
+		case logLevelError:
+			lvl := zaplib.NewAtomicLevelAt(zaplib.ErrorLevel)
+			o.Level = &lvl
+		}
+	})
+
+	ctrl.SetLogger(logger)
+
+	if *agentID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "unable to get the hostname, set the agent identifier")
+			os.Exit(1)
+		}
+		*agentID = strings.SplitN(hostname, ".", 2)[0]
+	}
+	// validate the Agent ID. it should not contain any special characters
+	regex := regexp.MustCompile(`^[a-zA-Z0-9\-]+$`)
+	if !regex.MatchString(*agentID) {
+		setupLog.Error(fmt.Errorf("invalid agent identifier: %s", *agentID), "it should not contain any special characters or spaces, set the agent identifier")
+		os.Exit(1)
+	}
+
+	controlPlaneToken, err := utils.NewTokenFunc(*controlPlaneAuthToken, *controlPlaneTokenFile)
+	if err != nil {
+		setupLog.Error(err, "invalid control plane auth token file")
+		os.Exit(1)
+	}
+	controlPlaneTLSConfig, err := utils.NewClientTLSConfig(*controlPlaneCertFile, *controlPlaneKeyFile, *controlPlaneCAFile)
+	if err != nil {
+		setupLog.Error(err, "invalid control plane TLS configuration")
+		os.Exit(1)
+	}
+	var controlPlaneProxyURL *url.URL
+	if *controlPlaneProxy != "" {
+		controlPlaneProxyURL, err = url.Parse(*controlPlaneProxy)
+		if err != nil || controlPlaneProxyURL.Host == "" {
+			setupLog.Error(fmt.Errorf("invalid proxy url: %s", *controlPlaneProxy), "the proxy url should be like http(s)://host:port")
+			os.Exit(1)
+		}
+		if *controlPlaneProxyUser != "" {
+			controlPlaneProxyURL.User = url.UserPassword(*controlPlaneProxyUser, *controlPlaneProxyPass)
+		}
+	}
+
+	var containerRuntime host.Runtime
+	socket := *dockerSocket
+	switch *runtime {
+	case runtimeContainerd:
+		socket = *containerdSocket
+		containerRuntime, err = host.NewContainerdClient(socket, *containerdNamespace)
+		if err != nil {
+			setupLog.Error(err, "unable to connect to containerd", "socket", socket)
+			os.Exit(1)
+		}
+	default:
+		containerRuntime = host.NewDockerClient(socket)
+	}
+
+	reporter := &host.Reporter{
+		Config: host.Config{
+			ID:       *agentID,
+			Interval: *agentInterval,
+			Tags:     *agentTags,
+			Regex:    *agentRegex,
+		},
+		Runtime: containerRuntime,
+		Shipper: agent.NewShipper(&agent.ShipperConfig{
+			URL:       *controlPlaneUrl,
+			Token:     controlPlaneToken,
+			Timeout:   time.Second * 10,
+			TLSVerify: true,
+			TLSConfig: controlPlaneTLSConfig,
+			Proxy:     agent.ProxyFunc(controlPlaneProxyURL),
+			Compress:  *controlPlaneGzip,
+		}),
+		Log: ctrl.Log.WithName("host"),
+	}
+
+	if err := reporter.Runtime.Ping(context.Background()); err != nil {
+		setupLog.Error(err, "the socket does not serve the API of the runtime", "runtime", *runtime, "socket", socket)
+		os.Exit(1)
+	}
+	setupLog.Info("starting the host agent", "id", *agentID, "runtime", *runtime, "socket", socket)
+	if err := reporter.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running the host agent")
+		os.Exit(1)
+	}
+}
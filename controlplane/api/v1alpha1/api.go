@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/skillz/opvic/agent/api/v1alpha1"
 )
@@ -18,9 +19,16 @@ const (
 	AgentAPIPath                 = "/agents/:id"
 	AgentsSubjectVersionPath     = "/agents/:id/:versionId"
 	AgentsSubjectVersionInfoPath = "/agents/:id/:versionId/versions"
+	AgentRefreshPath             = "/agents/:id/refresh"
+
+	// Stream endpoint of the agents reporting over a websocket
+	StreamAPIPath = "/stream"
 
 	// Control Plane endpoints
 	OverviewAPIPath = "/overview"
+
+	// Webhook endpoints
+	GithubWebhookPath = "/webhooks/github"
 )
 
 var (
@@ -30,6 +38,19 @@ var (
 	AgentAPIEndpoint                 = GetAPIEndpoint(AgentAPIPath)
 	AgentsSubjectVersionEndpoint     = GetAPIEndpoint(AgentsSubjectVersionPath)
 	AgentsSubjectVersionInfoEndpoint = GetAPIEndpoint(AgentsSubjectVersionInfoPath)
+	GithubWebhookEndpoint            = GetAPIEndpoint(GithubWebhookPath)
+	AgentRefreshEndpoint             = GetAPIEndpoint(AgentRefreshPath)
+	StreamAPIEndpoint                = GetAPIEndpoint(StreamAPIPath)
+)
+
+// Types of the stream messages
+const (
+	// StreamMessageReport is a report of an agent
+	StreamMessageReport = "report"
+	// StreamMessageAck acknowledges a report. The agent waits for it before sending the next report
+	StreamMessageAck = "ack"
+	// StreamMessageRefresh requests the agent to report all its subjects
+	StreamMessageRefresh = "refresh"
 )
 
 // gets the end point in `/<path>` format and returns (/api/<version>/<endpoint>)
@@ -65,6 +86,18 @@ type AgentPayload struct {
 	Version SubjectVersion `json:"version" binding:"required"`
 }
 
+// StreamMessage is a message of the stream between an agent and the control plane
+type StreamMessage struct {
+	// Type of the message (report, ack or refresh)
+	Type string `json:"type"`
+	// Sequence number of the report, echoed in its acknowledgement
+	Seq uint64 `json:"seq,omitempty"`
+	// Payload of the report
+	Payload *AgentPayload `json:"payload,omitempty"`
+	// Error of a report that was not accepted
+	Error string `json:"error,omitempty"`
+}
+
 // SubjectVersion contains all versions collected for a subject
 type SubjectVersion struct {
 	// Identifier of the subject
@@ -94,6 +127,12 @@ type Version struct {
 	ResourceKind string `json:"resourceKind"`
 	// Field value that version is extracted from
 	ExtractedFrom string `json:"extractedFrom"`
+	// Repository of the image the version is extracted from
+	Image string `json:"image,omitempty"`
+	// Digest of the image (e.g. sha256:...)
+	Digest string `json:"digest,omitempty"`
+	// Tags of the image pointing to the digest, resolved by the control plane for the images pinned by digest
+	Tags []string `json:"tags,omitempty"`
 }
 
 // VersionInfo contains information the running and remote versions of a subject
@@ -122,6 +161,10 @@ type VersionInfo struct {
 	MinorAvailable bool `json:"minorAvailable"`
 	// Boolean indicating if a newer patch version is available
 	PatchAvailable bool `json:"patchAvailable"`
+	// Date the running version was published if the remote provider exposes it
+	RunningPublishedAt *time.Time `json:"runningPublishedAt,omitempty"`
+	// Number of days between the publish dates of the running and the latest versions
+	DaysBehind *int `json:"daysBehind,omitempty"`
 }
 
 // VersionInfos holds all the information on a subject version
@@ -136,12 +179,23 @@ type VersionInfos struct {
 	RunningVersions []string `json:"runningVersions"`
 	// Latest version based on the remote provider configuration
 	LatestVersion string `json:"latestVersion"`
+	// Date the latest version was published if the remote provider exposes it
+	LatestPublishedAt *time.Time `json:"latestPublishedAt,omitempty"`
 	// Remote provider for extracting remote versions
 	RemoteProvider string `json:"remoteProvider"`
 	// Remote repository or extracting remote versions
 	RemoteRepo string `json:"remoteRepo"`
 	// List of all VersionInfos collected for the subject
 	Versions []VersionInfo `json:"versions"`
+	// Release assets of the latest version when the assets strategy of the github provider is used
+	LatestAssets []Asset `json:"latestAssets,omitempty"`
+}
+
+// Asset is a downloadable artifact of a remote version
+type Asset struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Digest string `json:"digest,omitempty"`
 }
 
 type AgentVersionInfos []VersionInfos
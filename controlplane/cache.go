@@ -2,17 +2,20 @@ package controlplane
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jasonlvhit/gocron"
-	"github.com/patrickmn/go-cache"
 	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/storage"
 	"github.com/skillz/opvic/utils"
 )
 
 const (
 	// holds the list of all the agents that have been registered with the control plane
 	AgentListCacheKey = "agents/list"
+	// holds the repos whose remote versions are to be refreshed by the leader
+	RemoteRefreshListCacheKey = "refresh/list"
 )
 
 // Cach key for storing all the versions recieved from an agent in format:
@@ -39,60 +42,125 @@ func AgentSubjectVersionListCacheKey(agentID string) string {
 	return fmt.Sprintf("%s/versions/list", agentID)
 }
 
+// Cach key of the last time a refresh of all the subjects of an agent was requested, in unix nanoseconds:
+// agents/:agentID/refresh
+func AgentRefreshCacheKey(agentID string) string {
+	return fmt.Sprintf("agents/%s/refresh", agentID)
+}
+
+// Cach key of the last time an agent was seen connected to the stream of a replica, in unix nanoseconds. It is 0
+// once the agent disconnected: agents/:agentID/stream
+func AgentStreamCacheKey(agentID string) string {
+	return fmt.Sprintf("agents/%s/stream", agentID)
+}
+
+// remoteRefresh is a repo whose remote versions are to be refreshed
+type remoteRefresh struct {
+	Provider string `json:"provider"`
+	Repo     string `json:"repo"`
+}
+
+// get reads the value of the key from the store. The errors of the store are logged and the value is missing
+func (cp *ControlPlane) get(key string, value interface{}) bool {
+	found, err := cp.store.Get(key, value)
+	if err != nil {
+		cp.log.Error(err, "failed to read from the store", "key", key)
+		cp.storeErrors.WithLabelValues("get").Inc()
+		return false
+	}
+	return found
+}
+
+// set writes the value of the key to the store. The errors of the store are logged
+func (cp *ControlPlane) set(key string, value interface{}) {
+	if err := cp.store.Set(key, value); err != nil {
+		cp.log.Error(err, "failed to write to the store", "key", key)
+		cp.storeErrors.WithLabelValues("set").Inc()
+	}
+}
+
+// lock acquires the lock of the key to serialize its updates with the other requests and replicas. The errors of
+// the store are logged and the key is updated without the lock
+func (cp *ControlPlane) lock(key string) func() {
+	locker, ok := cp.store.(storage.Locker)
+	if !ok {
+		return func() {}
+	}
+	unlock, err := locker.Lock(key)
+	if err != nil {
+		cp.log.Error(err, "failed to lock the key of the store", "key", key)
+		cp.storeErrors.WithLabelValues("lock").Inc()
+		return func() {}
+	}
+	return unlock
+}
+
 // SetAgentCache put the subjectVersions in the cache in the AgentCacheKey path
 func (cp *ControlPlane) SetAgentCache(agentID string, subjectVersions api.SubjectVersions) {
-	cp.cache.Set(AgentCacheKey(agentID), subjectVersions, cache.DefaultExpiration)
+	cp.set(AgentCacheKey(agentID), subjectVersions)
 }
 
 // GetAgentCache gets the SubjectVersions for an agent from the AgentCacheKey path cache
 func (cp *ControlPlane) GetAgentCache(agentID string) (api.SubjectVersions, bool) {
-
-	subjectVersions, found := cp.cache.Get(AgentCacheKey(agentID))
-	if !found {
+	var subjectVersions api.SubjectVersions
+	if !cp.get(AgentCacheKey(agentID), &subjectVersions) {
 		return api.SubjectVersions{}, false
 	}
-	return subjectVersions.(api.SubjectVersions), true
+	return subjectVersions, true
 }
 
 // SetAgentCache sets the version in the agent payload in the cache
 func (cp *ControlPlane) SetSubjectVersionCache(agentID, versionID string, subjectVersion api.SubjectVersion) {
-	cp.cache.Set(SubjectVersionCacheKey(agentID, versionID), subjectVersion, cache.DefaultExpiration)
+	cp.set(SubjectVersionCacheKey(agentID, versionID), subjectVersion)
+	if cp.history != nil {
+		if err := cp.history.RecordSubjectVersion(agentID, subjectVersion); err != nil {
+			cp.log.Error(err, "failed to record the subject version history", "agent", agentID, "version_id", versionID)
+			cp.storeErrors.WithLabelValues("record").Inc()
+		}
+	}
 }
 
 func (cp *ControlPlane) GetSubjectVersionCache(agent, versionID string) (api.SubjectVersion, bool) {
-	subjectVersion, found := cp.cache.Get(SubjectVersionCacheKey(agent, versionID))
-	if !found {
+	var subjectVersion api.SubjectVersion
+	if !cp.get(SubjectVersionCacheKey(agent, versionID), &subjectVersion) {
 		return api.SubjectVersion{}, false
 	}
-	return subjectVersion.(api.SubjectVersion), true
+	return subjectVersion, true
 }
 
 func (cp *ControlPlane) SetSubjectVersionInfoCache(agentID, versionID string, versionInfo api.VersionInfos) {
-	cp.cache.Set(SubjectVersionInfoCacheKey(agentID, versionID), versionInfo, cache.DefaultExpiration)
+	cp.set(SubjectVersionInfoCacheKey(agentID, versionID), versionInfo)
+	if cp.history != nil {
+		if err := cp.history.RecordVersionInfos(versionInfo); err != nil {
+			cp.log.Error(err, "failed to record the remote versions history", "agent", agentID, "version_id", versionID)
+			cp.storeErrors.WithLabelValues("record").Inc()
+		}
+	}
 }
 
 func (cp *ControlPlane) GetSubjectVersionInfoCache(agentID, versionID string) (api.VersionInfos, bool) {
-	versionInfo, found := cp.cache.Get(SubjectVersionInfoCacheKey(agentID, versionID))
-	if !found {
+	var versionInfo api.VersionInfos
+	if !cp.get(SubjectVersionInfoCacheKey(agentID, versionID), &versionInfo) {
 		return api.VersionInfos{}, false
 	}
-	return versionInfo.(api.VersionInfos), true
+	return versionInfo, true
 }
 
 func (cp *ControlPlane) SetAgentListCache(agents api.Agents) {
-	cp.cache.Set(AgentListCacheKey, agents, cache.DefaultExpiration)
+	cp.set(AgentListCacheKey, agents)
 }
 
 func (cp *ControlPlane) GetAgentListCache() api.Agents {
-	agents, found := cp.cache.Get(AgentListCacheKey)
-	if !found {
+	var agents api.Agents
+	if !cp.get(AgentListCacheKey, &agents) {
 		return api.Agents{}
 	}
-	return agents.(api.Agents)
+	return agents
 }
 
 // Check cache and update if necessary
 func (cp *ControlPlane) UpdateAgentListCache(agentId string, agentTags map[string]string) {
+	defer cp.lock(AgentListCacheKey)()
 	agents := cp.GetAgentListCache()
 	found := false
 	for _, agent := range agents {
@@ -113,18 +181,19 @@ func (cp *ControlPlane) UpdateAgentListCache(agentId string, agentTags map[strin
 }
 
 func (cp *ControlPlane) SetAgentSubjectVersionListCache(agentID string, list []string) {
-	cp.cache.Set(AgentSubjectVersionListCacheKey(agentID), list, cache.DefaultExpiration)
+	cp.set(AgentSubjectVersionListCacheKey(agentID), list)
 }
 
 func (cp *ControlPlane) GetAgentSubjectVersionListCache(agentID string) []string {
-	list, found := cp.cache.Get(AgentSubjectVersionListCacheKey(agentID))
-	if !found {
+	var list []string
+	if !cp.get(AgentSubjectVersionListCacheKey(agentID), &list) {
 		return []string{}
 	}
-	return list.([]string)
+	return list
 }
 
 func (cp *ControlPlane) UpdateAgentSubjectVersionsList(agentId, versionId string) {
+	defer cp.lock(AgentSubjectVersionListCacheKey(agentId))()
 	subjectList := cp.GetAgentSubjectVersionListCache(agentId)
 	if !utils.Contains(subjectList, versionId) {
 		subjectList = append(subjectList, versionId)
@@ -132,11 +201,19 @@ func (cp *ControlPlane) UpdateAgentSubjectVersionsList(agentId, versionId string
 	}
 }
 
+// CacheReconcile only runs on the leader so the replicas don't refresh the same remote versions
 func (cp *ControlPlane) CacheReconcile() {
 	log := cp.log.WithName("cache-reconcile")
+	if !cp.isLeader() {
+		log.V(1).Info("skipping cache reconcile on a replica that is not the leader")
+		return
+	}
 	log.Info("starting cache reconcile")
 
-	cp.cache.DeleteExpired()
+	if store, ok := cp.store.(storage.Expirer); ok {
+		store.DeleteExpired()
+	}
+	cp.RemoteRefreshReconcile()
 	cp.AgentListCacheReconcile()
 	cp.AgentCacheReconcile()
 	cp.SubjectVersionInfoCacheReconcile()
@@ -146,6 +223,7 @@ func (cp *ControlPlane) CacheReconcile() {
 
 func (cp *ControlPlane) AgentListCacheReconcile() {
 	log := cp.log.WithName("cache")
+	defer cp.lock(AgentListCacheKey)()
 	agents := cp.GetAgentListCache()
 	newAgents := api.Agents{}
 	for _, agent := range agents {
@@ -161,6 +239,7 @@ func (cp *ControlPlane) AgentCacheReconcile() {
 	log := cp.log.WithName("cache")
 	agents := cp.GetAgentListCache()
 	for _, agent := range agents.ListIDs() {
+		unlock := cp.lock(AgentSubjectVersionListCacheKey(agent))
 		subjectVersions := []*api.SubjectVersion{}
 		versionList := []string{}
 		for _, versionID := range cp.GetAgentSubjectVersionListCache(agent) {
@@ -172,6 +251,7 @@ func (cp *ControlPlane) AgentCacheReconcile() {
 		log.Info("updating registered subject versions in cache", "agent", agent, "subject versions count", len(subjectVersions))
 		cp.SetAgentCache(agent, subjectVersions)
 		cp.SetAgentSubjectVersionListCache(agent, versionList)
+		unlock()
 	}
 }
 
@@ -194,6 +274,71 @@ func (cp *ControlPlane) SubjectVersionInfoCacheReconcile() {
 	}
 }
 
+// RefreshRemoteVersions invalidates the cached remote versions of the repo and updates the
+// version infos of all the subjects tracking it
+func (cp *ControlPlane) RefreshRemoteVersions(provider, repo string) {
+	log := cp.log.WithName("cache").WithValues("provider", provider, "repo", repo)
+	invalidated := map[string]bool{}
+	agents := cp.GetAgentListCache()
+	for _, agent := range agents.ListIDs() {
+		appvers, found := cp.GetAgentCache(agent)
+		if !found {
+			continue
+		}
+		for _, ver := range appvers {
+			if ver.RemoteVersion.Provider != provider || !strings.EqualFold(ver.RemoteVersion.Repo, repo) {
+				continue
+			}
+			if !invalidated[ver.RemoteVersion.Repo] {
+				cp.provider.InvalidateCache(provider, ver.RemoteVersion.Repo)
+				invalidated[ver.RemoteVersion.Repo] = true
+			}
+			verInfos, err := cp.GetSubjectVersionInfos(agent, ver)
+			if err != nil {
+				log.Error(err, "error getting subject version info", "version_id", ver.ID)
+				continue
+			}
+			cp.SetSubjectVersionInfoCache(agent, ver.ID, verInfos)
+		}
+	}
+	if len(invalidated) == 0 {
+		log.V(1).Info("no subject is tracking the repo")
+	}
+}
+
+// RequestRemoteRefresh refreshes the remote versions of the repo on the leader. The replicas that are not the leader
+// add the repo to the refresh list of the store, refreshed by the next cache reconcile of the leader, so the
+// remote versions cached by the leader are invalidated too
+func (cp *ControlPlane) RequestRemoteRefresh(provider, repo string) {
+	if cp.isLeader() {
+		go cp.RefreshRemoteVersions(provider, repo)
+		return
+	}
+	defer cp.lock(RemoteRefreshListCacheKey)()
+	var list []remoteRefresh
+	cp.get(RemoteRefreshListCacheKey, &list)
+	for _, r := range list {
+		if r.Provider == provider && strings.EqualFold(r.Repo, repo) {
+			return
+		}
+	}
+	cp.set(RemoteRefreshListCacheKey, append(list, remoteRefresh{Provider: provider, Repo: repo}))
+	cp.log.V(1).Info("requested the leader to refresh the remote versions", "provider", provider, "repo", repo)
+}
+
+// RemoteRefreshReconcile refreshes the repos of the refresh list requested by the other replicas
+func (cp *ControlPlane) RemoteRefreshReconcile() {
+	unlock := cp.lock(RemoteRefreshListCacheKey)
+	var list []remoteRefresh
+	if cp.get(RemoteRefreshListCacheKey, &list) && len(list) > 0 {
+		cp.set(RemoteRefreshListCacheKey, []remoteRefresh{})
+	}
+	unlock()
+	for _, r := range list {
+		cp.RefreshRemoteVersions(r.Provider, r.Repo)
+	}
+}
+
 func (cp *ControlPlane) executeCronJobs() {
 	interval := uint64(cp.cacheReconcilerInterval.Seconds())
 	gocron.Every(interval).Second().Do(cp.CacheReconcile)
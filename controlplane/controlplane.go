@@ -2,7 +2,9 @@ package controlplane
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -10,15 +12,79 @@ import (
 	"github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/skillz/opvic/controlplane/providers"
+	"github.com/skillz/opvic/controlplane/providers/acr"
+	"github.com/skillz/opvic/controlplane/providers/apt"
+	"github.com/skillz/opvic/controlplane/providers/artifactory"
+	"github.com/skillz/opvic/controlplane/providers/azuredevops"
+	"github.com/skillz/opvic/controlplane/providers/bitbucket"
+	"github.com/skillz/opvic/controlplane/providers/crates"
+	"github.com/skillz/opvic/controlplane/providers/ecr"
+	"github.com/skillz/opvic/controlplane/providers/eks"
+	"github.com/skillz/opvic/controlplane/providers/gar"
+	"github.com/skillz/opvic/controlplane/providers/git"
 	"github.com/skillz/opvic/controlplane/providers/github"
+	"github.com/skillz/opvic/controlplane/providers/gitlab"
+	"github.com/skillz/opvic/controlplane/providers/gke"
+	"github.com/skillz/opvic/controlplane/providers/helm"
+	"github.com/skillz/opvic/controlplane/providers/html"
+	"github.com/skillz/opvic/controlplane/providers/kubernetes"
+	"github.com/skillz/opvic/controlplane/providers/maven"
+	"github.com/skillz/opvic/controlplane/providers/nexus"
+	"github.com/skillz/opvic/controlplane/providers/npm"
+	"github.com/skillz/opvic/controlplane/providers/nuget"
+	"github.com/skillz/opvic/controlplane/providers/oci"
+	"github.com/skillz/opvic/controlplane/providers/olm"
+	"github.com/skillz/opvic/controlplane/providers/packagist"
+	"github.com/skillz/opvic/controlplane/providers/pypi"
+	"github.com/skillz/opvic/controlplane/providers/quay"
+	"github.com/skillz/opvic/controlplane/providers/s3"
+	"github.com/skillz/opvic/controlplane/providers/yum"
+	"github.com/skillz/opvic/controlplane/storage"
+	"github.com/skillz/opvic/utils"
+	"k8s.io/client-go/tools/leaderelection"
 )
 
 type Config struct {
 	BindAddr                string
 	Token                   *string
+	TLSCertFile             string
+	TLSKeyFile              string
+	TLSClientCAFile         string
+	ProviderProxyURL        string
+	ProviderCAFile          string
 	GithubConfig            *github.Config
+	HelmConfig              *helm.Config
+	GitlabConfig            *gitlab.Config
+	BitbucketConfig         *bitbucket.Config
+	OCIConfig               *oci.Config
+	ECRConfig               *ecr.Config
+	GARConfig               *gar.Config
+	ACRConfig               *acr.Config
+	QuayConfig              *quay.Config
+	PyPIConfig              *pypi.Config
+	NPMConfig               *npm.Config
+	MavenConfig             *maven.Config
+	CratesConfig            *crates.Config
+	HTMLConfig              *html.Config
+	GitConfig               *git.Config
+	AzureDevOpsConfig       *azuredevops.Config
+	S3Config                *s3.Config
+	ArtifactoryConfig       *artifactory.Config
+	NexusConfig             *nexus.Config
+	NuGetConfig             *nuget.Config
+	PackagistConfig         *packagist.Config
+	APTConfig               *apt.Config
+	YUMConfig               *yum.Config
+	OLMConfig               *olm.Config
+	KubernetesConfig        *kubernetes.Config
+	GKEConfig               *gke.Config
+	EKSConfig               *eks.Config
 	CacheExpiration         time.Duration
 	CacheReconcilerInterval time.Duration
+	Store                   storage.Store
+	History                 storage.History
+	LeaderElection          *LeaderElectionConfig
+	GithubWebhookSecret     string
 	LogHttpRequests         bool
 	Logger                  logr.Logger
 }
@@ -26,14 +92,22 @@ type Config struct {
 type ControlPlane struct {
 	bindAddr                string
 	token                   *string
-	cache                   *cache.Cache
+	tlsConfig               *tls.Config
+	clientAuth              bool
+	store                   storage.Store
+	history                 storage.History
+	elector                 *leaderelection.LeaderElector
 	cacheExpiration         time.Duration
 	cacheReconcilerInterval time.Duration
 	provider                *providers.Provider
 	mutex                   sync.RWMutex
 	logHttpsRequests        bool
+	githubWebhookSecret     string
 	log                     logr.Logger
 	reqCount                *prometheus.CounterVec
+	storeErrors             *prometheus.CounterVec
+	// connected agent streams by agent ID
+	streams sync.Map
 }
 
 func (conf *Config) NewControlPlane() (*ControlPlane, error) {
@@ -44,25 +118,82 @@ func (conf *Config) NewControlPlane() (*ControlPlane, error) {
 	if conf.Token == nil {
 		return nil, fmt.Errorf("missing token")
 	}
+	var tlsConfig *tls.Config
+	if conf.TLSCertFile != "" || conf.TLSKeyFile != "" {
+		var err error
+		tlsConfig, err = utils.NewServerTLSConfig(conf.TLSCertFile, conf.TLSKeyFile, conf.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+	} else if conf.TLSClientCAFile != "" {
+		return nil, fmt.Errorf("a certificate and a key are required to verify the client certificates")
+	}
 
 	pConf := providers.Config{
-		Logger: log,
-		Github: conf.GithubConfig,
+		Logger:      log,
+		ProxyURL:    conf.ProviderProxyURL,
+		CAFile:      conf.ProviderCAFile,
+		Github:      conf.GithubConfig,
+		Helm:        conf.HelmConfig,
+		Gitlab:      conf.GitlabConfig,
+		Bitbucket:   conf.BitbucketConfig,
+		OCI:         conf.OCIConfig,
+		ECR:         conf.ECRConfig,
+		GAR:         conf.GARConfig,
+		ACR:         conf.ACRConfig,
+		Quay:        conf.QuayConfig,
+		PyPI:        conf.PyPIConfig,
+		NPM:         conf.NPMConfig,
+		Maven:       conf.MavenConfig,
+		Crates:      conf.CratesConfig,
+		HTML:        conf.HTMLConfig,
+		Git:         conf.GitConfig,
+		AzureDevOps: conf.AzureDevOpsConfig,
+		S3:          conf.S3Config,
+		Artifactory: conf.ArtifactoryConfig,
+		Nexus:       conf.NexusConfig,
+		NuGet:       conf.NuGetConfig,
+		Packagist:   conf.PackagistConfig,
+		APT:         conf.APTConfig,
+		YUM:         conf.YUMConfig,
+		OLM:         conf.OLMConfig,
+		Kubernetes:  conf.KubernetesConfig,
+		GKE:         conf.GKEConfig,
+		EKS:         conf.EKSConfig,
 	}
 	log.Info("initializing the remote providers")
 	provider, err := pConf.Init(ctx, cache)
 	if err != nil {
 		return nil, err
 	}
+	store := conf.Store
+	if store == nil {
+		store = storage.NewMemoryStore(cache)
+	}
+	history := conf.History
+	if history == nil {
+		history, _ = store.(storage.History)
+	}
+	var elector *leaderelection.LeaderElector
+	if conf.LeaderElection != nil {
+		if elector, err = newLeaderElector(conf.LeaderElection, log); err != nil {
+			return nil, err
+		}
+	}
 	return &ControlPlane{
 		bindAddr:                conf.BindAddr,
 		token:                   conf.Token,
-		cache:                   cache,
+		tlsConfig:               tlsConfig,
+		clientAuth:              conf.TLSClientCAFile != "",
+		store:                   store,
+		history:                 history,
+		elector:                 elector,
 		cacheExpiration:         conf.CacheExpiration,
 		cacheReconcilerInterval: conf.CacheReconcilerInterval,
 		provider:                provider,
 		mutex:                   sync.RWMutex{},
 		logHttpsRequests:        conf.LogHttpRequests,
+		githubWebhookSecret:     conf.GithubWebhookSecret,
 		log:                     log,
 		reqCount: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricNamespace,
@@ -70,18 +201,41 @@ func (conf *Config) NewControlPlane() (*ControlPlane, error) {
 			Name:      "requests_total",
 			Help:      "The number of HTTP requests processed",
 		}, []string{"method", "path", "status"}),
+		storeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricSubsystem,
+			Name:      "store_errors_total",
+			Help:      "The number of failed operations of the store",
+		}, []string{"operation"}),
 	}, nil
 }
 
 func (cp *ControlPlane) Start() {
-	prometheus.MustRegister(cp.reqCount)
+	prometheus.MustRegister(cp.reqCount, cp.storeErrors)
 
 	cp.log.V(1).Info("setting up the routes")
 	r := cp.SetupRouter()
 
 	cp.log.V(1).Info("starting the background cache reconciler")
 	go cp.executeCronJobs()
+	if cp.elector != nil {
+		go cp.runLeaderElection()
+		go cp.pollRefreshRequests()
+	}
 
+	if cp.tlsConfig != nil {
+		cp.log.Info("starting the HTTPS server", "bind_addr", cp.bindAddr, "client_auth", cp.clientAuth)
+		srv := &http.Server{
+			Addr:      cp.bindAddr,
+			Handler:   r,
+			TLSConfig: cp.tlsConfig,
+		}
+		// the certificate is served by the TLS config
+		if err := srv.ListenAndServeTLS("", ""); err != nil {
+			cp.log.Error(err, "HTTPS server stopped")
+		}
+		return
+	}
 	cp.log.Info("starting the HTTP server", "bind_addr", cp.bindAddr)
 	r.Run(cp.bindAddr)
 }
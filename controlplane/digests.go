@@ -0,0 +1,66 @@
+package controlplane
+
+import (
+	"reflect"
+	"strings"
+
+	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// pinned returns true if the version is extracted from an image pinned by digest (e.g. nginx@sha256:...)
+func pinned(v api.Version) bool {
+	return v.Image != "" && v.Digest != "" && strings.Contains(v.ExtractedFrom, "@")
+}
+
+// hasPinnedDigests returns true if the digests of the subject are to be resolved to their tags
+func (cp *ControlPlane) hasPinnedDigests(sv api.SubjectVersion) bool {
+	if cp.provider == nil || cp.provider.OCI == nil || !cp.provider.OCI.ResolvesDigests() {
+		return false
+	}
+	for _, v := range sv.Versions {
+		if pinned(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDigests resolves the digests of the images pinned by digest to their tags with the oci provider. The
+// running version of a pinned image becomes the version extracted from its tags with the extraction regex of the
+// remote version instead of the digest. The subject version is only updated if no newer report was stored meanwhile
+func (cp *ControlPlane) resolveDigests(agentID string, reported api.SubjectVersion) {
+	sv := reported
+	sv.RunningVersions = append([]string{}, reported.RunningVersions...)
+	sv.Versions = append([]api.Version{}, reported.Versions...)
+	regex := sv.RemoteVersion.Extraction.Regex
+	for i := range sv.Versions {
+		v := &sv.Versions[i]
+		if !pinned(*v) {
+			continue
+		}
+		tags, err := cp.provider.OCI.ResolveDigest(v.Image, v.Digest)
+		if err != nil {
+			cp.log.Error(err, "failed to resolve the image digest", "image", v.Image, "digest", v.Digest)
+			continue
+		}
+		v.Tags = tags
+		for _, tag := range tags {
+			version := utils.GetResultsFromRegex(regex.Pattern, regex.Result, tag)
+			if version == "" {
+				continue
+			}
+			for j, running := range sv.RunningVersions {
+				if running == v.RunningVersion {
+					sv.RunningVersions[j] = version
+				}
+			}
+			v.RunningVersion = version
+			break
+		}
+	}
+	if current, found := cp.GetSubjectVersionCache(agentID, sv.ID); found && !reflect.DeepEqual(current, reported) {
+		return
+	}
+	cp.SetSubjectVersionCache(agentID, sv.ID, sv)
+}
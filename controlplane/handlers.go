@@ -1,11 +1,20 @@
 package controlplane
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers"
 )
 
 // Metrics handler
@@ -19,28 +28,80 @@ func PrometheusHandler() gin.HandlerFunc {
 
 // API handlers
 
-// AgentsPost handles POST requests to /agents
+// AgentsPost handles POST requests to /agents. The body is the payload of a report or a JSON array of payloads
+// to report a batch of subjects at once
 func (cp *ControlPlane) AgentsPost() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ap api.AgentPayload
-		if err := c.ShouldBindJSON(&ap); err != nil {
+		payloads, err := bindAgentPayloads(c)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusAccepted, gin.H{"message": "data received"})
-		cp.log.V(1).Info(
-			"received agent payload",
-			"agent_id", ap.AgentID,
-			"version_id", ap.Version.ID,
-		)
+		for _, ap := range payloads {
+			cp.log.V(1).Info(
+				"received agent payload",
+				"agent_id", ap.AgentID,
+				"version_id", ap.Version.ID,
+			)
+		}
 		go func() {
-			cp.UpdateAgentListCache(ap.AgentID, ap.AgentTags)
-			cp.UpdateAgentSubjectVersionsList(ap.AgentID, ap.Version.ID)
-			cp.SetSubjectVersionCache(ap.AgentID, ap.Version.ID, ap.Version)
+			for _, ap := range payloads {
+				cp.processAgentPayload(ap)
+			}
 		}()
 	}
 }
 
+// bindAgentPayloads binds and validates the payload of a report, or the payloads of a batch of reports
+func bindAgentPayloads(c *gin.Context) ([]api.AgentPayload, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	var payloads []api.AgentPayload
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &payloads); err != nil {
+			return nil, err
+		}
+	} else {
+		var ap api.AgentPayload
+		if err := json.Unmarshal(body, &ap); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, ap)
+	}
+	for i := range payloads {
+		if err := binding.Validator.ValidateStruct(&payloads[i]); err != nil {
+			return nil, err
+		}
+	}
+	return payloads, nil
+}
+
+// processAgentPayload stores the subject version reported by an agent
+func (cp *ControlPlane) processAgentPayload(ap api.AgentPayload) {
+	cp.UpdateAgentListCache(ap.AgentID, ap.AgentTags)
+	cp.UpdateAgentSubjectVersionsList(ap.AgentID, ap.Version.ID)
+	cp.SetSubjectVersionCache(ap.AgentID, ap.Version.ID, ap.Version)
+	// resolving the digests can take many registry requests
+	if cp.hasPinnedDigests(ap.Version) {
+		go cp.resolveDigests(ap.AgentID, ap.Version)
+	}
+}
+
+// AgentRefreshPost handles POST requests to /agents/:id/refresh
+func (cp *ControlPlane) AgentRefreshPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		agentID := c.Param("id")
+		if err := cp.RequestRefresh(agentID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "refresh requested"})
+	}
+}
+
 // AgentsGet handles GET requests to /agents
 func (cp *ControlPlane) AgentsGet() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -96,3 +157,70 @@ func (cp *ControlPlane) OverviewGet() gin.HandlerFunc {
 		c.JSON(http.StatusOK, oveview)
 	}
 }
+
+// githubWebhookPayload contains the fields of the release, push and create events used to refresh the remote versions
+type githubWebhookPayload struct {
+	Ref        string `json:"ref"`
+	RefType    string `json:"ref_type"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// validGithubSignature checks the X-Hub-Signature-256 header against the HMAC of the body
+func validGithubSignature(secret string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// maximum size of a Github webhook payload, Github caps the payloads it delivers at 25 MB
+const maxGithubWebhookSize = 25 << 20
+
+// GithubWebhookPost handles POST requests to /webhooks/github. Release events and tag pushes
+// refresh the remote versions of the subjects tracking the repository
+func (cp *ControlPlane) GithubWebhookPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the body is read before its signature is checked, so its size is capped
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGithubWebhookSize)
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validGithubSignature(cp.githubWebhookSecret, body, c.GetHeader("X-Hub-Signature-256")) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		var payload githubWebhookPayload
+		event := c.GetHeader("X-GitHub-Event")
+		if event != "ping" {
+			if err := json.Unmarshal(body, &payload); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		switch {
+		case event == "release":
+		case event == "push" && strings.HasPrefix(payload.Ref, "refs/tags/"):
+		case event == "create" && payload.RefType == "tag":
+		default:
+			c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "data received"})
+		cp.log.V(1).Info(
+			"received github webhook",
+			"event", event,
+			"repo", payload.Repository.FullName,
+		)
+		cp.RequestRemoteRefresh(providers.Github.String(), payload.Repository.FullName)
+	}
+}
@@ -0,0 +1,96 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+
+	inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// LeaderElectionConfig is the config of the election of the replica running the background loops, i.e. the cache
+// reconciler refreshing the remote versions. The replicas elect their leader with a Kubernetes Lease
+type LeaderElectionConfig struct {
+	// Namespace of the Lease. Defaults to the namespace of the control plane
+	Namespace string
+	// Name of the Lease, shared by the replicas
+	ID string
+}
+
+func newLeaderElector(conf *LeaderElectionConfig, log logr.Logger) (*leaderelection.LeaderElector, error) {
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kubernetes config of the leader election: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	namespace := conf.Namespace
+	if namespace == "" {
+		data, err := ioutil.ReadFile(inClusterNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("the leader election namespace is required when not running in a cluster: %v", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	identity := hostname + "_" + string(uuid.NewUUID())
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      conf.ID,
+		},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	log = log.WithName("leader-election").WithValues("namespace", namespace, "lease", conf.ID, "identity", identity)
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		Name:          conf.ID,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Info("started leading")
+			},
+			OnStoppedLeading: func() {
+				log.Info("stopped leading")
+			},
+		},
+	})
+}
+
+// runLeaderElection campaigns to lead the replicas until the control plane stops. The elector returns when the
+// leadership is lost, in which case the replica campaigns again
+func (cp *ControlPlane) runLeaderElection() {
+	for {
+		cp.elector.Run(context.Background())
+	}
+}
+
+// isLeader returns true if the replica runs the background loops, i.e. it is the leader or there is no election
+func (cp *ControlPlane) isLeader() bool {
+	return cp.elector == nil || cp.elector.IsLeader()
+}
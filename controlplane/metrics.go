@@ -25,6 +25,7 @@ var (
 	availableMajorVersionMetric = newMetric("major_versions_count", "Number of available major versions to upgrade to", commonLabels, []string{"available_major_versions"})
 	availableMinorVersionMetric = newMetric("minor_versions_count", "Number of available minor versions to upgrade to", commonLabels, []string{"available_minor_versions"})
 	availablePatchVersionMetric = newMetric("patch_versions_count", "Number of available patch versions to upgrade to", commonLabels, []string{"available_patch_versions"})
+	daysBehindMetric            = newMetric("days_behind", "Number of days between the publish dates of the running and the latest versions", commonLabels, []string{"latest_version"})
 
 	agentMetric = newMetric("agent_last_heartbeat", "Last time the agent was seen", []string{}, []string{"agent_id", "tags"})
 )
@@ -34,6 +35,7 @@ func (cp *ControlPlane) Describe(ch chan<- *prometheus.Desc) {
 	ch <- availableMajorVersionMetric
 	ch <- availableMinorVersionMetric
 	ch <- availablePatchVersionMetric
+	ch <- daysBehindMetric
 }
 
 func (cp *ControlPlane) Collect(ch chan<- prometheus.Metric) {
@@ -110,6 +112,21 @@ func (cp *ControlPlane) setVersionMetrics(ch chan<- prometheus.Metric) {
 						versionInfos.RemoteRepo,
 						strings.Join(v.AvailablePatches, ","),
 					)
+					// Only set when the remote provider exposes the publish dates
+					if v.DaysBehind != nil {
+						ch <- prometheus.MustNewConstMetric(
+							daysBehindMetric,
+							prometheus.GaugeValue,
+							float64(*v.DaysBehind),
+							versionInfos.ID,
+							versionInfos.AgentID,
+							v.RunningVersion,
+							v.ResourceKind,
+							versionInfos.RemoteProvider,
+							versionInfos.RemoteRepo,
+							v.LatestVersion,
+						)
+					}
 				}
 			}
 		}
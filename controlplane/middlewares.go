@@ -1,6 +1,7 @@
 package controlplane
 
 import (
+	"compress/gzip"
 	"fmt"
 	"net"
 	"net/http"
@@ -41,6 +42,85 @@ func (cp *ControlPlane) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// ClientCertMiddleware requires a client certificate verified against the client CA. The other routes
+// (e.g. metrics and webhooks) accept connections without client certificates
+func (cp *ControlPlane) ClientCertMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "missing client certificate",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// maximum size of a decompressed request body
+const maxDecompressedBodySize = 64 << 20
+
+// DecompressMiddleware decompresses the gzip encoded request bodies (e.g. the large reports of the agents)
+func DecompressMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Content-Encoding") != "gzip" {
+			c.Next()
+			return
+		}
+		r, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "invalid gzip body",
+			})
+			return
+		}
+		defer r.Close()
+		c.Request.Body = http.MaxBytesReader(c.Writer, r, maxDecompressedBodySize)
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
+
+// gzipWriter compresses the body of a response
+type gzipWriter struct {
+	gin.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.writer == nil {
+		// the headers are sent with the first write
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.writer = gzip.NewWriter(w.ResponseWriter)
+	}
+	return w.writer.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// CompressMiddleware compresses the responses of the clients accepting the gzip encoding. The websocket upgrades
+// are not compressed
+func CompressMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.Request.Header.Get("Accept-Encoding"), "gzip") || c.Request.Header.Get("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		w := &gzipWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			if w.writer != nil {
+				w.writer.Close()
+			}
+		}()
+		c.Next()
+	}
+}
+
 func HeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,187 @@
+package acr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers/oci"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	imdsTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// the instance metadata service is local to the host and answers quickly
+	imdsTimeout = 5 * time.Second
+	// ACR expects this username when authenticating with a refresh token
+	refreshTokenUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+// Config contains configuration for ACR provider
+type Config struct {
+	// Service principal application (client) ID and secret.
+	// When using managed identity, ClientID selects a user assigned identity
+	ClientID     string
+	ClientSecret string
+	// Use the managed identity of the host (e.g. AKS workload/pod identity) to authenticate
+	ManagedIdentity bool
+}
+
+// Provider is an Azure Container Registry provider for getting remote versions from image tags
+type Provider struct {
+	httpClient      *http.Client
+	imdsClient      *http.Client
+	clientID        string
+	clientSecret    string
+	managedIdentity bool
+	cache           *cache.Cache
+	log             logr.Logger
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	if !c.ManagedIdentity && (c.ClientID == "" || c.ClientSecret == "") {
+		logger.V(1).Info("no authentication provided. only registries with anonymous pull will be accessible.")
+	}
+	return &Provider{
+		httpClient:      &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		imdsClient:      newIMDSClient(tr),
+		clientID:        c.ClientID,
+		clientSecret:    c.ClientSecret,
+		managedIdentity: c.ManagedIdentity,
+		cache:           cache,
+		log:             logger,
+	}
+}
+
+// newIMDSClient returns the client of the instance metadata service. It is never reached through a proxy, the
+// proxy of the providers ignores NO_PROXY
+func newIMDSClient(tr *http.Transport) *http.Client {
+	imds := tr.Clone()
+	imds.Proxy = nil
+	return &http.Client{Timeout: imdsTimeout, Transport: imds}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("acr/%s/tags", repo)
+}
+
+// registryClient returns a registry client with the configured credentials
+func (p *Provider) registryClient(registry string) (*oci.Client, error) {
+	client := &oci.Client{HTTPClient: p.httpClient, Registries: []string{registry}}
+	if p.managedIdentity {
+		refreshToken, err := p.exchangeManagedIdentityToken(registry)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: using managed identity: %v", err)
+		}
+		client.Username = refreshTokenUsername
+		client.Password = refreshToken
+	} else if p.clientID != "" && p.clientSecret != "" {
+		client.Username = p.clientID
+		client.Password = p.clientSecret
+	}
+	return client, nil
+}
+
+// exchangeManagedIdentityToken gets an AAD token for the managed identity from the
+// instance metadata service and exchanges it with an ACR refresh token
+func (p *Provider) exchangeManagedIdentityToken(registry string) (string, error) {
+	q := url.Values{}
+	q.Set("api-version", "2018-02-01")
+	q.Set("resource", "https://management.azure.com/")
+	if p.clientID != "" {
+		q.Set("client_id", p.clientID)
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", imdsTokenURL, q.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	aadToken, err := p.token(p.imdsClient, req)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "access_token")
+	form.Set("service", registry)
+	form.Set("access_token", aadToken.AccessToken)
+	req, err = http.NewRequest("POST", fmt.Sprintf("https://%s/oauth2/exchange", registry), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	acrToken, err := p.token(p.httpClient, req)
+	if err != nil {
+		return "", err
+	}
+	return acrToken.RefreshToken, nil
+}
+
+func (p *Provider) token(client *http.Client, req *http.Request) (*tokenResponse, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var t tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || !strings.HasSuffix(parts[0], ".azurecr.io") {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: <registry>.azurecr.io/<name>", repo)
+	}
+	client, err := p.registryClient(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	tags, err := client.ListTags(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.ACRStrategyTags {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, tags)
+}
@@ -0,0 +1,158 @@
+package apt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultArchitecture = "amd64"
+
+// Config contains configuration for APT provider
+type Config struct {
+	// Architecture of the packages index to use
+	Architecture string
+	// Base URL of the private repository (e.g. https://apt.example.com)
+	RepositoryURL string
+	// Basic authentication credentials for the private repository. They are only sent to the host of the repository URL
+	Username string
+	Password string
+}
+
+// Provider is an APT provider for getting remote versions from the Packages index of Debian repositories
+type Provider struct {
+	client        *http.Client
+	architecture  string
+	repositoryURL string
+	username      string
+	password      string
+	cache         *cache.Cache
+	log           logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	architecture := c.Architecture
+	if architecture == "" {
+		architecture = DefaultArchitecture
+	}
+	return &Provider{
+		client:        &http.Client{Timeout: 60 * time.Second, Transport: tr},
+		architecture:  architecture,
+		repositoryURL: c.RepositoryURL,
+		username:      c.Username,
+		password:      c.Password,
+		cache:         cache,
+		log:           logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("apt/%s/versions", repo)
+}
+
+// parseRepo parses the repo in the format of a sources.list entry followed by the package name
+// e.g "http://archive.ubuntu.com/ubuntu focal main nginx"
+func parseRepo(repo string) (repositoryURL, distribution, component, pkg string, err error) {
+	fields := strings.Fields(repo)
+	if len(fields) != 4 {
+		return "", "", "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: <url> <distribution> <component> <package>", repo)
+	}
+	return strings.TrimSuffix(fields[0], "/"), fields[1], fields[2], fields[3], nil
+}
+
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	repositoryURL, distribution, component, pkg, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages.gz", repositoryURL, distribution, component, p.architecture), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.username != "" && p.password != "" && utils.SameHost(p.repositoryURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	versions, err := packageVersions(gz, pkg)
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+// packageVersions returns the versions of the package listed in the Packages index
+func packageVersions(r io.Reader, pkg string) ([]string, error) {
+	var versions []string
+	var name, version string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// end of the paragraph of a package
+			if name == pkg && version != "" {
+				versions = append(versions, version)
+			}
+			name, version = "", ""
+		case strings.HasPrefix(line, "Package:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "Package:"))
+		case strings.HasPrefix(line, "Version:"):
+			version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if name == pkg && version != "" {
+		versions = append(versions, version)
+	}
+	return utils.RemoveDuplicateStr(versions), nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.APTStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
@@ -0,0 +1,99 @@
+package artifacthub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const apiURL = "https://artifacthub.io/api/v1"
+
+// Provider is an ArtifactHub provider for getting remote versions of the packages listed in ArtifactHub
+type Provider struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+type Package struct {
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	AvailableVersions []struct {
+		Version string `json:"version"`
+	} `json:"available_versions"`
+}
+
+func NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		cache:  cache,
+		log:    logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func packageCacheKey(repo string) string {
+	return fmt.Sprintf("artifacthub/%s/package", repo)
+}
+
+// getPackage gets the package from ArtifactHub. repo is in the format of <kind>/<repository>/<package>
+// e.g helm/bitnami/nginx or olm/community-operators/prometheus
+func (p *Provider) getPackage(repo string) (*Package, error) {
+	log := p.log.WithValues("repo", repo)
+	if pkg, ok := p.getCacheValue(packageCacheKey(repo)); ok {
+		log.V(1).Info("found package in cache")
+		return pkg.(*Package), nil
+	}
+	log.V(1).Info("getting package")
+	if len(strings.Split(repo, "/")) != 3 {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: kind/repository/package", repo)
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/packages/%s", apiURL, repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	pkg := &Package{}
+	if err := json.NewDecoder(resp.Body).Decode(pkg); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(packageCacheKey(repo), pkg)
+	return pkg, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.ArtifactHubStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	pkg, err := p.getPackage(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range pkg.AvailableVersions {
+		versions = append(versions, v.Version)
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
@@ -0,0 +1,210 @@
+package artifactory
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Config contains configuration for Artifactory provider
+type Config struct {
+	// Base URL of the Artifactory instance (e.g. https://example.jfrog.io/artifactory)
+	BaseURL string
+	// API key or access token. It is only sent to the host of the base URL
+	APIKey string
+}
+
+// Provider is an Artifactory provider for getting remote versions from the artifacts of a repository path
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+type FolderInfo struct {
+	Children []struct {
+		URI    string `json:"uri"`
+		Folder bool   `json:"folder"`
+	} `json:"children"`
+}
+
+type Item struct {
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	if c.BaseURL == "" {
+		logger.V(1).Info("no base url provided. repositories must be referenced by their full url.")
+	}
+	return &Provider{
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL: strings.TrimSuffix(c.BaseURL, "/"),
+		apiKey:  c.APIKey,
+		cache:   cache,
+		log:     logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("artifactory/%s/versions", repo)
+}
+
+func artifactsCacheKey(repo string) string {
+	return fmt.Sprintf("artifactory/%s/artifacts", repo)
+}
+
+// parseRepo splits the repo into the base URL, the repository key and the path. repo is in the format of
+// repository/path (e.g. libs-release-local/com/example/app) optionally prefixed by the Artifactory URL
+func (p *Provider) parseRepo(repo string) (baseURL, key, itemPath string, err error) {
+	baseURL = p.baseURL
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		i := strings.Index(repo, "/artifactory/")
+		if i < 0 {
+			return "", "", "", fmt.Errorf("invalid repo: %s. url must contain the /artifactory/ context path", repo)
+		}
+		baseURL, repo = repo[:i+len("/artifactory")], repo[i+len("/artifactory/"):]
+	}
+	if baseURL == "" {
+		return "", "", "", fmt.Errorf("base url is required to access %s", repo)
+	}
+	parts := strings.SplitN(strings.Trim(repo, "/"), "/", 2)
+	if parts[0] == "" {
+		return "", "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: repository/path", repo)
+	}
+	itemPath = "."
+	if len(parts) == 2 {
+		itemPath = parts[1]
+	}
+	return baseURL, parts[0], itemPath, nil
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	if p.apiKey != "" && utils.SameHost(p.baseURL, req.URL) {
+		req.Header.Set("X-JFrog-Art-Api", p.apiKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getVersions gets the names of the children of the path using the storage API
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	baseURL, key, itemPath, err := p.parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/storage/%s", baseURL, path.Join(key, itemPath)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var info FolderInfo
+	if err := p.do(req, &info); err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, c := range info.Children {
+		versions = append(versions, strings.TrimPrefix(c.URI, "/"))
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+// getArtifacts gets the names of all the files under the path using AQL
+func (p *Provider) getArtifacts(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if a, ok := p.getCacheValue(artifactsCacheKey(repo)); ok {
+		log.V(1).Info("found artifacts in cache")
+		return a.([]string), nil
+	}
+	log.V(1).Info("getting artifacts")
+	baseURL, key, itemPath, err := p.parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	find := map[string]interface{}{
+		"repo": key,
+		"type": "file",
+		"$or": []map[string]interface{}{
+			{"path": itemPath},
+			{"path": map[string]string{"$match": path.Join(itemPath, "*")}},
+		},
+	}
+	if itemPath == "." {
+		delete(find, "$or")
+	}
+	b, err := json.Marshal(find)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`items.find(%s).include("repo","path","name")`, b)
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/search/aql", baseURL), strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	var result struct {
+		Results []Item `json:"results"`
+	}
+	if err := p.do(req, &result); err != nil {
+		return nil, err
+	}
+	var artifacts []string
+	for _, item := range result.Results {
+		artifacts = append(artifacts, item.Name)
+	}
+	p.setCacheValue(artifactsCacheKey(repo), artifacts)
+	return artifacts, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	var candidates []string
+	var err error
+	switch conf.Strategy {
+	case v1alpha1.ArtifactoryStrategyVersions:
+		candidates, err = p.getVersions(conf.Repo)
+	case v1alpha1.ArtifactoryStrategyArtifacts:
+		candidates, err = p.getArtifacts(conf.Repo)
+	default:
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions, err := utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return utils.RemoveDuplicateStr(versions), nil
+}
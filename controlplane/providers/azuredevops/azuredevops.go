@@ -0,0 +1,232 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	DefaultBaseURL = "https://dev.azure.com"
+	// release management API of Azure DevOps Services is served from a different host
+	releasesBaseURL = "https://vsrm.dev.azure.com"
+	apiVersion      = "6.0"
+)
+
+// Config contains configuration for Azure DevOps provider
+type Config struct {
+	// Base URL of the Azure DevOps organization host (e.g. https://devops.example.com/tfs for Azure DevOps Server)
+	BaseURL string
+	// Personal access token
+	Token string
+}
+
+// Provider is an Azure DevOps provider for getting remote versions from Azure Repos tags and release pipelines
+type Provider struct {
+	client          *http.Client
+	baseURL         string
+	releasesBaseURL string
+	token           string
+	cache           *cache.Cache
+	log             logr.Logger
+}
+
+type Ref struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+}
+
+type Release struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ReleaseDefinition struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	baseURL := strings.TrimSuffix(c.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	relURL := baseURL
+	if baseURL == DefaultBaseURL {
+		relURL = releasesBaseURL
+	}
+	if c.Token == "" {
+		logger.V(1).Info("no authentication provided. only public projects will be accessible.")
+	}
+	return &Provider{
+		client:          &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL:         baseURL,
+		releasesBaseURL: relURL,
+		token:           c.Token,
+		cache:           cache,
+		log:             logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("azuredevops/%s/tags", repo)
+}
+
+func releasesCacheKey(repo string) string {
+	return fmt.Sprintf("azuredevops/%s/releases", repo)
+}
+
+// parseRepo splits the repo in the format of organization/project/name
+func parseRepo(repo string) (org, project, name string, err error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: organization/project/name", repo)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// list gets all the pages of a list API and decodes the values into items
+func (p *Provider) list(u string, query url.Values, items func(json.RawMessage) error) error {
+	query.Set("api-version", apiVersion)
+	continuationToken := ""
+	for {
+		if continuationToken != "" {
+			query.Set("continuationToken", continuationToken)
+		}
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", u, query.Encode()), nil)
+		if err != nil {
+			return err
+		}
+		if p.token != "" {
+			req.SetBasicAuth("", p.token)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var page struct {
+			Value json.RawMessage `json:"value"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if err := items(page.Value); err != nil {
+			return err
+		}
+		continuationToken = resp.Header.Get("X-MS-ContinuationToken")
+		if continuationToken == "" {
+			return nil
+		}
+	}
+}
+
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	org, project, name, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/refs", p.baseURL, org, project, name)
+	var tags []string
+	err = p.list(u, url.Values{"filter": {"tags/"}}, func(raw json.RawMessage) error {
+		var refs []Ref
+		if err := json.Unmarshal(raw, &refs); err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			tags = append(tags, strings.TrimPrefix(ref.Name, "refs/tags/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+// getReleases gets the release names of a release pipeline. repo is in the format of organization/project/definition
+func (p *Provider) getReleases(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if r, ok := p.getCacheValue(releasesCacheKey(repo)); ok {
+		log.V(1).Info("found releases in cache")
+		return r.([]string), nil
+	}
+	log.V(1).Info("getting releases")
+	org, project, name, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []ReleaseDefinition
+	u := fmt.Sprintf("%s/%s/%s/_apis/release/definitions", p.releasesBaseURL, org, project)
+	err = p.list(u, url.Values{"searchText": {name}, "isExactNameMatch": {"true"}}, func(raw json.RawMessage) error {
+		return json.Unmarshal(raw, &definitions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("release definition %s not found", name)
+	}
+	var releases []string
+	u = fmt.Sprintf("%s/%s/%s/_apis/release/releases", p.releasesBaseURL, org, project)
+	err = p.list(u, url.Values{"definitionId": {fmt.Sprint(definitions[0].ID)}}, func(raw json.RawMessage) error {
+		var page []Release
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			releases = append(releases, r.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(releasesCacheKey(repo), releases)
+	return releases, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	var candidates []string
+	var err error
+	switch conf.Strategy {
+	case v1alpha1.AzureDevOpsStrategyTags:
+		candidates, err = p.getTags(conf.Repo)
+	case v1alpha1.AzureDevOpsStrategyReleases:
+		candidates, err = p.getReleases(conf.Repo)
+	default:
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+}
@@ -0,0 +1,156 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const apiURL = "https://api.bitbucket.org/2.0"
+
+// Config contains configuration for Bitbucket provider
+type Config struct {
+	// Username and app password for basic authentication
+	Username    string
+	AppPassword string
+	// OAuth access token. takes precedence over the app password
+	Token string
+}
+
+// Provider is a bitbucket provider for getting remote versions from Bitbucket Cloud
+type Provider struct {
+	client      *http.Client
+	username    string
+	appPassword string
+	token       string
+	cache       *cache.Cache
+	log         logr.Logger
+}
+
+// page is a paginated response of the Bitbucket 2.0 API
+type page struct {
+	Values []struct {
+		Name string `json:"name"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	if c.Token == "" && (c.Username == "" || c.AppPassword == "") {
+		logger.V(1).Info("no authentication provided. private repositories will not be accessible.")
+	}
+	return &Provider{
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		username:    c.Username,
+		appPassword: c.AppPassword,
+		token:       c.Token,
+		cache:       cache,
+		log:         logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("bitbucket/%s/tags", repo)
+}
+
+func downloadsCacheKey(repo string) string {
+	return fmt.Sprintf("bitbucket/%s/downloads", repo)
+}
+
+func (p *Provider) setAuth(req *http.Request) {
+	if p.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+	} else if p.username != "" && p.appPassword != "" {
+		req.SetBasicAuth(p.username, p.appPassword)
+	}
+}
+
+// listNames follows the pagination of a repository resource and returns the name of all the values
+func (p *Provider) listNames(repo, resource string) ([]string, error) {
+	workspace, slug, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	next := fmt.Sprintf("%s/repositories/%s/%s/%s?pagelen=100", apiURL, workspace, slug, resource)
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.setAuth(req)
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var pg page
+		err = json.NewDecoder(resp.Body).Decode(&pg)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range pg.Values {
+			names = append(names, v.Name)
+		}
+		next = pg.Next
+	}
+	return names, nil
+}
+
+func (p *Provider) getNames(repo, resource, cacheKey string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if n, ok := p.getCacheValue(cacheKey); ok {
+		log.V(1).Info("found names in cache", "resource", resource)
+		return n.([]string), nil
+	}
+	log.V(1).Info("getting names", "resource", resource)
+	names, err := p.listNames(repo, resource)
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(cacheKey, names)
+	return names, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	var names []string
+	var err error
+	if conf.Strategy == v1alpha1.BitbucketStrategyTags {
+		names, err = p.getNames(conf.Repo, "refs/tags", tagsCacheKey(conf.Repo))
+	} else if conf.Strategy == v1alpha1.BitbucketStrategyDownloads {
+		names, err = p.getNames(conf.Repo, "downloads", downloadsCacheKey(conf.Repo))
+	} else {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, names)
+}
+
+func splitRepo(repo string) (workspace string, slug string, err error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: workspace/slug", repo)
+	}
+	return parts[0], parts[1], nil
+}
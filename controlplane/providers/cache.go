@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// cacheTTL returns the TTL of the remote version, or 0 for the default expiration. The TTL of the github options is
+// deprecated but still honoured
+func cacheTTL(conf v1alpha1.RemoteVersion) time.Duration {
+	if conf.CacheTTL != nil && conf.CacheTTL.Duration > 0 {
+		return conf.CacheTTL.Duration
+	}
+	if conf.Provider == Github.String() && conf.Github.CacheTTL != nil && conf.Github.CacheTTL.Duration > 0 {
+		return conf.Github.CacheTTL.Duration
+	}
+	return 0
+}
+
+// repoCachePrefix is the prefix of the cache keys of the repo. Every provider keeps the data of a repo under it so
+// the data is dropped with the repo
+func repoCachePrefix(provider, repo string) string {
+	return fmt.Sprintf("%s/%s/", provider, repo)
+}
+
+// resultsCacheKey is the key of the published versions of the remote version. The whole config is in the key so
+// the remote versions of the same repo with different strategies, limits or TTLs are cached separately
+func resultsCacheKey(conf v1alpha1.RemoteVersion) (string, error) {
+	b, err := json.Marshal(conf)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("results/%s%s", repoCachePrefix(conf.Provider, conf.Repo), b), nil
+}
+
+// cachedPublishedVersions returns the published versions of the remote version cached for its TTL. When they
+// expire, the data of the repo cached by the provider is dropped so it is requested again even if the default
+// expiration is longer than the TTL
+func (p *Provider) cachedPublishedVersions(conf v1alpha1.RemoteVersion, get func() ([]utils.PublishedVersion, error)) ([]utils.PublishedVersion, error) {
+	ttl := cacheTTL(conf)
+	if ttl == 0 {
+		return get()
+	}
+	key, err := resultsCacheKey(conf)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := p.cache.Get(key); ok {
+		return v.([]utils.PublishedVersion), nil
+	}
+	p.invalidateRepo(conf.Provider, conf.Repo, false)
+	published, err := get()
+	if err != nil {
+		return nil, err
+	}
+	p.cache.Set(key, published, ttl)
+	return published, nil
+}
+
+// InvalidateCache drops the remote versions of the repo and the data of the repo cached by the provider
+func (p *Provider) InvalidateCache(provider, repo string) {
+	p.log.V(1).Info("invalidating cache", "provider", provider, "repo", repo)
+	p.invalidateRepo(provider, repo, true)
+	if provider == Github.String() {
+		p.Github.InvalidateCache(repo)
+	}
+}
+
+// invalidateRepo deletes the keys of the repo, and its results when results is true
+func (p *Provider) invalidateRepo(provider, repo string, results bool) {
+	prefix := repoCachePrefix(provider, repo)
+	for key := range p.cache.Items() {
+		if strings.HasPrefix(key, prefix) || (results && strings.HasPrefix(key, "results/"+prefix)) {
+			p.cache.Delete(key)
+		}
+	}
+}
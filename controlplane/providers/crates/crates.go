@@ -0,0 +1,204 @@
+package crates
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	DefaultAPIURL   = "https://crates.io"
+	DefaultIndexURL = "https://index.crates.io"
+	// crates.io requires a user agent that identifies the client
+	userAgent = "opvic (https://github.com/skillz/opvic)"
+)
+
+// Config contains configuration for crates provider
+type Config struct {
+	// Base URL of the crates.io API
+	APIURL string
+	// URL of the sparse index (e.g. https://index.crates.io or the index of an alternate registry)
+	IndexURL string
+	// Auth token for alternate registries that require authentication
+	Token string
+}
+
+// Provider is a crates provider for getting remote versions from Rust crate releases
+type Provider struct {
+	client   *http.Client
+	apiURL   string
+	indexURL string
+	token    string
+	cache    *cache.Cache
+	log      logr.Logger
+}
+
+type Version struct {
+	Num    string `json:"num"`
+	Yanked bool   `json:"yanked"`
+}
+
+// IndexEntry is a line of a crate file in the sparse index
+type IndexEntry struct {
+	Vers   string `json:"vers"`
+	Yanked bool   `json:"yanked"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	indexURL := c.IndexURL
+	if indexURL == "" {
+		indexURL = DefaultIndexURL
+	}
+	return &Provider{
+		client:   &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		apiURL:   strings.TrimSuffix(apiURL, "/"),
+		indexURL: strings.TrimSuffix(indexURL, "/"),
+		token:    c.Token,
+		cache:    cache,
+		log:      logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("crates/%s/versions", repo)
+}
+
+func indexCacheKey(repo string) string {
+	return fmt.Sprintf("crates/%s/index", repo)
+}
+
+func (p *Provider) get(u string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if p.token != "" {
+		req.Header.Set("Authorization", p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return resp, nil
+}
+
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	resp, err := p.get(fmt.Sprintf("%s/api/v1/crates/%s/versions", p.apiURL, repo))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Versions []Version `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range result.Versions {
+		if !v.Yanked {
+			versions = append(versions, v.Num)
+		}
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+// indexPath returns the path of the crate file in the index
+// https://doc.rust-lang.org/cargo/reference/registry-index.html#index-files
+func indexPath(name string) string {
+	name = strings.ToLower(name)
+	switch len(name) {
+	case 1:
+		return fmt.Sprintf("1/%s", name)
+	case 2:
+		return fmt.Sprintf("2/%s", name)
+	case 3:
+		return fmt.Sprintf("3/%s/%s", name[:1], name)
+	default:
+		return fmt.Sprintf("%s/%s/%s", name[:2], name[2:4], name)
+	}
+}
+
+func (p *Provider) getIndexVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(indexCacheKey(repo)); ok {
+		log.V(1).Info("found index versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting index versions")
+	resp, err := p.get(fmt.Sprintf("%s/%s", p.indexURL, indexPath(repo)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var versions []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry IndexEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, err
+		}
+		if !entry.Yanked {
+			versions = append(versions, entry.Vers)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(indexCacheKey(repo), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	var versions []string
+	var err error
+	switch conf.Strategy {
+	case v1alpha1.CratesStrategyVersions:
+		versions, err = p.getVersions(conf.Repo)
+	case v1alpha1.CratesStrategyIndex:
+		versions, err = p.getIndexVersions(conf.Repo)
+	default:
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
@@ -0,0 +1,150 @@
+package ecr
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// e.g 123456789012.dkr.ecr.us-east-1.amazonaws.com
+var registryHostRegex = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// Config contains configuration for ECR provider
+type Config struct {
+	// Region to use for repositories that are not referenced by their registry host.
+	// Defaults to the region of the AWS environment (e.g. AWS_REGION)
+	Region string
+}
+
+// Provider is an ECR provider for getting remote versions from image tags in ECR repositories.
+// Credentials are resolved by the default AWS credential chain (environment, IRSA, instance profile, etc.)
+type Provider struct {
+	session *session.Session
+	region  string
+	clients map[string]*ecr.ECR
+	mutex   sync.Mutex
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{HTTPClient: &http.Client{Transport: tr}},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %v", err)
+	}
+	region := c.Region
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+	if region == "" {
+		logger.V(1).Info("no default region provided. repositories must be referenced by their registry host.")
+	}
+	return &Provider{
+		session: sess,
+		region:  region,
+		clients: map[string]*ecr.ECR{},
+		cache:   cache,
+		log:     logger,
+	}, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("ecr/%s/tags", repo)
+}
+
+// client returns the ECR client of the region
+func (p *Provider) client(region string) *ecr.ECR {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if c, ok := p.clients[region]; ok {
+		return c
+	}
+	c := ecr.New(p.session, aws.NewConfig().WithRegion(region))
+	p.clients[region] = c
+	return c
+}
+
+// parseRepo splits the repo into registry ID, region and repository name.
+// repo is either the full image repository (e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com/app)
+// or only the repository name in the default registry of the default region (e.g. app)
+func (p *Provider) parseRepo(repo string) (registryID, region, name string, err error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) == 2 {
+		if m := registryHostRegex.FindStringSubmatch(parts[0]); m != nil {
+			return m[1], m[2], parts[1], nil
+		}
+	}
+	if p.region == "" {
+		return "", "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: <account>.dkr.ecr.<region>.amazonaws.com/<name> when no default region is set", repo)
+	}
+	return "", p.region, repo, nil
+}
+
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	registryID, region, name, err := p.parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	input := &ecr.ListImagesInput{
+		RepositoryName: aws.String(name),
+		MaxResults:     aws.Int64(1000),
+		Filter: &ecr.ListImagesFilter{
+			TagStatus: aws.String(ecr.TagStatusTagged),
+		},
+	}
+	if registryID != "" {
+		input.RegistryId = aws.String(registryID)
+	}
+	var tags []string
+	err = p.client(region).ListImagesPages(input, func(page *ecr.ListImagesOutput, lastPage bool) bool {
+		for _, id := range page.ImageIds {
+			if id.ImageTag != nil {
+				tags = append(tags, *id.ImageTag)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.ECRStrategyTags {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, tags)
+}
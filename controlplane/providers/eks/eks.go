@@ -0,0 +1,137 @@
+package eks
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// kube-proxy add-on is published for every Kubernetes version supported by EKS, in standard or in extended support
+const referenceAddon = "kube-proxy"
+
+// Config contains configuration for EKS provider
+type Config struct {
+	// Region to use when the repo does not specify one.
+	// Defaults to the region of the AWS environment (e.g. AWS_REGION)
+	Region string
+}
+
+// Provider is an EKS provider for getting the Kubernetes versions supported by EKS. The versions in extended support
+// are not told apart from the ones in standard support and the platform versions are not reported: the API
+// exposing them (DescribeClusterVersions) is not available in the AWS SDK in use.
+// Credentials are resolved by the default AWS credential chain (environment, IRSA, instance profile, etc.)
+type Provider struct {
+	session *session.Session
+	region  string
+	clients map[string]*eks.EKS
+	mutex   sync.Mutex
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{HTTPClient: &http.Client{Transport: tr}},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %v", err)
+	}
+	region := c.Region
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+	if region == "" {
+		logger.V(1).Info("no default region provided. repo must be set to the region.")
+	}
+	return &Provider{
+		session: sess,
+		region:  region,
+		clients: map[string]*eks.EKS{},
+		cache:   cache,
+		log:     logger,
+	}, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+// versionsCacheKey is the key of the versions of the region, which is the repo of the remote version
+func versionsCacheKey(region string) string {
+	return fmt.Sprintf("eks/%s/versions", region)
+}
+
+// client returns the EKS client of the region
+func (p *Provider) client(region string) *eks.EKS {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if c, ok := p.clients[region]; ok {
+		return c
+	}
+	c := eks.New(p.session, aws.NewConfig().WithRegion(region))
+	p.clients[region] = c
+	return c
+}
+
+// getVersions gets the Kubernetes versions supported by EKS in the region from the
+// compatibilities of the kube-proxy add-on versions
+func (p *Provider) getVersions(region string) ([]string, error) {
+	log := p.log.WithValues("region", region)
+	if v, ok := p.getCacheValue(versionsCacheKey(region)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	input := &eks.DescribeAddonVersionsInput{
+		AddonName: aws.String(referenceAddon),
+	}
+	var versions []string
+	err := p.client(region).DescribeAddonVersionsPages(input, func(page *eks.DescribeAddonVersionsOutput, lastPage bool) bool {
+		for _, addon := range page.Addons {
+			for _, v := range addon.AddonVersions {
+				for _, c := range v.Compatibilities {
+					versions = append(versions, aws.StringValue(c.ClusterVersion))
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	versions = utils.RemoveDuplicateStr(versions)
+	p.setCacheValue(versionsCacheKey(region), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.EKSStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	// repo is the region to get the supported versions for
+	region := conf.Repo
+	if region == "" {
+		region = p.region
+	}
+	if region == "" {
+		return nil, fmt.Errorf("region is required for the eks provider")
+	}
+	versions, err := p.getVersions(region)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
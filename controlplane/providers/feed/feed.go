@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Provider is a feed provider for getting remote versions from the entries of RSS and Atom feeds
+type Provider struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+// Feed contains the entries of both RSS (channel/item) and Atom (entry) feeds
+type Feed struct {
+	Items   []Entry `xml:"channel>item"`
+	Entries []Entry `xml:"entry"`
+}
+
+type Entry struct {
+	Title string `xml:"title"`
+}
+
+func NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		cache:  cache,
+		log:    logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func entriesCacheKey(repo string) string {
+	return fmt.Sprintf("feed/%s/entries", repo)
+}
+
+// getEntries gets the titles of the feed entries. repo is the URL of the feed
+// e.g https://github.com/owner/repo/releases.atom
+func (p *Provider) getEntries(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if e, ok := p.getCacheValue(entriesCacheKey(repo)); ok {
+		log.V(1).Info("found entries in cache")
+		return e.([]string), nil
+	}
+	log.V(1).Info("getting entries")
+	req, err := http.NewRequest("GET", repo, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/atom+xml, application/rss+xml, application/xml;q=0.9, */*;q=0.8")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var feed Feed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, e := range append(feed.Items, feed.Entries...) {
+		entries = append(entries, strings.TrimSpace(e.Title))
+	}
+	p.setCacheValue(entriesCacheKey(repo), entries)
+	return entries, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.FeedStrategyEntries {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	entries, err := p.getEntries(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, entries)
+}
@@ -0,0 +1,89 @@
+package flathub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const apiURL = "https://flathub.org/api/v2"
+
+// Provider is a flathub provider for getting remote versions from the releases of flatpak applications
+type Provider struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+type Release struct {
+	Version   string `json:"version"`
+	Timestamp string `json:"timestamp"`
+}
+
+func NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		cache:  cache,
+		log:    logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func releasesCacheKey(repo string) string {
+	return fmt.Sprintf("flathub/%s/releases", repo)
+}
+
+// getReleases gets the releases from the appstream data of the application. repo is the application ID
+// e.g org.mozilla.firefox
+func (p *Provider) getReleases(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if r, ok := p.getCacheValue(releasesCacheKey(repo)); ok {
+		log.V(1).Info("found releases in cache")
+		return r.([]string), nil
+	}
+	log.V(1).Info("getting releases")
+	resp, err := p.client.Get(fmt.Sprintf("%s/appstream/%s", apiURL, repo))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var appstream struct {
+		Releases []Release `json:"releases"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&appstream); err != nil {
+		return nil, err
+	}
+	var releases []string
+	for _, r := range appstream.Releases {
+		releases = append(releases, r.Version)
+	}
+	p.setCacheValue(releasesCacheKey(repo), releases)
+	return releases, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.FlathubStrategyReleases {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	releases, err := p.getReleases(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, releases)
+}
@@ -0,0 +1,208 @@
+package gar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers/oci"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	apiURL = "https://artifactregistry.googleapis.com/v1"
+	scope  = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// e.g us-central1-docker.pkg.dev
+var registryHostRegex = regexp.MustCompile(`^([a-z0-9-]+)-([a-z]+)\.pkg\.dev$`)
+
+// Config contains configuration for Google Artifact Registry provider
+type Config struct {
+	// Path to a service account key file. If empty, the application default
+	// credentials are used (e.g. GKE workload identity or GOOGLE_APPLICATION_CREDENTIALS)
+	CredentialsFile string
+}
+
+// Provider is a Google Artifact Registry provider for getting remote versions
+// from docker image tags and package versions
+type Provider struct {
+	tokenSource oauth2.TokenSource
+	client      *http.Client
+	transport   *http.Transport
+	cache       *cache.Cache
+	log         logr.Logger
+}
+
+type versionList struct {
+	Versions []struct {
+		Name string `json:"name"`
+	} `json:"versions"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (c *Config) NewProvider(ctx context.Context, cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	// the tokens are also requested through the transport of the providers
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: 30 * time.Second, Transport: tr})
+	var creds *google.Credentials
+	if c.CredentialsFile != "" {
+		data, err := ioutil.ReadFile(c.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: reading credentials file %s: %v", c.CredentialsFile, err)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, scope)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: using credentials file %s: %v", c.CredentialsFile, err)
+		}
+	} else {
+		var err error
+		creds, err = google.FindDefaultCredentials(ctx, scope)
+		if err != nil {
+			logger.V(1).Info("no google credentials found. gar provider will not be able to authenticate", "error", err.Error())
+		}
+	}
+	p := &Provider{
+		client:    &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		transport: tr,
+		cache:     cache,
+		log:       logger,
+	}
+	if creds != nil {
+		p.tokenSource = creds.TokenSource
+		p.client = oauth2.NewClient(ctx, creds.TokenSource)
+		p.client.Timeout = 30 * time.Second
+	}
+	return p, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("gar/%s/tags", repo)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("gar/%s/versions", repo)
+}
+
+// parseRepo splits the repo (e.g. us-central1-docker.pkg.dev/project/repository/package) into its parts
+func parseRepo(repo string) (host, location, project, repository, pkg string, err error) {
+	parts := strings.SplitN(repo, "/", 4)
+	if len(parts) != 4 {
+		return "", "", "", "", "", fmt.Errorf("invalid repo: %s. it must be in the format of: <location>-<format>.pkg.dev/<project>/<repository>/<package>", repo)
+	}
+	m := registryHostRegex.FindStringSubmatch(parts[0])
+	if m == nil {
+		return "", "", "", "", "", fmt.Errorf("invalid artifact registry host: %s", parts[0])
+	}
+	return parts[0], m[1], parts[1], parts[2], parts[3], nil
+}
+
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	host, _, project, repository, pkg, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	client := &oci.Client{HTTPClient: &http.Client{Timeout: 30 * time.Second, Transport: p.transport}, Registries: []string{host}}
+	if p.tokenSource != nil {
+		token, err := p.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		client.Username = "oauth2accesstoken"
+		client.Password = token.AccessToken
+	}
+	tags, err := client.ListTags(host, fmt.Sprintf("%s/%s/%s", project, repository, pkg))
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) getPackageVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found package versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting package versions")
+	_, location, project, repository, pkg, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	parent := fmt.Sprintf("%s/projects/%s/locations/%s/repositories/%s/packages/%s/versions", apiURL, project, location, repository, url.PathEscape(pkg))
+	var versions []string
+	pageToken := ""
+	for {
+		u := fmt.Sprintf("%s?pageSize=1000&pageToken=%s", parent, url.QueryEscape(pageToken))
+		resp, err := p.client.Get(u)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var list versionList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range list.Versions {
+			// version name is in the format of projects/../packages/<package>/versions/<version>
+			version, err := url.PathUnescape(path.Base(v.Name))
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, version)
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	var versions []string
+	var err error
+	if conf.Strategy == v1alpha1.GARStrategyTags {
+		versions, err = p.getTags(conf.Repo)
+	} else if conf.Strategy == v1alpha1.GARStrategyVersions {
+		versions, err = p.getPackageVersions(conf.Repo)
+	} else {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
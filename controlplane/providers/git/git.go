@@ -0,0 +1,121 @@
+package git
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/crypto/ssh"
+)
+
+// Config contains configuration for git provider
+type Config struct {
+	// Base URL of the private git server (e.g. https://git.example.com)
+	RepositoryURL string
+	// Basic authentication credentials for https remotes. They are only sent to the host of the repository URL and
+	// never over plain http
+	Username string
+	Password string
+	// Private key for ssh remotes
+	SSHKeyFile string
+	// known_hosts file to verify the host key of ssh remotes
+	SSHKnownHostsFile string
+	// SHA256 fingerprints of the trusted host keys of ssh remotes, as printed by ssh-keygen -l
+	// (e.g. SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU)
+	SSHHostKeyFingerprints []string
+}
+
+// Provider is a git provider for getting remote versions from the tags of any git remote
+type Provider struct {
+	client                 *http.Client
+	repositoryURL          string
+	username               string
+	password               string
+	sshSigner              ssh.Signer
+	sshKnownHostsFile      string
+	sshHostKeyFingerprints []string
+	cache                  *cache.Cache
+	log                    logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	var signer ssh.Signer
+	if c.SSHKeyFile != "" {
+		key, err := ioutil.ReadFile(c.SSHKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the ssh key: %v", err)
+		}
+		signer, err = ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the ssh key: %v", err)
+		}
+		if c.SSHKnownHostsFile == "" && len(c.SSHHostKeyFingerprints) == 0 {
+			return nil, fmt.Errorf("a known_hosts file or host key fingerprints are required to verify the host keys of ssh remotes")
+		}
+	}
+	return &Provider{
+		client:                 &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		repositoryURL:          c.RepositoryURL,
+		username:               c.Username,
+		password:               c.Password,
+		sshSigner:              signer,
+		sshKnownHostsFile:      c.SSHKnownHostsFile,
+		sshHostKeyFingerprints: c.SSHHostKeyFingerprints,
+		cache:                  cache,
+		log:                    logger,
+	}, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("git/%s/tags", repo)
+}
+
+// getTags gets the tags of the remote. repo is an http(s) or ssh url
+// e.g https://git.example.com/owner/repo.git or git@git.example.com:owner/repo.git
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	refs, err := p.lsRemote(repo)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for ref := range refs {
+		// skip the peeled refs of the annotated tags
+		if !strings.HasPrefix(ref, "refs/tags/") || strings.HasSuffix(ref, "^{}") {
+			continue
+		}
+		tags = append(tags, strings.TrimPrefix(ref, "refs/tags/"))
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.GitStrategyTags {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, tags)
+}
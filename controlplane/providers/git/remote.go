@@ -0,0 +1,147 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// lsRemote returns the refs advertised by the remote repository, the equivalent of `git ls-remote`. The upload-pack
+// session is opened with the transports of go-git rather than with Remote.List, so the https remotes are reached
+// with the client of the provider (proxy, CA bundle and timeout) and the ssh remotes with the host key verification
+// of the provider
+func (p *Provider) lsRemote(remote string) (map[string]string, error) {
+	ep, err := transport.NewEndpoint(remote)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repo: %s: %v", remote, err)
+	}
+	var client transport.Transport
+	var auth transport.AuthMethod
+	switch ep.Protocol {
+	case "http", "https":
+		client = githttp.NewClient(p.client)
+		if p.username != "" && p.password != "" && p.sendCredentials(remote) {
+			auth = &githttp.BasicAuth{Username: p.username, Password: p.password}
+		}
+	case "ssh":
+		if client, auth, err = p.sshTransport(remote, ep); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid repo: %s. it must be an http(s) or ssh url", remote)
+	}
+	// go-git bounds the ssh dial and the reads of the advertisement but not the ssh handshake, so the listing is
+	// abandoned at the timeout rather than block the refresh on a stalled server
+	ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout)
+	defer cancel()
+	done := make(chan lsRemoteResult, 1)
+	go func() {
+		refs, err := listRefs(ctx, client, ep, auth)
+		done <- lsRemoteResult{refs: refs, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.refs, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to list the refs of %s: %v", remote, ctx.Err())
+	}
+}
+
+type lsRemoteResult struct {
+	refs map[string]string
+	err  error
+}
+
+// listRefs returns the refs advertised by the upload-pack session of the endpoint
+func listRefs(ctx context.Context, client transport.Transport, ep *transport.Endpoint, auth transport.AuthMethod) (map[string]string, error) {
+	session, err := client.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	advertised, err := session.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := advertised.AllReferences()
+	if err != nil {
+		return nil, err
+	}
+	refs := map[string]string{}
+	for name, ref := range all {
+		refs[name.String()] = ref.Hash().String()
+	}
+	return refs, nil
+}
+
+// sendCredentials returns true if the credentials can be sent to the remote, an https url of the host of the
+// repository URL
+func (p *Provider) sendCredentials(remote string) bool {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "https" && utils.SameHost(p.repositoryURL, u)
+}
+
+// sshTransport returns the ssh transport of the endpoint, with the key of the provider and the verification of the
+// host keys. The dial times out with the timeout of the provider
+func (p *Provider) sshTransport(remote string, ep *transport.Endpoint) (transport.Transport, transport.AuthMethod, error) {
+	if p.sshSigner == nil {
+		return nil, nil, fmt.Errorf("ssh key is required to access %s", remote)
+	}
+	// the path is quoted in the command run by the shell of the remote host
+	if strings.Contains(ep.Path, "'") {
+		return nil, nil, fmt.Errorf("invalid repo: %s. the path can not contain a single quote", remote)
+	}
+	hostKeyCallback, err := p.hostKeyCallback()
+	if err != nil {
+		return nil, nil, err
+	}
+	user := ep.User
+	if user == "" {
+		user = "git"
+	}
+	auth := &gitssh.PublicKeys{
+		User:   user,
+		Signer: p.sshSigner,
+		HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+			HostKeyCallback: hostKeyCallback,
+		},
+	}
+	return gitssh.NewClient(&ssh.ClientConfig{Timeout: p.client.Timeout}), auth, nil
+}
+
+// hostKeyCallback returns the verification of the host keys, which must be in the known_hosts file or have one of
+// the trusted fingerprints
+func (p *Provider) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	var knownHosts ssh.HostKeyCallback
+	if p.sshKnownHostsFile != "" {
+		var err error
+		knownHosts, err = knownhosts.New(p.sshKnownHostsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+		for _, f := range p.sshHostKeyFingerprints {
+			if f == fingerprint {
+				return nil
+			}
+		}
+		if knownHosts != nil {
+			return knownHosts(hostname, remote, key)
+		}
+		return fmt.Errorf("host key %s of %s is not trusted", fingerprint, hostname)
+	}, nil
+}
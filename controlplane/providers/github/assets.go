@@ -0,0 +1,194 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/hashicorp/go-version"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// checksum files published along with the release assets (e.g. checksums.txt, SHA256SUMS or app.tar.gz.sha256)
+var checksumFileRegex = regexp.MustCompile(`(?i)((^|[._-])(checksums?|sha256sums?)([._-]|$)|\.sha256$)`)
+
+// Asset is a release asset that a version was extracted from
+type Asset struct {
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	// SHA256 digest of the asset in the format of sha256:<hex> if a checksum file is published with the release
+	Digest string `json:"digest,omitempty"`
+
+	owner     string
+	repo      string
+	checksums *github.ReleaseAsset
+}
+
+func assetsCacheKey(repo, pattern string) string {
+	return fmt.Sprintf("github/%s/assets/%s", repo, pattern)
+}
+
+func checksumsCacheKey(repo string, assetID int64, url string) string {
+	return fmt.Sprintf("github/%s/checksums/%d/%s", repo, assetID, url)
+}
+
+// getVersionsFromAssets extracts the versions from the file names of the release assets and records
+// the matched assets so they can be looked up by version with GetAssets
+func (p *Provider) getVersionsFromAssets(conf v1alpha1.RemoteVersion) ([]string, error) {
+	limits, err := newPageLimits(conf)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := p.getReleases(conf.Repo, limits)
+	if err != nil {
+		return nil, err
+	}
+	owner, name, err := splitRepo(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	var assets []*Asset
+	for _, release := range releases {
+		if release.GetPrerelease() && !conf.Github.PrereleasesIncluded() {
+			continue
+		}
+		if release.GetDraft() && !conf.Github.DraftsIncluded() {
+			continue
+		}
+		// <asset>.sha256 files take precedence over the checksum file of the release
+		var checksums *github.ReleaseAsset
+		checksumFiles := map[string]*github.ReleaseAsset{}
+		for _, asset := range release.Assets {
+			if strings.HasSuffix(asset.GetName(), ".sha256") {
+				checksumFiles[strings.TrimSuffix(asset.GetName(), ".sha256")] = asset
+			} else if checksums == nil && checksumFileRegex.MatchString(asset.GetName()) {
+				checksums = asset
+			}
+		}
+		for _, asset := range release.Assets {
+			matched, v := utils.MatchPattern(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, asset.GetName())
+			if !matched {
+				continue
+			}
+			if conf.Constraint != "" {
+				meet, err := utils.MeetConstraint(conf.Constraint, v)
+				if err != nil {
+					return nil, err
+				}
+				if !meet {
+					continue
+				}
+			}
+			if !utils.Contains(versions, v) {
+				versions = append(versions, v)
+			}
+			assets = append(assets, &Asset{
+				Version:   v,
+				Name:      asset.GetName(),
+				URL:       asset.GetBrowserDownloadURL(),
+				owner:     owner,
+				repo:      name,
+				checksums: checksums,
+			})
+			if f, ok := checksumFiles[asset.GetName()]; ok {
+				assets[len(assets)-1].checksums = f
+			}
+		}
+	}
+	p.setCacheValue(conf.Repo, assetsCacheKey(conf.Repo, conf.Extraction.Regex.Pattern), assets)
+	return versions, nil
+}
+
+// GetAssets returns the release assets that the version was extracted from by the assets strategy.
+// The digests are resolved from the checksum file of the release when `assetDigests` is enabled
+func (p *Provider) GetAssets(conf v1alpha1.RemoteVersion, ver string) []Asset {
+	log := p.log.WithValues("repo", conf.Repo, "version", ver)
+	a, ok := p.getCacheValue(conf.Repo, assetsCacheKey(conf.Repo, conf.Extraction.Regex.Pattern))
+	if !ok {
+		return nil
+	}
+	var assets []Asset
+	for _, recorded := range a.([]*Asset) {
+		if !sameVersion(recorded.Version, ver) {
+			continue
+		}
+		asset := *recorded
+		if conf.Github.AssetDigests && asset.checksums != nil {
+			// checksum files are cached so they are only downloaded once
+			checksums, err := p.getChecksums(conf.Repo, asset.owner, asset.repo, asset.checksums)
+			if err != nil {
+				log.Error(err, "failed to get checksums", "file", asset.checksums.GetName())
+			} else if sum, ok := checksums[asset.Name]; ok {
+				asset.Digest = "sha256:" + sum
+			}
+		}
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+func sameVersion(a, b string) bool {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Equal(vb)
+}
+
+// getChecksums downloads a checksum file of a release and returns the checksums by file name
+func (p *Provider) getChecksums(fullName, owner, repo string, file *github.ReleaseAsset) (map[string]string, error) {
+	key := checksumsCacheKey(fullName, file.GetID(), file.GetBrowserDownloadURL())
+	if c, ok := p.getCacheValue(fullName, key); ok {
+		return c.(map[string]string), nil
+	}
+	var rc io.ReadCloser
+	err := p.retry(func() (err error) {
+		if file.GetID() != 0 {
+			rc, _, err = p.clientFor(fullName).Repositories.DownloadReleaseAsset(p.ctx, owner, repo, file.GetID(), p.downloadClient)
+			return err
+		}
+		// assets from the GraphQL API only have a download URL
+		resp, err := p.downloadClient.Get(file.GetBrowserDownloadURL())
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		rc = resp.Body
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			// <asset>.sha256 files may only contain the checksum
+			checksums[strings.TrimSuffix(file.GetName(), ".sha256")] = strings.ToLower(fields[0])
+		default:
+			// sha256sum format: <checksum> [*]<file name>
+			checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(fullName, key, checksums)
+	return checksums, nil
+}
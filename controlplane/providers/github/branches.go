@@ -0,0 +1,61 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v39/github"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+func branchesCacheKey(repo string) string {
+	return fmt.Sprintf("github/%s/branches", repo)
+}
+
+// getBranches gets the names of the branches of the repo
+func (p *Provider) getBranches(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if b, ok := p.getCacheValue(repo, branchesCacheKey(repo)); ok {
+		log.V(1).Info("found branches in cache")
+		return b.([]string), nil
+	}
+	log.V(1).Info("getting branches")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	// get branches by pagination (max 100)
+	opt := &github.BranchListOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		var branchesPage []*github.Branch
+		var resp *github.Response
+		err := p.retry(func() (err error) {
+			branchesPage, resp, err = p.clientFor(repo).Repositories.ListBranches(p.ctx, owner, name, opt)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, branch := range branchesPage {
+			branches = append(branches, branch.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	p.setCacheValue(repo, branchesCacheKey(repo), branches)
+	return branches, nil
+}
+
+// getVersionsFromBranches extracts the versions from the branch names (e.g. release-1.22)
+func (p *Provider) getVersionsFromBranches(conf v1alpha1.RemoteVersion) ([]string, error) {
+	branches, err := p.getBranches(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, branches)
+}
@@ -0,0 +1,337 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultCredentials   = "default"
+	anonymousCredentials = "anonymous"
+)
+
+// Credentials to authenticate against Github with either a token, a Github App installation or
+// short-lived tokens from a token endpoint
+type Credentials struct {
+	AppID             int64  `yaml:"appId"`
+	AppInstallationID int64  `yaml:"appInstallationId"`
+	AppPrivateKey     string `yaml:"appPrivateKey"`
+	Token             string `yaml:"token"`
+	// Path to a file containing the token. It takes precedence over Token
+	TokenFile string `yaml:"tokenFile"`
+	// URL of an endpoint returning short-lived tokens. They are refreshed before they expire
+	TokenURL string `yaml:"tokenUrl"`
+	// Path to a file containing an OIDC token sent to the token endpoint (e.g. a projected service account token).
+	// The OIDC token of the Github Actions runner is used when empty and available
+	IDTokenFile string `yaml:"idTokenFile"`
+	// Audience of the OIDC token requested from the Github Actions runner
+	Audience string `yaml:"audience"`
+}
+
+func (c Credentials) empty() bool {
+	return c.Token == "" && c.TokenFile == "" && c.TokenURL == "" && (c.AppID == 0 || c.AppPrivateKey == "")
+}
+
+// files returns the files the credentials are read from
+func (c Credentials) files() []string {
+	var files []string
+	if c.TokenFile != "" {
+		files = append(files, c.TokenFile)
+	}
+	if _, err := os.Stat(c.AppPrivateKey); c.AppPrivateKey != "" && err == nil {
+		files = append(files, c.AppPrivateKey)
+	}
+	return files
+}
+
+// loadCredentialsFile reads the credentials by org or repo prefix from a YAML file in the format of:
+//
+//	my-org:
+//	  token: ${MY_ORG_TOKEN}
+//	other-org/repo:
+//	  appId: 1234
+//	  appInstallationId: 5678
+//	  appPrivateKey: /etc/opvic/other-org.pem
+//
+// Environment variables in the tokens and private keys are expanded. The installation of the org of the
+// prefix is discovered when appInstallationId is omitted
+func loadCredentialsFile(path string) (map[string]Credentials, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file %s: %v", path, err)
+	}
+	var file map[string]Credentials
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %v", path, err)
+	}
+	credentials := map[string]Credentials{}
+	for prefix, creds := range file {
+		creds.Token = os.ExpandEnv(creds.Token)
+		creds.TokenFile = os.ExpandEnv(creds.TokenFile)
+		creds.TokenURL = os.ExpandEnv(creds.TokenURL)
+		creds.IDTokenFile = os.ExpandEnv(creds.IDTokenFile)
+		creds.AppPrivateKey = os.ExpandEnv(creds.AppPrivateKey)
+		credentials[strings.Trim(prefix, "/")] = creds
+	}
+	return credentials, nil
+}
+
+// client is a Github client authenticated with one set of credentials
+type client struct {
+	*github.Client
+	// name of the credentials used as the metrics label
+	name string
+	// rate limiting can be disabled on Github Enterprise Server. Set to 1 once it is found disabled, it is read and
+	// written atomically by the concurrent refreshes
+	rateLimitDisabled int32
+	// GraphQL endpoint to get releases and tags from. REST API is used when empty
+	graphQLURL string
+}
+
+// newClient creates a Github client for the credentials. Anonymous clients are created for empty credentials
+func (c *Config) newClient(name string, creds Credentials, base http.RoundTripper, logger logr.Logger) (*client, error) {
+	var transport http.RoundTripper
+	if creds.TokenFile != "" {
+		token, err := ioutil.ReadFile(creds.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: reading token file %s: %v", creds.TokenFile, err)
+		}
+		creds.Token = strings.TrimSpace(string(token))
+	}
+	if creds.Token != "" {
+		transport = &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: creds.Token}),
+			Base:   base,
+		}
+	} else if creds.TokenURL != "" {
+		transport = &oauth2.Transport{
+			Source: newExternalTokenSource(creds, base),
+			Base:   base,
+		}
+	} else if !creds.empty() {
+		var tr *ghinstallation.Transport
+		if _, err := os.Stat(creds.AppPrivateKey); err == nil {
+			tr, err = ghinstallation.NewKeyFromFile(base, creds.AppID, creds.AppInstallationID, creds.AppPrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("authentication failed: using private key from file %s: %v", creds.AppPrivateKey, err)
+			}
+		} else {
+			tr, err = ghinstallation.New(base, creds.AppID, creds.AppInstallationID, []byte(creds.AppPrivateKey))
+			if err != nil {
+				return nil, fmt.Errorf("authentication failed: using private key: %v", err)
+			}
+		}
+		// installation tokens must be requested from the enterprise instance
+		if c.BaseURL != "" {
+			tr.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+		}
+		transport = tr
+	}
+	authenticated := transport != nil
+	if !authenticated {
+		transport = base
+	}
+	// conditional requests save the rate limit when the releases and tags have not changed
+	// and requests are serialized to avoid the secondary rate limits
+	httpClient := &http.Client{Transport: newETagTransport(newSerialTransport(newMetricsTransport(transport)))}
+	cl := &client{
+		name: name,
+	}
+	if c.BaseURL != "" {
+		uploadURL := c.UploadURL
+		if uploadURL == "" {
+			uploadURL = c.BaseURL
+		}
+		var err error
+		cl.Client, err = github.NewEnterpriseClient(c.BaseURL, uploadURL, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid github enterprise url: %v", err)
+		}
+	} else {
+		cl.Client = github.NewClient(httpClient)
+	}
+	if c.GraphQL {
+		if !authenticated {
+			logger.V(1).Info("graphql api requires authentication. using the rest api", "credentials", name)
+		} else {
+			cl.graphQLURL = graphQLEndpoint(cl.Client)
+		}
+	}
+	return cl, nil
+}
+
+// credentials returns the default credentials and the credentials by org or repo prefix
+func (c *Config) credentials() (Credentials, map[string]Credentials, error) {
+	creds := Credentials{
+		AppID:             c.AppID,
+		AppInstallationID: c.AppInstallationID,
+		AppPrivateKey:     c.AppPrivateKey,
+		Token:             c.Token,
+		TokenFile:         c.TokenFile,
+		TokenURL:          c.TokenURL,
+		IDTokenFile:       c.IDTokenFile,
+		Audience:          c.TokenAudience,
+	}
+	credentials := map[string]Credentials{}
+	if c.CredentialsFile != "" {
+		var err error
+		credentials, err = loadCredentialsFile(c.CredentialsFile)
+		if err != nil {
+			return creds, nil, err
+		}
+	}
+	return creds, credentials, nil
+}
+
+// newClients creates the default client and the clients by org or repo prefix
+func (c *Config) newClients(ctx context.Context, base http.RoundTripper, logger logr.Logger) (*client, map[string]*client, error) {
+	creds, credentials, err := c.credentials()
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, credentials, err = c.resolveInstallations(ctx, creds, credentials, base)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := defaultCredentials
+	if creds.empty() {
+		logger.V(1).Info("no authentication provided. You might encounter Github API rate limiting issues.")
+		name = anonymousCredentials
+	}
+	defaultClient, err := c.newClient(name, creds, base, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	clients := map[string]*client{}
+	for prefix, creds := range credentials {
+		if creds.empty() {
+			return nil, nil, fmt.Errorf("credentials of %s require either a token or app credentials", prefix)
+		}
+		clients[prefix], err = c.newClient(prefix, creds, base, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("credentials of %s: %v", prefix, err)
+		}
+	}
+	return defaultClient, clients, nil
+}
+
+// credentialsChecksum returns a checksum of the content of all the files the credentials are read from
+// and of the discovered installations of the Github Apps so the clients are rebuilt when an app is reinstalled
+func (c *Config) credentialsChecksum(ctx context.Context, base http.RoundTripper) string {
+	files := []string{}
+	if c.CredentialsFile != "" {
+		files = append(files, c.CredentialsFile)
+	}
+	creds, credentials, err := c.credentials()
+	if err == nil {
+		files = append(files, creds.files()...)
+		for _, creds := range credentials {
+			files = append(files, creds.files()...)
+		}
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, f := range files {
+		data, _ := ioutil.ReadFile(f)
+		h.Write([]byte(f))
+		h.Write(data)
+	}
+	if err == nil {
+		if _, resolved, err := c.resolveInstallations(ctx, creds, credentials, base); err == nil {
+			var installations []string
+			for prefix, creds := range resolved {
+				installations = append(installations, fmt.Sprintf("%s=%d", prefix, creds.AppInstallationID))
+			}
+			sort.Strings(installations)
+			h.Write([]byte(strings.Join(installations, ",")))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// watchCredentials rebuilds the clients when the content of the credentials files changes (e.g. a rotated
+// token in a mounted secret). Polling is used since mounted secrets are updated by swapping symlinks
+func (p *Provider) watchCredentials(c *Config, base http.RoundTripper, interval time.Duration) {
+	log := p.log.WithName("credentials")
+	checksum := c.credentialsChecksum(p.ctx, base)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		newChecksum := c.credentialsChecksum(p.ctx, base)
+		if newChecksum == checksum {
+			continue
+		}
+		log.Info("credentials changed. reloading the github clients")
+		defaultClient, clients, err := c.newClients(p.ctx, base, log)
+		if err != nil {
+			// keep the current clients and retry on the next change
+			log.Error(err, "failed to reload the github clients")
+			continue
+		}
+		p.clientsMutex.Lock()
+		p.defaultClient = defaultClient
+		p.clients = clients
+		p.clientsMutex.Unlock()
+		checksum = newChecksum
+	}
+}
+
+// clientFor returns the client of the credentials with the longest org or repo prefix matching the repo.
+// The default client is returned if no prefix matches
+func (p *Provider) clientFor(repo string) *client {
+	p.clientsMutex.RLock()
+	defer p.clientsMutex.RUnlock()
+	// org and repo names are case insensitive
+	repo = strings.ToLower(repo)
+	var match string
+	for prefix := range p.clients {
+		lower := strings.ToLower(prefix)
+		if (repo == lower || strings.HasPrefix(repo, lower+"/")) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match == "" {
+		return p.defaultClient
+	}
+	return p.clients[match]
+}
+
+// checkRateLimit sets the remaining requests of the rate limit of the client as metrics. Github Enterprise Server
+// responds with not found when rate limiting is disabled, in which case the check is skipped from then on
+func (p *Provider) checkRateLimit(c *client) error {
+	if atomic.LoadInt32(&c.rateLimitDisabled) == 1 {
+		return nil
+	}
+	limit, _, err := c.RateLimits(p.ctx)
+	if err != nil {
+		if errResp, ok := err.(*github.ErrorResponse); ok && errResp.Response.StatusCode == http.StatusNotFound {
+			p.log.V(1).Info("rate limiting is not enabled on the github instance")
+			atomic.StoreInt32(&c.rateLimitDisabled, 1)
+			return nil
+		}
+		return err
+	}
+	p.log.V(1).Info("rate limit", "credentials", c.name, "remaining", limit.Core.Remaining)
+	rateLimitRemaining.WithLabelValues(c.name).Set(float64(limit.Core.Remaining))
+	return nil
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v39/github"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	commitFormatSHA  = "sha"
+	commitDateFormat = "20060102.150405"
+	shortSHALength   = 7
+)
+
+func commitsCacheKey(repo, branch string) string {
+	return fmt.Sprintf("github/%s/commits/%s", repo, branch)
+}
+
+// getCommits gets the last 100 commits of the branch. The default branch is used if branch is empty
+func (p *Provider) getCommits(repo, branch string) ([]*github.RepositoryCommit, error) {
+	log := p.log.WithValues("repo", repo, "branch", branch)
+	if c, ok := p.getCacheValue(repo, commitsCacheKey(repo, branch)); ok {
+		log.V(1).Info("found commits in cache")
+		return c.([]*github.RepositoryCommit), nil
+	}
+	log.V(1).Info("getting commits")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	opt := &github.CommitsListOptions{
+		SHA:         branch,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var commits []*github.RepositoryCommit
+	err = p.retry(func() (err error) {
+		commits, _, err = p.clientFor(repo).Repositories.ListCommits(p.ctx, owner, name, opt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(repo, commitsCacheKey(repo, branch), commits)
+	return commits, nil
+}
+
+// getVersionsFromCommits returns the commits of the branch as versions. By default the commit date is used
+// since it can be compared like a version. Short SHAs can not be ordered and are returned newest first
+func (p *Provider) getVersionsFromCommits(conf v1alpha1.RemoteVersion) ([]string, error) {
+	commits, err := p.getCommits(conf.Repo, conf.Github.Branch)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for _, commit := range commits {
+		if conf.Github.CommitFormat == commitFormatSHA {
+			sha := commit.GetSHA()
+			if len(sha) > shortSHALength {
+				sha = sha[:shortSHALength]
+			}
+			candidates = append(candidates, sha)
+			continue
+		}
+		date := commit.GetCommit().GetCommitter().GetDate()
+		if date.IsZero() {
+			continue
+		}
+		candidates = append(candidates, date.UTC().Format(commitDateFormat))
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+}
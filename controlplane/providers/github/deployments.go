@@ -0,0 +1,79 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v39/github"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	deploymentStateSuccess = "success"
+	// maximum number of deployments to check the statuses of to find a successful one
+	maxDeploymentsChecked = 10
+)
+
+func deploymentCacheKey(repo, environment string) string {
+	return fmt.Sprintf("github/%s/deployments/%s", repo, environment)
+}
+
+// getLatestDeploymentRef gets the ref (branch, tag or commit SHA) of the latest successful deployment to the
+// environment. It returns an empty string if none of the recent deployments succeeded
+func (p *Provider) getLatestDeploymentRef(repo, environment string) (string, error) {
+	log := p.log.WithValues("repo", repo, "environment", environment)
+	if r, ok := p.getCacheValue(repo, deploymentCacheKey(repo, environment)); ok {
+		log.V(1).Info("found latest deployment in cache")
+		return r.(string), nil
+	}
+	log.V(1).Info("getting deployments")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	c := p.clientFor(repo)
+	// deployments are returned newest first
+	opt := &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: maxDeploymentsChecked},
+	}
+	var deployments []*github.Deployment
+	err = p.retry(func() (err error) {
+		deployments, _, err = c.Repositories.ListDeployments(p.ctx, owner, name, opt)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	var ref string
+	for _, deployment := range deployments {
+		var statuses []*github.DeploymentStatus
+		err = p.retry(func() (err error) {
+			// the latest status is the first one
+			statuses, _, err = c.Repositories.ListDeploymentStatuses(p.ctx, owner, name, deployment.GetID(), &github.ListOptions{PerPage: 1})
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(statuses) > 0 && statuses[0].GetState() == deploymentStateSuccess {
+			ref = deployment.GetRef()
+			break
+		}
+	}
+	p.setCacheValue(repo, deploymentCacheKey(repo, environment), ref)
+	return ref, nil
+}
+
+// getVersionsFromDeployments extracts the version from the ref of the latest successful deployment
+// to the environment of `github.environment` (e.g. production)
+func (p *Provider) getVersionsFromDeployments(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Github.Environment == "" {
+		return nil, fmt.Errorf("github.environment is required for the %s strategy", conf.Strategy)
+	}
+	ref, err := p.getLatestDeploymentRef(conf.Repo, conf.Github.Environment)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, []string{ref})
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	// the stored responses of the URLs that are not requested again within the expiration are dropped, so the
+	// responses of the pages, commits and files that are no longer requested don't pile up
+	etagExpiration      = 24 * time.Hour
+	etagCleanupInterval = time.Hour
+)
+
+// conditionalResponse is the last successful response of a request along with its ETag
+type conditionalResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// etagTransport issues conditional requests for the GET requests that previously returned an ETag.
+// A 304 Not Modified response does not count against the Github rate limit and is replaced by the
+// stored response so the client handles it as a regular 200 OK
+type etagTransport struct {
+	transport http.RoundTripper
+	responses *cache.Cache
+}
+
+func newETagTransport(transport http.RoundTripper) *etagTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &etagTransport{
+		transport: transport,
+		responses: cache.New(etagExpiration, etagCleanupInterval),
+	}
+}
+
+func (t *etagTransport) get(key string) (*conditionalResponse, bool) {
+	r, ok := t.responses.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return r.(*conditionalResponse), true
+}
+
+// set stores the response of the key, or extends its expiration
+func (t *etagTransport) set(key string, r *conditionalResponse) {
+	t.responses.Set(key, r, cache.DefaultExpiration)
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the rate limit endpoint must always reflect the current state
+	if req.Method != http.MethodGet || strings.HasSuffix(req.URL.Path, "/rate_limit") {
+		return t.transport.RoundTrip(req)
+	}
+	key := req.URL.String()
+	stored, found := t.get(key)
+	if found {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", stored.etag)
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if found && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		t.set(key, stored)
+		header := stored.header.Clone()
+		// keep the rate limit headers of the conditional request up to date
+		for k, v := range resp.Header {
+			if strings.HasPrefix(k, "X-Ratelimit-") {
+				header[k] = v
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(stored.body)),
+			ContentLength: int64(len(stored.body)),
+			Request:       req,
+		}, nil
+	}
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.set(key, &conditionalResponse{
+		etag:   etag,
+		header: resp.Header.Clone(),
+		body:   body,
+	})
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
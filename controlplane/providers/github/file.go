@@ -0,0 +1,101 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+func fileCacheKey(repo, path, ref string) string {
+	return fmt.Sprintf("github/%s/file/%s@%s", repo, path, ref)
+}
+
+// getFile gets the content of the file at the ref. The default branch is used if ref is empty
+func (p *Provider) getFile(repo, path, ref string) (string, error) {
+	log := p.log.WithValues("repo", repo, "path", path, "ref", ref)
+	if f, ok := p.getCacheValue(repo, fileCacheKey(repo, path, ref)); ok {
+		log.V(1).Info("found file in cache")
+		return f.(string), nil
+	}
+	log.V(1).Info("getting file")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	var file *github.RepositoryContent
+	err = p.retry(func() (err error) {
+		file, _, _, err = p.clientFor(repo).Repositories.GetContents(p.ctx, owner, name, path, &github.RepositoryContentGetOptions{Ref: ref})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return "", err
+	}
+	p.setCacheValue(repo, fileCacheKey(repo, path, ref), content)
+	return content, nil
+}
+
+// getFields gets the values of the JsonPath from a JSON or YAML document
+func getFields(fieldSelector, content string) ([]string, error) {
+	data, err := yaml.YAMLToJSON([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	// accept relaxed expressions like the agent does (e.g. .version or version)
+	if !strings.HasPrefix(fieldSelector, "{") {
+		if !strings.HasPrefix(fieldSelector, ".") {
+			fieldSelector = "." + fieldSelector
+		}
+		fieldSelector = fmt.Sprintf("{%s}", fieldSelector)
+	}
+	j := jsonpath.New("fieldSelector")
+	if err := j.Parse(fieldSelector); err != nil {
+		return nil, err
+	}
+	results, err := j.FindResults(doc)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, fmt.Sprintf("%v", value.Interface()))
+		}
+	}
+	return values, nil
+}
+
+// getVersionsFromFile extracts the version from a file of the repo (e.g. VERSION, Chart.yaml or package.json)
+func (p *Provider) getVersionsFromFile(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Github.Path == "" {
+		return nil, fmt.Errorf("github.path is required for the %s strategy", conf.Strategy)
+	}
+	content, err := p.getFile(conf.Repo, conf.Github.Path, conf.Github.Ref)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{strings.TrimSpace(content)}
+	if conf.Github.FieldSelector != "" {
+		candidates, err = getFields(conf.Github.FieldSelector, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s from %s: %v", conf.Github.FieldSelector, conf.Github.Path, err)
+		}
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+}
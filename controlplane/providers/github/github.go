@@ -4,104 +4,185 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/bradleyfalzon/ghinstallation"
 	"github.com/go-logr/logr"
 	"github.com/google/go-github/v39/github"
 	"github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
 	"github.com/skillz/opvic/utils"
-	"golang.org/x/oauth2"
 )
 
 var (
-	rateLimitRemaining = prometheus.NewGauge(
+	rateLimitRemaining = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "opvic_provider_github",
 			Name:      "rate_limit_remaining",
 			Help:      "The number of requests remaining in the current rate limit window.",
 		},
+		[]string{"credentials"},
 	)
 )
 
 // Config contains configuration for Github provider
 type Config struct {
-	AppID             int64
+	AppID int64
+	// Installation of the Github App. When 0, the installations of the app are discovered and used
+	// for the repos of the organizations and users the app is installed on
 	AppInstallationID int64
 	AppPrivateKey     string
 	Token             string
+	// Path to a file containing the token (e.g. a mounted secret). It takes precedence over Token
+	TokenFile string
+	// URL of an endpoint returning short-lived tokens in the format of {"token": "...", "expires_at": "..."}
+	// (e.g. a token broker exchanging OIDC tokens). Tokens are refreshed before they expire
+	TokenURL string
+	// Path to a file containing an OIDC token sent as a bearer token to the token endpoint
+	// (e.g. a projected service account token). The OIDC token of the Github Actions runner is used when empty
+	IDTokenFile string
+	// Audience of the OIDC token requested from the Github Actions runner
+	TokenAudience string
+	// Path to a YAML file with credentials by org or repo prefix (e.g. my-org or my-org/my-repo).
+	// The credentials with the longest matching prefix are used and the credentials above otherwise.
+	// Repos without any matching credentials are accessed anonymously
+	CredentialsFile string
+	// API URL of a Github Enterprise Server instance (e.g. https://github.example.com/api/v3/).
+	// Defaults to github.com when empty
+	BaseURL string
+	// Upload URL of a Github Enterprise Server instance. Defaults to BaseURL when empty
+	UploadURL string
+	// Use the GraphQL API to get releases and tags. It requires authentication
+	GraphQL bool
+	// Number of times a failed request is retried. Defaults to 3
+	MaxRetries int
+	// Maximum time to wait for the rate limit to reset or between retries of a request. Defaults to 1m
+	MaxWait time.Duration
+	// URL of the proxy for the requests to Github. Defaults to the proxy from the environment
+	ProxyURL string
+	// Path to a CA bundle to trust in addition to the system roots
+	CAFile string
+	// Interval to check the token, private key and credentials files for changes.
+	// The clients are rebuilt with the new credentials without losing the cache. Disabled when 0
+	ReloadInterval time.Duration
+}
+
+// packageTypeContainer is the default package type of the packages strategy
+const packageTypeContainer = "container"
+
+// PackageVersion is a version of a package in Github Packages
+type PackageVersion struct {
+	Name     string `json:"name"`
+	Metadata struct {
+		Container struct {
+			Tags []string `json:"tags"`
+		} `json:"container"`
+	} `json:"metadata"`
 }
 
 // Provider is a github provider for getting remote versions from Github
 type Provider struct {
-	client *github.Client
-	ctx    context.Context
-	cache  *cache.Cache
-	log    logr.Logger
+	defaultClient *client
+	// clients by org or repo prefix
+	clients map[string]*client
+	// guards the clients which are rebuilt when the credentials change
+	clientsMutex sync.RWMutex
+	ctx          context.Context
+	cache        *cache.Cache
+	log          logr.Logger
+	maxRetries   int
+	maxWait      time.Duration
+	// client to download release assets from their storage (without the Github credentials)
+	downloadClient *http.Client
 }
 
 func init() {
 	prometheus.MustRegister(rateLimitRemaining)
 }
 
-func (c *Config) NewProvider(ctx context.Context, cache *cache.Cache, logger logr.Logger) (*Provider, error) {
-	var transport http.RoundTripper
-	var client *github.Client
-	if c.Token != "" {
-		transport = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Token})).Transport
-	} else if c.AppID != 0 && c.AppInstallationID != 0 && c.AppPrivateKey != "" {
-		var tr *ghinstallation.Transport
-		tr = nil
-
-		if _, err := os.Stat(c.AppPrivateKey); err == nil {
-			tr, err = ghinstallation.NewKeyFromFile(http.DefaultTransport, c.AppID, c.AppInstallationID, c.AppPrivateKey)
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key from file %s: %v", c.AppPrivateKey, err)
-			}
-		} else if c.AppPrivateKey != "" {
-			tr, err = ghinstallation.New(http.DefaultTransport, c.AppID, c.AppInstallationID, []byte(c.AppPrivateKey))
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key: %v", err)
-			}
+func (c *Config) NewProvider(ctx context.Context, cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	// the proxy and the CA of the github provider override the ones of the providers
+	base := tr
+	if c.ProxyURL != "" || c.CAFile != "" {
+		custom, err := utils.NewHTTPTransport(c.ProxyURL, c.CAFile)
+		if err != nil {
+			return nil, err
 		}
-
-		transport = tr
+		base = custom
 	}
-	if transport != nil {
-		httpClient := &http.Client{Transport: transport}
-		client = github.NewClient(httpClient)
-	} else {
-		logger.V(1).Info("no authentication provided. You might encounter Github API rate limiting issues.")
-		client = github.NewClient(nil)
+	p := &Provider{
+		clients:        map[string]*client{},
+		ctx:            ctx,
+		cache:          cache,
+		log:            logger,
+		maxRetries:     c.MaxRetries,
+		maxWait:        c.MaxWait,
+		downloadClient: &http.Client{Transport: base, Timeout: 30 * time.Second},
+	}
+	if p.maxRetries <= 0 {
+		p.maxRetries = defaultMaxRetries
+	}
+	if p.maxWait <= 0 {
+		p.maxWait = defaultMaxWait
 	}
 
-	// Check the rate limit and set it as metrics on startup
-	limit, _, err := client.RateLimits(ctx)
+	var err error
+	p.defaultClient, p.clients, err = c.newClients(ctx, base, logger)
 	if err != nil {
 		return nil, err
 	}
-	logger.V(1).Info("rate limit", "remaining", limit.Core.Remaining)
-	rateLimitRemaining.Set(float64(limit.Core.Remaining))
+	if c.BaseURL != "" {
+		logger.V(1).Info("using github enterprise server", "url", p.defaultClient.BaseURL.String())
+	}
 
-	return &Provider{
-		client: client,
-		ctx:    ctx,
-		cache:  cache,
-		log:    logger,
-	}, nil
+	// Check the rate limits and set them as metrics on startup
+	if err := p.checkRateLimit(p.defaultClient); err != nil {
+		return nil, err
+	}
+	for _, cl := range p.clients {
+		if err := p.checkRateLimit(cl); err != nil {
+			return nil, err
+		}
+	}
+	if c.ReloadInterval > 0 {
+		go p.watchCredentials(c, base, c.ReloadInterval)
+	}
+	return p, nil
 }
 
-func (p *Provider) getCacheValue(key string) (interface{}, bool) {
-	return p.cache.Get(key)
+// getCacheValue gets a cached value of the repo and records the cache hit or miss as metrics
+func (p *Provider) getCacheValue(repo, key string) (interface{}, bool) {
+	v, ok := p.cache.Get(key)
+	if ok {
+		cacheRequestsTotal.WithLabelValues(repo, "hit").Inc()
+	} else {
+		cacheRequestsTotal.WithLabelValues(repo, "miss").Inc()
+	}
+	return v, ok
 }
 
-func (p *Provider) setCacheValue(key string, value interface{}) {
+// setCacheValue caches the value of the repo. The cache TTL of the remote versions is applied by the providers
+func (p *Provider) setCacheValue(repo, key string, value interface{}) {
 	p.cache.Set(key, value, cache.DefaultExpiration)
 }
 
+// InvalidateCache removes the cached releases, tags, packages, branches and deployments of the repo
+func (p *Provider) InvalidateCache(repo string) {
+	p.log.V(1).Info("invalidating cache", "repo", repo)
+	// releases and tags are cached by page limits, deployments by environment and packages by type
+	for key := range p.cache.Items() {
+		if strings.HasPrefix(key, releasesCacheKey(repo)) || strings.HasPrefix(key, tagsCacheKey(repo)) ||
+			strings.HasPrefix(key, deploymentCacheKey(repo, "")) || strings.HasPrefix(key, packagesCacheKey(repo, "")) {
+			p.cache.Delete(key)
+		}
+	}
+	p.cache.Delete(latestReleaseCacheKey(repo))
+	p.cache.Delete(branchesCacheKey(repo))
+}
+
 func releasesCacheKey(repo string) string {
 	return fmt.Sprintf("github/%s/releases", repo)
 }
@@ -110,31 +191,34 @@ func tagsCacheKey(repo string) string {
 	return fmt.Sprintf("github/%s/tags", repo)
 }
 
-func (p *Provider) getReleases(repo string) ([]*github.RepositoryRelease, error) {
+func packagesCacheKey(repo, packageType string) string {
+	return fmt.Sprintf("github/%s/packages/%s", repo, packageType)
+}
+
+func latestReleaseCacheKey(repo string) string {
+	return fmt.Sprintf("github/%s/latest", repo)
+}
+
+func (p *Provider) getReleases(repo string, limits pageLimits) ([]*github.RepositoryRelease, error) {
 	log := p.log.WithValues("repo", repo)
 	var releases []*github.RepositoryRelease
-	if r, ok := p.getCacheValue(releasesCacheKey(repo)); !ok {
+	key := releasesCacheKey(repo) + limits.key()
+	if r, ok := p.getCacheValue(repo, key); !ok {
 		log.V(1).Info("getting releases")
 		owner, name, err := splitRepo(repo)
 		if err != nil {
 			return nil, err
 		}
-		// get releases by pagination (max 100)
-		opt := &github.ListOptions{
-			PerPage: 100,
+		c := p.clientFor(repo)
+		if c.graphQLURL != "" {
+			releases, err = p.listReleasesGraphQL(c, owner, name, limits)
+		} else {
+			releases, err = p.listReleases(c, owner, name, limits)
 		}
-		for {
-			releasesPage, resp, err := p.client.Repositories.ListReleases(p.ctx, owner, name, opt)
-			if err != nil {
-				return nil, err
-			}
-			releases = append(releases, releasesPage...)
-			if resp.NextPage == 0 {
-				break
-			}
-			opt.Page = resp.NextPage
+		if err != nil {
+			return nil, err
 		}
-		p.setCacheValue(releasesCacheKey(repo), releases)
+		p.setCacheValue(repo, key, releases)
 	} else {
 		log.V(1).Info("found releases in cache")
 		releases = r.([]*github.RepositoryRelease)
@@ -142,31 +226,26 @@ func (p *Provider) getReleases(repo string) ([]*github.RepositoryRelease, error)
 	return releases, nil
 }
 
-func (p *Provider) getTags(repo string) ([]*github.RepositoryTag, error) {
+func (p *Provider) getTags(repo string, limits pageLimits) ([]*github.RepositoryTag, error) {
 	log := p.log.WithValues("repo", repo)
 	var tags []*github.RepositoryTag
-	if t, ok := p.getCacheValue(tagsCacheKey(repo)); !ok {
+	key := tagsCacheKey(repo) + limits.key()
+	if t, ok := p.getCacheValue(repo, key); !ok {
 		log.V(1).Info("getting tags")
 		owner, name, err := splitRepo(repo)
 		if err != nil {
 			return nil, err
 		}
-		// get releases by pagination (max 100)
-		opt := &github.ListOptions{
-			PerPage: 100,
+		c := p.clientFor(repo)
+		if c.graphQLURL != "" {
+			tags, err = p.listTagsGraphQL(c, owner, name, limits)
+		} else {
+			tags, err = p.listTags(c, owner, name, limits)
 		}
-		for {
-			tagsPage, resp, err := p.client.Repositories.ListTags(p.ctx, owner, name, opt)
-			if err != nil {
-				return nil, err
-			}
-			tags = append(tags, tagsPage...)
-			if resp.NextPage == 0 {
-				break
-			}
-			opt.Page = resp.NextPage
+		if err != nil {
+			return nil, err
 		}
-		p.setCacheValue(tagsCacheKey(repo), tags)
+		p.setCacheValue(repo, key, tags)
 	} else {
 		log.V(1).Info("found tags in cache")
 		tags = t.([]*github.RepositoryTag)
@@ -174,10 +253,192 @@ func (p *Provider) getTags(repo string) ([]*github.RepositoryTag, error) {
 	return tags, nil
 }
 
+// listReleases gets the releases of the repository, most recent first, within the page limits
+func (p *Provider) listReleases(c *client, owner, name string, limits pageLimits) ([]*github.RepositoryRelease, error) {
+	var releases []*github.RepositoryRelease
+	// get releases by pagination (max 100)
+	opt := &github.ListOptions{
+		PerPage: limits.perPage(),
+	}
+	met := false
+	for pages := 1; ; pages++ {
+		var releasesPage []*github.RepositoryRelease
+		var resp *github.Response
+		err := p.retry(func() (err error) {
+			releasesPage, resp, err = c.Repositories.ListReleases(p.ctx, owner, name, opt)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		expired := false
+		var names []string
+		for _, release := range releasesPage {
+			if limits.expired(release.GetCreatedAt().Time) {
+				expired = true
+				break
+			}
+			releases = append(releases, release)
+			names = append(names, release.GetName())
+		}
+		if resp.NextPage == 0 || expired || limits.done(pages, len(releases)) || limits.pastConstraint(names, &met) {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return releases[:limits.truncate(len(releases))], nil
+}
+
+// listTags gets the tags of the repository within the page limits. Tags have no date so the cutoff doesn't apply.
+// The REST API doesn't return the tags newest first, so the pagination only stops at the max pages and the tags are
+// sorted by version before keeping the max items
+func (p *Provider) listTags(c *client, owner, name string, limits pageLimits) ([]*github.RepositoryTag, error) {
+	var tags []*github.RepositoryTag
+	// get tags by pagination (max 100)
+	opt := &github.ListOptions{
+		PerPage: maxPerPage,
+	}
+	for pages := 1; ; pages++ {
+		var tagsPage []*github.RepositoryTag
+		var resp *github.Response
+		err := p.retry(func() (err error) {
+			tagsPage, resp, err = c.Repositories.ListTags(p.ctx, owner, name, opt)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tagsPage...)
+		if resp.NextPage == 0 || limits.done(pages, 0) {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.GetName()
+	}
+	sorted := make([]*github.RepositoryTag, len(tags))
+	for i, j := range limits.sortNewestFirst(names) {
+		sorted[i] = tags[j]
+	}
+	return sorted[:limits.truncate(len(sorted))], nil
+}
+
+// getLatestRelease gets the most recent non-prerelease, non-draft release of the repo with a single request.
+// It returns nil if the repo has no release
+func (p *Provider) getLatestRelease(repo string) (*github.RepositoryRelease, error) {
+	log := p.log.WithValues("repo", repo)
+	if r, ok := p.getCacheValue(repo, latestReleaseCacheKey(repo)); ok {
+		log.V(1).Info("found latest release in cache")
+		return r.(*github.RepositoryRelease), nil
+	}
+	log.V(1).Info("getting latest release")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	var release *github.RepositoryRelease
+	err = p.retry(func() (err error) {
+		release, _, err = p.clientFor(repo).Repositories.GetLatestRelease(p.ctx, owner, name)
+		return err
+	})
+	if err != nil {
+		if errResp, ok := err.(*github.ErrorResponse); !ok || errResp.Response.StatusCode != http.StatusNotFound {
+			return nil, err
+		}
+		release = nil
+	}
+	p.setCacheValue(repo, latestReleaseCacheKey(repo), release)
+	return release, nil
+}
+
+// getPackageVersions gets the versions of a Github Packages package of the type (container, npm, maven, rubygems
+// or nuget) owned by an organization or a user
+func (p *Provider) getPackageVersions(repo, packageType string) ([]*PackageVersion, error) {
+	log := p.log.WithValues("repo", repo, "packageType", packageType)
+	var versions []*PackageVersion
+	if v, ok := p.getCacheValue(repo, packagesCacheKey(repo, packageType)); !ok {
+		log.V(1).Info("getting package versions")
+		owner, name, err := splitRepo(repo)
+		if err != nil {
+			return nil, err
+		}
+		// the package can be owned by an organization or a user
+		for _, ownerType := range []string{"orgs", "users"} {
+			versions, err = p.listPackageVersions(p.clientFor(repo), ownerType, owner, packageType, name)
+			if err == nil {
+				break
+			}
+			if errResp, ok := err.(*github.ErrorResponse); !ok || errResp.Response.StatusCode != http.StatusNotFound {
+				return nil, err
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.setCacheValue(repo, packagesCacheKey(repo, packageType), versions)
+	} else {
+		log.V(1).Info("found package versions in cache")
+		versions = v.([]*PackageVersion)
+	}
+	return versions, nil
+}
+
+func (p *Provider) listPackageVersions(c *client, ownerType, owner, packageType, name string) ([]*PackageVersion, error) {
+	var versions []*PackageVersion
+	page := 1
+	for page != 0 {
+		u := fmt.Sprintf("%s/%s/packages/%s/%s/versions?per_page=100&page=%d", ownerType, owner, packageType, url.PathEscape(name), page)
+		req, err := c.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		var versionsPage []*PackageVersion
+		var resp *github.Response
+		err = p.retry(func() (err error) {
+			resp, err = c.Do(p.ctx, req, &versionsPage)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, versionsPage...)
+		page = resp.NextPage
+	}
+	return versions, nil
+}
+
+// getVersionsFromPackages extracts the versions from the tags of a container package or the
+// version names of the other package types
+func (p *Provider) getVersionsFromPackages(conf v1alpha1.RemoteVersion) ([]string, error) {
+	packageType := conf.Github.PackageType
+	if packageType == "" {
+		packageType = packageTypeContainer
+	}
+	versions, err := p.getPackageVersions(conf.Repo, packageType)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for _, version := range versions {
+		if packageType == packageTypeContainer {
+			candidates = append(candidates, version.Metadata.Container.Tags...)
+		} else {
+			candidates = append(candidates, version.Name)
+		}
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+}
+
 func (p *Provider) getVersionsFromReleases(conf v1alpha1.RemoteVersion) ([]string, error) {
 	var matchedVersions []string
 	var versions []string
-	releases, err := p.getReleases(conf.Repo)
+	limits, err := newPageLimits(conf)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := p.getReleases(conf.Repo, limits)
 	if err != nil {
 		return nil, err
 	}
@@ -185,10 +446,26 @@ func (p *Provider) getVersionsFromReleases(conf v1alpha1.RemoteVersion) ([]strin
 		if release.GetTagName() == "" {
 			continue
 		}
+		if release.GetPrerelease() && !conf.Github.PrereleasesIncluded() {
+			continue
+		}
+		if release.GetDraft() && !conf.Github.DraftsIncluded() {
+			continue
+		}
 		matched, v := utils.MatchPattern(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, release.GetName())
-		if matched {
-			matchedVersions = append(matchedVersions, v)
+		if !matched {
+			continue
 		}
+		if conf.Github.VerifiedOnly {
+			verified, err := p.isTagVerified(conf.Repo, release.GetTagName())
+			if err != nil {
+				return nil, err
+			}
+			if !verified {
+				continue
+			}
+		}
+		matchedVersions = append(matchedVersions, v)
 	}
 	if conf.Constraint == "" {
 		return matchedVersions, nil
@@ -206,10 +483,25 @@ func (p *Provider) getVersionsFromReleases(conf v1alpha1.RemoteVersion) ([]strin
 	return versions, nil
 }
 
+func (p *Provider) getVersionsFromLatestRelease(conf v1alpha1.RemoteVersion) ([]string, error) {
+	release, err := p.getLatestRelease(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil || release.GetTagName() == "" {
+		return []string{}, nil
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, []string{release.GetName()})
+}
+
 func (p *Provider) getVersionsFromTags(conf v1alpha1.RemoteVersion) ([]string, error) {
 	var matchedVersions []string
 	var versions []string
-	tags, err := p.getTags(conf.Repo)
+	limits, err := newPageLimits(conf)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := p.getTags(conf.Repo, limits)
 	if err != nil {
 		return nil, err
 	}
@@ -218,9 +510,19 @@ func (p *Provider) getVersionsFromTags(conf v1alpha1.RemoteVersion) ([]string, e
 			continue
 		}
 		matched, v := utils.MatchPattern(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, tag.GetName())
-		if matched {
-			matchedVersions = append(matchedVersions, v)
+		if !matched {
+			continue
+		}
+		if conf.Github.VerifiedOnly {
+			verified, err := p.isTagVerified(conf.Repo, tag.GetName())
+			if err != nil {
+				return nil, err
+			}
+			if !verified {
+				continue
+			}
 		}
+		matchedVersions = append(matchedVersions, v)
 	}
 	if conf.Constraint == "" {
 		return matchedVersions, nil
@@ -240,17 +542,28 @@ func (p *Provider) getVersionsFromTags(conf v1alpha1.RemoteVersion) ([]string, e
 
 func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
 	// Check the rate limit and set it as metrics
-	limit, _, err := p.client.RateLimits(p.ctx)
-	if err != nil {
+	if err := p.checkRateLimit(p.clientFor(conf.Repo)); err != nil {
 		return nil, err
 	}
-	p.log.V(1).Info("rate limit", "remaining", limit.Core.Remaining)
-	rateLimitRemaining.Set(float64(limit.Core.Remaining))
 
 	if conf.Strategy == v1alpha1.GithubStrategyReleases {
 		return p.getVersionsFromReleases(conf)
 	} else if conf.Strategy == v1alpha1.GithubStrategyTags {
 		return p.getVersionsFromTags(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyPackages {
+		return p.getVersionsFromPackages(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyLatestRelease {
+		return p.getVersionsFromLatestRelease(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyBranches {
+		return p.getVersionsFromBranches(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyCommits {
+		return p.getVersionsFromCommits(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyFile {
+		return p.getVersionsFromFile(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyAssets {
+		return p.getVersionsFromAssets(conf)
+	} else if conf.Strategy == v1alpha1.GithubStrategyDeployments {
+		return p.getVersionsFromDeployments(conf)
 	}
 	return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
 }
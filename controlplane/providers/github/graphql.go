@@ -0,0 +1,217 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+const releasesQuery = `query($owner: String!, $name: String!, $first: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    releases(first: $first, after: $cursor, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes {
+        name tagName isPrerelease isDraft createdAt publishedAt
+        releaseAssets(first: 100) { nodes { name downloadUrl } }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+const tagsQuery = `query($owner: String!, $name: String!, $first: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    refs(refPrefix: "refs/tags/", first: $first, after: $cursor, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+      nodes {
+        name
+        target {
+          oid
+          ... on Commit { committedDate }
+          ... on Tag { target { oid ... on Commit { committedDate } } }
+        }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type releasesResponse struct {
+	Data struct {
+		Repository *struct {
+			Releases struct {
+				Nodes []struct {
+					Name         string            `json:"name"`
+					TagName      string            `json:"tagName"`
+					IsPrerelease bool              `json:"isPrerelease"`
+					IsDraft      bool              `json:"isDraft"`
+					CreatedAt    *github.Timestamp `json:"createdAt"`
+					PublishedAt  *github.Timestamp `json:"publishedAt"`
+					Assets       struct {
+						Nodes []struct {
+							Name        string `json:"name"`
+							DownloadURL string `json:"downloadUrl"`
+						} `json:"nodes"`
+					} `json:"releaseAssets"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"releases"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type tagsResponse struct {
+	Data struct {
+		Repository *struct {
+			Refs struct {
+				Nodes []struct {
+					Name   string `json:"name"`
+					Target struct {
+						OID           string     `json:"oid"`
+						CommittedDate *time.Time `json:"committedDate"`
+						// commit of an annotated tag, whose own oid is the oid of the tag object
+						Target *struct {
+							OID           string     `json:"oid"`
+							CommittedDate *time.Time `json:"committedDate"`
+						} `json:"target"`
+					} `json:"target"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"refs"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// graphQLEndpoint returns the GraphQL endpoint of the API. It is https://api.github.com/graphql
+// on github.com and https://<host>/api/graphql on Github Enterprise Server
+func graphQLEndpoint(client *github.Client) string {
+	u, _ := client.BaseURL.Parse("../graphql")
+	return u.String()
+}
+
+func graphQLErrors(errs []graphQLError) error {
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	return fmt.Errorf("graphql query failed: %s", strings.Join(messages, ", "))
+}
+
+func (p *Provider) graphQL(c *client, query string, variables map[string]interface{}, v interface{}) error {
+	// the request is created on each attempt since its body is consumed
+	return p.retry(func() error {
+		req, err := c.NewRequest("POST", c.graphQLURL, &graphQLRequest{Query: query, Variables: variables})
+		if err != nil {
+			return err
+		}
+		_, err = c.Do(p.ctx, req, v)
+		return err
+	})
+}
+
+// listReleasesGraphQL gets the releases of the repository with one query per 100 releases within the page limits
+func (p *Provider) listReleasesGraphQL(c *client, owner, name string, limits pageLimits) ([]*github.RepositoryRelease, error) {
+	var releases []*github.RepositoryRelease
+	variables := map[string]interface{}{"owner": owner, "name": name, "first": limits.perPage()}
+	met := false
+	for pages := 1; ; pages++ {
+		var resp releasesResponse
+		if err := p.graphQL(c, releasesQuery, variables, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Errors) > 0 {
+			return nil, graphQLErrors(resp.Errors)
+		}
+		if resp.Data.Repository == nil {
+			return nil, fmt.Errorf("repository %s/%s not found", owner, name)
+		}
+		expired := false
+		var names []string
+		for _, r := range resp.Data.Repository.Releases.Nodes {
+			if r.CreatedAt != nil && limits.expired(r.CreatedAt.Time) {
+				expired = true
+				break
+			}
+			names = append(names, r.Name)
+			release := &github.RepositoryRelease{
+				Name:        github.String(r.Name),
+				TagName:     github.String(r.TagName),
+				Prerelease:  github.Bool(r.IsPrerelease),
+				Draft:       github.Bool(r.IsDraft),
+				CreatedAt:   r.CreatedAt,
+				PublishedAt: r.PublishedAt,
+			}
+			for _, a := range r.Assets.Nodes {
+				release.Assets = append(release.Assets, &github.ReleaseAsset{
+					Name:               github.String(a.Name),
+					BrowserDownloadURL: github.String(a.DownloadURL),
+				})
+			}
+			releases = append(releases, release)
+		}
+		info := resp.Data.Repository.Releases.PageInfo
+		if !info.HasNextPage || expired || limits.done(pages, len(releases)) || limits.pastConstraint(names, &met) {
+			break
+		}
+		variables["cursor"] = info.EndCursor
+	}
+	return releases[:limits.truncate(len(releases))], nil
+}
+
+// listTagsGraphQL gets the tags of the repository with one query per 100 tags within the page limits
+func (p *Provider) listTagsGraphQL(c *client, owner, name string, limits pageLimits) ([]*github.RepositoryTag, error) {
+	var tags []*github.RepositoryTag
+	variables := map[string]interface{}{"owner": owner, "name": name, "first": limits.perPage()}
+	met := false
+	for pages := 1; ; pages++ {
+		var resp tagsResponse
+		if err := p.graphQL(c, tagsQuery, variables, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Errors) > 0 {
+			return nil, graphQLErrors(resp.Errors)
+		}
+		if resp.Data.Repository == nil {
+			return nil, fmt.Errorf("repository %s/%s not found", owner, name)
+		}
+		var names []string
+		for _, t := range resp.Data.Repository.Refs.Nodes {
+			names = append(names, t.Name)
+			// the commit of an annotated tag is peeled, like the commit of the tags of the REST API
+			sha, date := t.Target.OID, t.Target.CommittedDate
+			if t.Target.Target != nil {
+				sha, date = t.Target.Target.OID, t.Target.Target.CommittedDate
+			}
+			commit := &github.Commit{SHA: github.String(sha)}
+			if date != nil {
+				commit.Committer = &github.CommitAuthor{Date: date}
+			}
+			tags = append(tags, &github.RepositoryTag{
+				Name:   github.String(t.Name),
+				Commit: commit,
+			})
+		}
+		info := resp.Data.Repository.Refs.PageInfo
+		if !info.HasNextPage || limits.done(pages, len(tags)) || limits.pastConstraint(names, &met) {
+			break
+		}
+		variables["cursor"] = info.EndCursor
+	}
+	return tags[:limits.truncate(len(tags))], nil
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bradleyfalzon/ghinstallation"
+	"github.com/google/go-github/v39/github"
+)
+
+// discoverable returns true if the credentials are of a Github App without an installation ID,
+// in which case the installations of the app are discovered
+func (c Credentials) discoverable() bool {
+	return c.Token == "" && c.TokenFile == "" && c.TokenURL == "" && c.AppID != 0 && c.AppPrivateKey != "" && c.AppInstallationID == 0
+}
+
+// discoverInstallations returns the installation IDs of the Github App by account (organization or user) login
+func (c *Config) discoverInstallations(ctx context.Context, creds Credentials, base http.RoundTripper) (map[string]int64, error) {
+	var tr *ghinstallation.AppsTransport
+	var err error
+	if _, statErr := os.Stat(creds.AppPrivateKey); statErr == nil {
+		tr, err = ghinstallation.NewAppsTransportKeyFromFile(base, creds.AppID, creds.AppPrivateKey)
+	} else {
+		tr, err = ghinstallation.NewAppsTransport(base, creds.AppID, []byte(creds.AppPrivateKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: app %d: %v", creds.AppID, err)
+	}
+	httpClient := &http.Client{Transport: newMetricsTransport(tr)}
+	appClient := github.NewClient(httpClient)
+	if c.BaseURL != "" {
+		uploadURL := c.UploadURL
+		if uploadURL == "" {
+			uploadURL = c.BaseURL
+		}
+		appClient, err = github.NewEnterpriseClient(c.BaseURL, uploadURL, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid github enterprise url: %v", err)
+		}
+	}
+	installations := map[string]int64{}
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := appClient.Apps.ListInstallations(ctx, opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the installations of app %d: %v", creds.AppID, err)
+		}
+		for _, installation := range page {
+			installations[strings.ToLower(installation.GetAccount().GetLogin())] = installation.GetID()
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return installations, nil
+}
+
+// resolveInstallations fills in the installation IDs of the Github App credentials without one. The installations
+// of the default credentials become credentials by org unless the org already has credentials, and the default
+// credentials become anonymous. The installation of the org of the prefix is used for the prefixed credentials
+func (c *Config) resolveInstallations(ctx context.Context, creds Credentials, credentials map[string]Credentials, base http.RoundTripper) (Credentials, map[string]Credentials, error) {
+	// installations are listed once per app
+	discovered := map[int64]map[string]int64{}
+	discover := func(creds Credentials) (map[string]int64, error) {
+		if installations, ok := discovered[creds.AppID]; ok {
+			return installations, nil
+		}
+		installations, err := c.discoverInstallations(ctx, creds, base)
+		if err != nil {
+			return nil, err
+		}
+		discovered[creds.AppID] = installations
+		return installations, nil
+	}
+	resolved := map[string]Credentials{}
+	for prefix, prefixCreds := range credentials {
+		if prefixCreds.discoverable() {
+			installations, err := discover(prefixCreds)
+			if err != nil {
+				return creds, nil, err
+			}
+			org := strings.ToLower(strings.SplitN(prefix, "/", 2)[0])
+			id, ok := installations[org]
+			if !ok {
+				return creds, nil, fmt.Errorf("app %d is not installed on %s", prefixCreds.AppID, org)
+			}
+			prefixCreds.AppInstallationID = id
+		}
+		resolved[prefix] = prefixCreds
+	}
+	if !creds.discoverable() {
+		return creds, resolved, nil
+	}
+	installations, err := discover(creds)
+	if err != nil {
+		return creds, nil, err
+	}
+	for org, id := range installations {
+		if _, ok := resolved[org]; ok {
+			continue
+		}
+		orgCreds := creds
+		orgCreds.AppInstallationID = id
+		resolved[org] = orgCreds
+	}
+	return Credentials{}, resolved, nil
+}
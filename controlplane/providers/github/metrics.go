@@ -0,0 +1,94 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "opvic_provider_github",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests to the Github API by endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"endpoint"},
+	)
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "opvic_provider_github",
+			Name:      "requests_total",
+			Help:      "The number of requests to the Github API by endpoint and status code.",
+		},
+		[]string{"endpoint", "status"},
+	)
+	cacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "opvic_provider_github",
+			Name:      "cache_requests_total",
+			Help:      "The number of cache lookups by repo and result (hit or miss).",
+		},
+		[]string{"repo", "result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, requestsTotal, cacheRequestsTotal)
+}
+
+// metricsTransport records the duration and the status code of the requests to the Github API
+type metricsTransport struct {
+	transport http.RoundTripper
+}
+
+func newMetricsTransport(transport http.RoundTripper) *metricsTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &metricsTransport{transport: transport}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := endpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	requestDuration.WithLabelValues(e).Observe(time.Since(start).Seconds())
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestsTotal.WithLabelValues(e, status).Inc()
+	return resp, err
+}
+
+// endpoint returns the path of the request without the owner, the repo and the IDs to keep the
+// cardinality of the metrics low (e.g. /repos/{owner}/{repo}/releases)
+func endpoint(path string) string {
+	// Github Enterprise Server API prefixes
+	path = strings.TrimPrefix(path, "/api/v3")
+	path = strings.TrimPrefix(path, "/api")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch segments[0] {
+	case "repos":
+		segments = append([]string{"repos", "{owner}", "{repo}"}, segments[min(3, len(segments)):]...)
+		segments = segments[:min(4, len(segments))]
+	case "orgs", "users":
+		segments = append([]string{segments[0], "{owner}"}, segments[min(2, len(segments)):]...)
+		segments = segments[:min(3, len(segments))]
+	default:
+		segments = segments[:min(2, len(segments))]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
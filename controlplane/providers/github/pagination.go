@@ -0,0 +1,136 @@
+package github
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const maxPerPage = 100
+
+// pageLimits bounds the number of requests made to list the releases and tags of a repo
+type pageLimits struct {
+	maxPages int
+	maxItems int
+	// releases created before the cutoff are dropped and the pagination stops at the first one
+	cutoff time.Time
+	// the pagination stops once a page has no version meeting the constraint after pages that had some
+	constraint string
+	pattern    string
+	result     string
+}
+
+func newPageLimits(conf v1alpha1.RemoteVersion) (pageLimits, error) {
+	limits := pageLimits{
+		maxPages: conf.Github.MaxPages,
+		maxItems: conf.Github.MaxItems,
+	}
+	if conf.Github.Cutoff != "" {
+		var err error
+		limits.cutoff, err = parseCutoff(conf.Github.Cutoff)
+		if err != nil {
+			return limits, err
+		}
+	}
+	// the versions are extracted from the release and tag names in these strategies only
+	if conf.Strategy == v1alpha1.GithubStrategyReleases || conf.Strategy == v1alpha1.GithubStrategyTags {
+		limits.constraint = conf.Constraint
+		limits.pattern = conf.Extraction.Regex.Pattern
+		limits.result = conf.Extraction.Regex.Result
+	}
+	return limits, nil
+}
+
+// parseCutoff parses a date in the format of YYYY-MM-DD or RFC3339
+func parseCutoff(cutoff string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", cutoff); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, cutoff)
+	if err != nil {
+		return t, fmt.Errorf("invalid cutoff %s. it must be in the format of YYYY-MM-DD or RFC3339", cutoff)
+	}
+	return t, nil
+}
+
+// key returns the suffix of the cache keys so the results of different limits are cached separately
+func (l pageLimits) key() string {
+	if l.maxPages == 0 && l.maxItems == 0 && l.cutoff.IsZero() && l.constraint == "" {
+		return ""
+	}
+	return fmt.Sprintf("/%d/%d/%d/%s/%s/%s", l.maxPages, l.maxItems, l.cutoff.Unix(), l.constraint, l.pattern, l.result)
+}
+
+// perPage returns the page size. Smaller pages are requested when fewer items are needed
+func (l pageLimits) perPage() int {
+	if l.maxItems > 0 && l.maxItems < maxPerPage {
+		return l.maxItems
+	}
+	return maxPerPage
+}
+
+// done returns true if no more pages should be requested after the given number of pages and items
+func (l pageLimits) done(pages, items int) bool {
+	return (l.maxPages > 0 && pages >= l.maxPages) || (l.maxItems > 0 && items >= l.maxItems)
+}
+
+// truncate returns the number of items to keep out of n
+func (l pageLimits) truncate(n int) int {
+	if l.maxItems > 0 && n > l.maxItems {
+		return l.maxItems
+	}
+	return n
+}
+
+// expired returns true if an item created at t is older than the cutoff
+func (l pageLimits) expired(t time.Time) bool {
+	return !l.cutoff.IsZero() && t.Before(l.cutoff)
+}
+
+// pastConstraint returns true if none of the versions extracted from the names of a page meet the constraint
+// while versions of the previous pages did. It only applies to lists returned newest first (the releases and the
+// tags of the GraphQL API) so the following pages only have older versions. met records whether a page had versions
+// meeting the constraint
+func (l pageLimits) pastConstraint(names []string, met *bool) bool {
+	if l.constraint == "" {
+		return false
+	}
+	matched := false
+	for _, name := range names {
+		ok, v := utils.MatchPattern(l.pattern, l.result, name)
+		if !ok {
+			continue
+		}
+		matched = true
+		if meet, err := utils.MeetConstraint(l.constraint, v); err == nil && meet {
+			*met = true
+			return false
+		}
+	}
+	return matched && *met
+}
+
+// sortNewestFirst sorts the names from the highest version to the lowest, with the versions extracted with the
+// pattern. The names without a version keep their order after the versions
+func (l pageLimits) sortNewestFirst(names []string) []int {
+	versions := make([]*version.Version, len(names))
+	order := make([]int, len(names))
+	for i, name := range names {
+		order[i] = i
+		if ok, v := utils.MatchPattern(l.pattern, l.result, name); ok {
+			versions[i], _ = version.NewVersion(v)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := versions[order[a]], versions[order[b]]
+		if va == nil || vb == nil {
+			return va != nil
+		}
+		return va.GreaterThan(vb)
+	})
+	return order
+}
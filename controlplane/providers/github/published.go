@@ -0,0 +1,107 @@
+package github
+
+import (
+	"time"
+
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// GetPublishedVersions returns the versions with the date they were published. The dates are known for the
+// releases, latestRelease, assets and commits strategies, and for the tags strategy with the GraphQL API only
+// since the REST API doesn't return the dates of the tags
+func (p *Provider) GetPublishedVersions(conf v1alpha1.RemoteVersion) ([]utils.PublishedVersion, error) {
+	versions, err := p.GetVersions(conf)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := p.getPublishDates(conf)
+	if err != nil {
+		return nil, err
+	}
+	published := make([]utils.PublishedVersion, 0, len(versions))
+	for _, v := range versions {
+		published = append(published, utils.PublishedVersion{Version: v, PublishedAt: dates[v]})
+	}
+	return published, nil
+}
+
+// getPublishDates returns the publish dates by version from the cached releases, tags or commits
+func (p *Provider) getPublishDates(conf v1alpha1.RemoteVersion) (map[string]time.Time, error) {
+	dates := map[string]time.Time{}
+	add := func(candidate string, date time.Time) {
+		if candidate == "" || date.IsZero() {
+			return
+		}
+		matched, v := utils.MatchPattern(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, candidate)
+		// the first match is the most recent one
+		if _, ok := dates[v]; matched && !ok {
+			dates[v] = date
+		}
+	}
+	switch conf.Strategy {
+	case v1alpha1.GithubStrategyReleases, v1alpha1.GithubStrategyAssets:
+		limits, err := newPageLimits(conf)
+		if err != nil {
+			return nil, err
+		}
+		releases, err := p.getReleases(conf.Repo, limits)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			date := release.GetPublishedAt().Time
+			if date.IsZero() {
+				// drafts are not published
+				date = release.GetCreatedAt().Time
+			}
+			if conf.Strategy == v1alpha1.GithubStrategyReleases {
+				add(release.GetName(), date)
+				continue
+			}
+			for _, asset := range release.Assets {
+				add(asset.GetName(), date)
+			}
+		}
+	case v1alpha1.GithubStrategyLatestRelease:
+		release, err := p.getLatestRelease(conf.Repo)
+		if err != nil {
+			return nil, err
+		}
+		if release != nil {
+			add(release.GetName(), release.GetPublishedAt().Time)
+		}
+	case v1alpha1.GithubStrategyTags:
+		limits, err := newPageLimits(conf)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := p.getTags(conf.Repo, limits)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			add(tag.GetName(), tag.GetCommit().GetCommitter().GetDate())
+		}
+	case v1alpha1.GithubStrategyCommits:
+		commits, err := p.getCommits(conf.Repo, conf.Github.Branch)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			date := commit.GetCommit().GetCommitter().GetDate()
+			if conf.Github.CommitFormat == commitFormatSHA {
+				sha := commit.GetSHA()
+				if len(sha) > shortSHALength {
+					sha = sha[:shortSHALength]
+				}
+				add(sha, date)
+				continue
+			}
+			if !date.IsZero() {
+				add(date.UTC().Format(commitDateFormat), date)
+			}
+		}
+	}
+	return dates, nil
+}
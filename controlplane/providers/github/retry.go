@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultMaxWait      = time.Minute
+	initialRetryBackoff = time.Second
+	// Github recommends to wait at least one minute when a secondary rate limit is hit without a Retry-After
+	defaultAbuseRetryAfter = time.Minute
+)
+
+// retryAfter returns how long to wait before retrying the request that failed with err
+// and false if the request should not be retried
+func retryAfter(err error, backoff time.Duration) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		// the quota is exhausted until the reset time of the rate limit window
+		if wait := time.Until(rateLimitErr.Rate.Reset.Time); wait > 0 {
+			return wait + time.Second, true
+		}
+		return backoff, true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return defaultAbuseRetryAfter, true
+	}
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		if d, ok := retryAfterHeader(errResp.Response); ok {
+			return d, true
+		}
+		if errResp.Response.StatusCode >= http.StatusInternalServerError {
+			return backoff, true
+		}
+		return 0, false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	// network errors are transient
+	return backoff, true
+}
+
+// retryAfterHeader parses the Retry-After header of the response in seconds
+func retryAfterHeader(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// retry calls fn until it succeeds or the retries are exhausted. It waits for the rate limit window
+// to reset when the quota is exhausted and backs off exponentially on transient errors.
+// The total time spent waiting is bounded by the max wait of the provider
+func (p *Provider) retry(fn func() error) error {
+	backoff := initialRetryBackoff
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		wait, ok := retryAfter(err, backoff)
+		if !ok || attempt >= p.maxRetries || waited+wait > p.maxWait {
+			return err
+		}
+		p.log.Info("github request failed. retrying", "error", err.Error(), "attempt", attempt+1, "wait", wait.String())
+		select {
+		case <-time.After(wait):
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		}
+		waited += wait
+		backoff *= 2
+	}
+}
+
+// serialTransport sends the requests of a client one at a time so bursts of concurrent requests don't
+// trigger the secondary rate limits. When a secondary rate limit is hit, the following requests are
+// held until the advised delay has passed
+type serialTransport struct {
+	transport http.RoundTripper
+	mutex     sync.Mutex
+	until     time.Time
+}
+
+func newSerialTransport(transport http.RoundTripper) *serialTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &serialTransport{transport: transport}
+}
+
+func (t *serialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if wait := time.Until(t.until); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		// primary rate limit errors have no Retry-After and are handled by the client
+		if d, ok := retryAfterHeader(resp); ok {
+			t.until = time.Now().Add(d)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,125 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// tokens are refreshed this long before they expire
+	tokenExpiryDelta = time.Minute
+	// environment variables of the Github Actions runners to request an OIDC token
+	actionsIDTokenRequestURL   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	actionsIDTokenRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// externalTokenSource gets short-lived Github tokens from an external token endpoint (e.g. a token broker exchanging
+// OIDC tokens for Github App installation tokens). An OIDC token identifying the control plane is sent as a bearer
+// token if one is available from a file (e.g. a projected service account token) or from the Github Actions runner
+type externalTokenSource struct {
+	tokenURL    string
+	idTokenFile string
+	audience    string
+	client      *http.Client
+}
+
+// tokenResponse is the response of the token endpoint. It is the format of the Github installation tokens
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newExternalTokenSource(creds Credentials, base http.RoundTripper) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &externalTokenSource{
+		tokenURL:    creds.TokenURL,
+		idTokenFile: creds.IDTokenFile,
+		audience:    creds.Audience,
+		client:      &http.Client{Transport: base, Timeout: 30 * time.Second},
+	})
+}
+
+// Token gets a new token from the token endpoint. It is called by the reuse token source when the previous token
+// is about to expire
+func (s *externalTokenSource) Token() (*oauth2.Token, error) {
+	idToken, err := s.idToken()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if idToken != "" {
+		req.Header.Set("Authorization", "Bearer "+idToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	var resp tokenResponse
+	if err := s.do(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get a token from %s: %v", s.tokenURL, err)
+	}
+	if resp.Token == "" {
+		return nil, fmt.Errorf("failed to get a token from %s: empty token", s.tokenURL)
+	}
+	token := &oauth2.Token{AccessToken: resp.Token}
+	if !resp.ExpiresAt.IsZero() {
+		token.Expiry = resp.ExpiresAt.Add(-tokenExpiryDelta)
+	}
+	return token, nil
+}
+
+// idToken returns the OIDC token from the file or the Github Actions runner, and an empty string if there is none
+func (s *externalTokenSource) idToken() (string, error) {
+	if s.idTokenFile != "" {
+		// read on every refresh since projected tokens are rotated
+		data, err := ioutil.ReadFile(s.idTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read id token file %s: %v", s.idTokenFile, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	requestURL := os.Getenv(actionsIDTokenRequestURL)
+	if requestURL == "" {
+		return "", nil
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %v", actionsIDTokenRequestURL, err)
+	}
+	if s.audience != "" {
+		q := u.Query()
+		q.Set("audience", s.audience)
+		u.RawQuery = q.Encode()
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv(actionsIDTokenRequestToken))
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := s.do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get an id token from the github actions runner: %v", err)
+	}
+	return resp.Value, nil
+}
+
+func (s *externalTokenSource) do(req *http.Request, v interface{}) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
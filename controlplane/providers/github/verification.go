@@ -0,0 +1,74 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func verificationCacheKey(repo, tag string) string {
+	return fmt.Sprintf("github/%s/verification/%s", repo, tag)
+}
+
+// isTagVerified returns true if the signature of the tag is verified by Github. The signature of the tag object
+// is checked for annotated tags and the signature of the commit otherwise or if the tag object isn't signed.
+// Verifications are cached per tag since every tag requires up to 3 requests. A tag that doesn't exist, like the tag
+// of a draft release, is not verified
+func (p *Provider) isTagVerified(repo, tag string) (bool, error) {
+	log := p.log.WithValues("repo", repo, "tag", tag)
+	if v, ok := p.getCacheValue(repo, verificationCacheKey(repo, tag)); ok {
+		log.V(1).Info("found tag verification in cache")
+		return v.(bool), nil
+	}
+	log.V(1).Info("getting tag verification")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	c := p.clientFor(repo)
+	var ref *github.Reference
+	err = p.retry(func() (err error) {
+		ref, _, err = c.Git.GetRef(p.ctx, owner, name, "tags/"+tag)
+		return err
+	})
+	if errResp, ok := err.(*github.ErrorResponse); ok && errResp.Response.StatusCode == http.StatusNotFound {
+		log.V(1).Info("tag not found")
+		p.setCacheValue(repo, verificationCacheKey(repo, tag), false)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	verified := false
+	sha := ref.GetObject().GetSHA()
+	if ref.GetObject().GetType() == "tag" {
+		err = p.retry(func() error {
+			t, _, err := c.Git.GetTag(p.ctx, owner, name, sha)
+			if err != nil {
+				return err
+			}
+			verified = t.GetVerification().GetVerified()
+			sha = t.GetObject().GetSHA()
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if !verified {
+		err = p.retry(func() error {
+			commit, _, err := c.Git.GetCommit(p.ctx, owner, name, sha)
+			if err != nil {
+				return err
+			}
+			verified = commit.GetVerification().GetVerified()
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	p.setCacheValue(repo, verificationCacheKey(repo, tag), verified)
+	return verified, nil
+}
@@ -0,0 +1,198 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultBaseURL = "https://gitlab.com"
+
+// Config contains configuration for Gitlab provider
+type Config struct {
+	// Base URL of the Gitlab instance (e.g. https://gitlab.example.com)
+	BaseURL string
+	Token   string
+}
+
+// Provider is a gitlab provider for getting remote versions from Gitlab
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+type Release struct {
+	Name    string `json:"name"`
+	TagName string `json:"tag_name"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if c.Token == "" {
+		logger.V(1).Info("no authentication provided. private projects will not be accessible.")
+	}
+	return &Provider{
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   c.Token,
+		cache:   cache,
+		log:     logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func releasesCacheKey(repo string) string {
+	return fmt.Sprintf("gitlab/%s/releases", repo)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("gitlab/%s/tags", repo)
+}
+
+// projectURL returns the API url of a project resource. Project path is url encoded
+// as gitlab expects it to be (e.g. group/subgroup/project -> group%2Fsubgroup%2Fproject)
+func (p *Provider) projectURL(repo, resource string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s/%s", p.baseURL, url.PathEscape(repo), resource)
+}
+
+// list gets all the pages of a project resource and decodes each page with the decode function
+func (p *Provider) list(repo, resource string, decode func(*json.Decoder) error) error {
+	page := "1"
+	for page != "" {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?per_page=100&page=%s", p.projectURL(repo, resource), page), nil)
+		if err != nil {
+			return err
+		}
+		if p.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", p.token)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		err = decode(json.NewDecoder(resp.Body))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return nil
+}
+
+func (p *Provider) getReleases(repo string) ([]Release, error) {
+	log := p.log.WithValues("repo", repo)
+	var releases []Release
+	if r, ok := p.getCacheValue(releasesCacheKey(repo)); !ok {
+		log.V(1).Info("getting releases")
+		err := p.list(repo, "releases", func(d *json.Decoder) error {
+			var releasesPage []Release
+			if err := d.Decode(&releasesPage); err != nil {
+				return err
+			}
+			releases = append(releases, releasesPage...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.setCacheValue(releasesCacheKey(repo), releases)
+	} else {
+		log.V(1).Info("found releases in cache")
+		releases = r.([]Release)
+	}
+	return releases, nil
+}
+
+func (p *Provider) getTags(repo string) ([]Tag, error) {
+	log := p.log.WithValues("repo", repo)
+	var tags []Tag
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); !ok {
+		log.V(1).Info("getting tags")
+		err := p.list(repo, "repository/tags", func(d *json.Decoder) error {
+			var tagsPage []Tag
+			if err := d.Decode(&tagsPage); err != nil {
+				return err
+			}
+			tags = append(tags, tagsPage...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.setCacheValue(tagsCacheKey(repo), tags)
+	} else {
+		log.V(1).Info("found tags in cache")
+		tags = t.([]Tag)
+	}
+	return tags, nil
+}
+
+func (p *Provider) getVersionsFromReleases(conf v1alpha1.RemoteVersion) ([]string, error) {
+	releases, err := p.getReleases(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, release := range releases {
+		if release.TagName == "" {
+			continue
+		}
+		name := release.Name
+		if name == "" {
+			name = release.TagName
+		}
+		names = append(names, name)
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, names)
+}
+
+func (p *Provider) getVersionsFromTags(conf v1alpha1.RemoteVersion) ([]string, error) {
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, names)
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy == v1alpha1.GitlabStrategyReleases {
+		return p.getVersionsFromReleases(conf)
+	} else if conf.Strategy == v1alpha1.GitlabStrategyTags {
+		return p.getVersionsFromTags(conf)
+	}
+	return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+}
@@ -0,0 +1,158 @@
+package gke
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	apiURL = "https://container.googleapis.com/v1"
+	scope  = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// Config contains configuration for GKE provider
+type Config struct {
+	// Path to a service account key file. If empty, the application default
+	// credentials are used (e.g. GKE workload identity or GOOGLE_APPLICATION_CREDENTIALS)
+	CredentialsFile string
+}
+
+// Provider is a GKE provider for getting the available GKE versions of each release channel
+type Provider struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+// ServerConfig is the Kubernetes Engine service configuration of a location
+type ServerConfig struct {
+	DefaultClusterVersion string   `json:"defaultClusterVersion"`
+	ValidMasterVersions   []string `json:"validMasterVersions"`
+	ValidNodeVersions     []string `json:"validNodeVersions"`
+	Channels              []struct {
+		Channel        string   `json:"channel"`
+		DefaultVersion string   `json:"defaultVersion"`
+		ValidVersions  []string `json:"validVersions"`
+	} `json:"channels"`
+}
+
+func (c *Config) NewProvider(ctx context.Context, cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	// the tokens are also requested through the transport of the providers
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: 30 * time.Second, Transport: tr})
+	var creds *google.Credentials
+	if c.CredentialsFile != "" {
+		data, err := ioutil.ReadFile(c.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: reading credentials file %s: %v", c.CredentialsFile, err)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, scope)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: using credentials file %s: %v", c.CredentialsFile, err)
+		}
+	} else {
+		var err error
+		creds, err = google.FindDefaultCredentials(ctx, scope)
+		if err != nil {
+			logger.V(1).Info("no google credentials found. gke provider will not be able to authenticate", "error", err.Error())
+		}
+	}
+	p := &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		cache:  cache,
+		log:    logger,
+	}
+	if creds != nil {
+		p.client = oauth2.NewClient(ctx, creds.TokenSource)
+		p.client.Timeout = 30 * time.Second
+	}
+	return p, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func serverConfigCacheKey(repo string) string {
+	return fmt.Sprintf("gke/%s/serverconfig", repo)
+}
+
+// getServerConfig gets the server config of the location. It is cached under the repo so it is dropped with the repo
+func (p *Provider) getServerConfig(repo, project, location string) (*ServerConfig, error) {
+	log := p.log.WithValues("project", project, "location", location)
+	if c, ok := p.getCacheValue(serverConfigCacheKey(repo)); ok {
+		log.V(1).Info("found server config in cache")
+		return c.(*ServerConfig), nil
+	}
+	log.V(1).Info("getting server config")
+	resp, err := p.client.Get(fmt.Sprintf("%s/projects/%s/locations/%s/serverConfig", apiURL, project, location))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	config := &ServerConfig{}
+	if err := json.NewDecoder(resp.Body).Decode(config); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(serverConfigCacheKey(repo), config)
+	return config, nil
+}
+
+// getVersions gets the versions of the release channel. repo is in the format of project/location/channel
+// (e.g. my-project/us-central1/REGULAR). Without a channel, the valid master versions of the location
+// are returned for the channels strategy and the default cluster version for the default strategy
+func (p *Provider) getVersions(repo string, strategy v1alpha1.RemoteStrategy) ([]string, error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: project/location[/channel]", repo)
+	}
+	config, err := p.getServerConfig(repo, parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 2 {
+		if strategy == v1alpha1.GKEStrategyDefault {
+			return []string{config.DefaultClusterVersion}, nil
+		}
+		return config.ValidMasterVersions, nil
+	}
+	for _, c := range config.Channels {
+		if !strings.EqualFold(c.Channel, parts[2]) {
+			continue
+		}
+		if strategy == v1alpha1.GKEStrategyDefault {
+			return []string{c.DefaultVersion}, nil
+		}
+		return c.ValidVersions, nil
+	}
+	return nil, fmt.Errorf("release channel %s not found in %s", parts[2], parts[1])
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.GKEStrategyChannels && conf.Strategy != v1alpha1.GKEStrategyDefault {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo, conf.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
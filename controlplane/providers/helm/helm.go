@@ -1,19 +1,25 @@
 package helm
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/patrickmn/go-cache"
 	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers/oci"
 	"github.com/skillz/opvic/utils"
 	"gopkg.in/yaml.v2"
 )
 
-const indexPath string = "index.yaml"
+const (
+	indexPath string = "index.yaml"
+	ociScheme string = "oci://"
+)
 
 type ChartVersion struct {
 	Version    string `yaml:"version"`
@@ -25,18 +31,101 @@ type Index struct {
 	Entries    map[string][]*ChartVersion
 }
 
+// Config contains configuration for Helm provider
+type Config struct {
+	// Base URL of the private chart repository (e.g. https://charts.example.com)
+	RepositoryURL string
+	// Basic authentication credentials for the private chart repository. They are only sent to the host of the
+	// repository URL
+	Username string
+	Password string
+	// Path to the CA bundle to verify the certificate of the private chart repository, in addition to the system roots
+	CAFile string
+	// Path to the client certificate and key for TLS client authentication to the private chart repository
+	CertFile string
+	KeyFile  string
+	// Skip the TLS verification of the private chart repository
+	InsecureSkipTLSVerify bool
+	// Registry configuration for OCI based chart repositories (e.g. oci://ghcr.io/owner/charts)
+	Registry *oci.Config
+}
+
 type Provider struct {
-	client *http.Client
-	cache  *cache.Cache
-	log    logr.Logger
+	client        *http.Client
+	registry      *oci.Client
+	repositoryURL string
+	username      string
+	password      string
+	cache         *cache.Cache
+	log           logr.Logger
 }
 
-func NewProvider(cache *cache.Cache, logger logr.Logger) *Provider {
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	// the chart repositories are reached through the proxy of the providers and trust its CA. The TLS options
+	// only apply to the host of the repository URL
+	var transport http.RoundTripper = tr
+	if c.CAFile != "" || c.CertFile != "" || c.InsecureSkipTLSVerify {
+		if c.RepositoryURL == "" {
+			return nil, fmt.Errorf("the TLS options of the helm provider require a repository URL")
+		}
+		tlsConfig := &tls.Config{}
+		if tr.TLSClientConfig != nil {
+			tlsConfig = tr.TLSClientConfig.Clone()
+		}
+		tlsConfig.InsecureSkipVerify = c.InsecureSkipTLSVerify
+		if c.CAFile != "" {
+			pool, err := utils.LoadCertPool(c.CAFile, true)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if c.CertFile != "" && c.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		repository := tr.Clone()
+		repository.TLSClientConfig = tlsConfig
+		transport = &repositoryTransport{
+			repositoryURL: c.RepositoryURL,
+			repository:    repository,
+			base:          tr,
+		}
+	}
+	registry := &oci.Config{}
+	if c.Registry != nil {
+		registry = c.Registry
+	}
 	return &Provider{
-		client: &http.Client{Timeout: 30 * time.Second},
-		cache:  cache,
-		log:    logger,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		registry:      registry.NewClient(tr),
+		repositoryURL: c.RepositoryURL,
+		username:      c.Username,
+		password:      c.Password,
+		cache:         cache,
+		log:           logger,
+	}, nil
+}
+
+// repositoryTransport sends the requests to the host of the repository URL with the TLS options of the repository
+// and the other requests, e.g. to the public chart repositories, with the transport of the providers
+type repositoryTransport struct {
+	repositoryURL string
+	repository    http.RoundTripper
+	base          http.RoundTripper
+}
+
+func (t *repositoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if utils.SameHost(t.repositoryURL, req.URL) {
+		return t.repository.RoundTrip(req)
 	}
+	return t.base.RoundTrip(req)
 }
 
 func (p *Provider) GetCacheValue(key string) (interface{}, bool) {
@@ -48,8 +137,7 @@ func (p *Provider) SetCacheValue(key string, value interface{}) {
 }
 
 func ReleasesCacheKey(repo string) string {
-	// drop the https://
-	return fmt.Sprintf("helm/%s", repo[8:])
+	return fmt.Sprintf("helm/%s/index", repo)
 }
 
 func AppendIndex(repo string) string {
@@ -64,12 +152,21 @@ func (p *Provider) GetIndex(repo string) (*Index, error) {
 		return indexCache.(*Index), nil
 	}
 	log.V(1).Info("getting index from remote")
-	url := AppendIndex(repo)
-	resp, err := p.client.Get(url)
+	req, err := http.NewRequest("GET", AppendIndex(repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.username != "" && p.password != "" && utils.SameHost(p.repositoryURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -93,7 +190,43 @@ func LoadIndex(data []byte) (*Index, error) {
 	return i, nil
 }
 
+func OCITagsCacheKey(repo, chart string) string {
+	return fmt.Sprintf("helm/%s/%s/tags", repo, chart)
+}
+
+// GetOCIChartVersions lists the chart versions from the tags of an OCI based chart repository
+func (p *Provider) GetOCIChartVersions(repo, chart string) ([]string, error) {
+	log := p.log.WithValues("repo", repo, "chart", chart)
+	if tags, ok := p.GetCacheValue(OCITagsCacheKey(repo, chart)); ok {
+		log.V(1).Info("found chart versions in cache")
+		return tags.([]string), nil
+	}
+	log.V(1).Info("getting chart versions from registry")
+	registry, name := oci.ParseRepo(fmt.Sprintf("%s/%s", strings.TrimSuffix(strings.TrimPrefix(repo, ociScheme), "/"), chart))
+	tags, err := p.registry.ListTags(registry, name)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, len(tags))
+	for i, tag := range tags {
+		// helm replaces + with _ in the tags since + is not allowed in OCI tags
+		versions[i] = strings.ReplaceAll(tag, "_", "+")
+	}
+	p.SetCacheValue(OCITagsCacheKey(repo, chart), versions)
+	return versions, nil
+}
+
 func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if strings.HasPrefix(conf.Repo, ociScheme) {
+		if conf.Strategy != v1alpha1.HelmStrategyChartVersion {
+			return nil, fmt.Errorf("strategy %s is not supported for OCI chart repositories", conf.Strategy)
+		}
+		versions, err := p.GetOCIChartVersions(conf.Repo, conf.Chart)
+		if err != nil {
+			return nil, err
+		}
+		return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+	}
 	var matchedVersions []string
 	var versions []string
 	index, err := p.GetIndex(conf.Repo)
@@ -0,0 +1,231 @@
+package html
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v2"
+)
+
+// Config contains configuration for HTML provider
+type Config struct {
+	// Path to a yaml file with the headers and credentials to use per target
+	TargetsFile string
+}
+
+// Target contains the headers and credentials to use for the pages with the URL prefix. The pages must have the
+// scheme and the host of the URL, and a path under its path
+type Target struct {
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password"`
+	Token    string            `yaml:"token"`
+}
+
+// Provider is an HTML provider for getting remote versions by scraping web pages
+type Provider struct {
+	client  *http.Client
+	targets []Target
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	var targets []Target
+	if c.TargetsFile != "" {
+		b, err := ioutil.ReadFile(c.TargetsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the targets file: %v", err)
+		}
+		if err := yaml.Unmarshal(b, &targets); err != nil {
+			return nil, fmt.Errorf("failed to parse the targets file: %v", err)
+		}
+		for i := range targets {
+			if u, err := url.Parse(targets[i].URL); err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, fmt.Errorf("invalid URL %q of target %d in the targets file", targets[i].URL, i)
+			}
+			targets[i].Password = os.ExpandEnv(targets[i].Password)
+			targets[i].Token = os.ExpandEnv(targets[i].Token)
+		}
+	}
+	return &Provider{
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		targets: targets,
+		cache:   cache,
+		log:     logger,
+	}, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func pageCacheKey(repo string) string {
+	return fmt.Sprintf("html/%s/page", repo)
+}
+
+// target returns the target with the longest URL prefix matching the page URL
+func (p *Provider) target(u string) *Target {
+	page, err := url.Parse(u)
+	if err != nil {
+		return nil
+	}
+	var match *Target
+	for i, t := range p.targets {
+		if t.matches(page) && (match == nil || len(t.URL) > len(match.URL)) {
+			match = &p.targets[i]
+		}
+	}
+	return match
+}
+
+// matches returns true if the page has the scheme and the host of the target, and its path is the path of the
+// target or under it
+func (t Target) matches(page *url.URL) bool {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, page.Scheme) || !strings.EqualFold(u.Host, page.Host) {
+		return false
+	}
+	prefix := strings.TrimSuffix(u.Path, "/")
+	return page.Path == prefix || strings.HasPrefix(page.Path, prefix+"/")
+}
+
+func (p *Provider) getPage(repo string) (string, error) {
+	log := p.log.WithValues("repo", repo)
+	if page, ok := p.getCacheValue(pageCacheKey(repo)); ok {
+		log.V(1).Info("found page in cache")
+		return page.(string), nil
+	}
+	log.V(1).Info("getting page")
+	req, err := http.NewRequest("GET", repo, nil)
+	if err != nil {
+		return "", err
+	}
+	if t := p.target(repo); t != nil {
+		for k, v := range t.Headers {
+			req.Header.Set(k, v)
+		}
+		if t.Username != "" && t.Password != "" {
+			req.SetBasicAuth(t.Username, t.Password)
+		}
+		if t.Token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.Token))
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	page := string(b)
+	p.setCacheValue(pageCacheKey(repo), page)
+	return page, nil
+}
+
+// regexCandidates returns the result of every match of the pattern in the page
+func regexCandidates(pattern, tmpl, page string) ([]string, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("extraction regex pattern is required for the regex strategy")
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == "" {
+		tmpl = "$0"
+	}
+	var candidates []string
+	for _, m := range regex.FindAllStringSubmatchIndex(page, -1) {
+		candidates = append(candidates, string(regex.ExpandString([]byte{}, tmpl, page, m)))
+	}
+	return utils.RemoveDuplicateStr(candidates), nil
+}
+
+// selectorCandidates returns the text (or the attribute) of the elements matching the selector
+func selectorCandidates(selector, attribute, page string) ([]string, error) {
+	if selector == "" {
+		return nil, fmt.Errorf("html selector is required for the selector strategy")
+	}
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for _, n := range sel.MatchAll(doc) {
+		if attribute == "" {
+			candidates = append(candidates, strings.TrimSpace(text(n)))
+			continue
+		}
+		for _, a := range n.Attr {
+			if a.Key == attribute {
+				candidates = append(candidates, strings.TrimSpace(a.Val))
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(text(c))
+	}
+	return sb.String()
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	page, err := p.getPage(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Strategy {
+	case v1alpha1.HTMLStrategyRegex:
+		candidates, err := regexCandidates(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, page)
+		if err != nil {
+			return nil, err
+		}
+		return utils.FilterVersions("", "", conf.Constraint, candidates)
+	case v1alpha1.HTMLStrategySelector:
+		candidates, err := selectorCandidates(conf.HTML.Selector, conf.HTML.Attribute, page)
+		if err != nil {
+			return nil, err
+		}
+		return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, candidates)
+	default:
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+}
@@ -0,0 +1,135 @@
+package kubernetes
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-version"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	DefaultReleaseURL = "https://dl.k8s.io/release"
+	DefaultMinors     = 4
+)
+
+// Config contains configuration for Kubernetes provider
+type Config struct {
+	// Number of minor releases to resolve the patch releases for (counting back from the latest stable minor)
+	Minors int
+}
+
+// Provider is a Kubernetes provider for getting the upstream Kubernetes patch releases of each minor release
+type Provider struct {
+	client *http.Client
+	minors int
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	minors := c.Minors
+	if minors <= 0 {
+		minors = DefaultMinors
+	}
+	return &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		minors: minors,
+		cache:  cache,
+		log:    logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func markerCacheKey(repo, marker string) string {
+	return fmt.Sprintf("kubernetes/%s/markers/%s", repo, marker)
+}
+
+// getMarker gets the version of a release marker file (e.g. stable.txt or latest-1.22.txt).
+// An empty version is returned if the marker does not exist. The markers are cached under the repo so they are
+// dropped with the repo
+func (p *Provider) getMarker(repo, releaseURL, marker string) (string, error) {
+	log := p.log.WithValues("marker", marker)
+	if v, ok := p.getCacheValue(markerCacheKey(repo, marker)); ok {
+		log.V(1).Info("found release marker in cache")
+		return v.(string), nil
+	}
+	log.V(1).Info("getting release marker")
+	resp, err := p.client.Get(fmt.Sprintf("%s/%s", releaseURL, marker))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		p.setCacheValue(markerCacheKey(repo, marker), "")
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	v := strings.TrimSpace(string(b))
+	p.setCacheValue(markerCacheKey(repo, marker), v)
+	return v, nil
+}
+
+// getReleases gets the latest patch release of each minor release of the channel.
+// The latest channel also includes the pre-releases of the next minor release
+func (p *Provider) getReleases(repo, releaseURL string, channel v1alpha1.RemoteStrategy) ([]string, error) {
+	stable, err := p.getMarker(repo, releaseURL, "stable.txt")
+	if err != nil {
+		return nil, err
+	}
+	v, err := version.NewVersion(stable)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stable release %s: %v", stable, err)
+	}
+	segments := v.Segments()
+	major, minor := segments[0], segments[1]
+	if channel == v1alpha1.KubernetesStrategyLatest {
+		minor++
+	}
+	var releases []string
+	for i := 0; i < p.minors && minor >= 0; i, minor = i+1, minor-1 {
+		release, err := p.getMarker(repo, releaseURL, fmt.Sprintf("%s-%d.%d.txt", channel, major, minor))
+		if err != nil {
+			return nil, err
+		}
+		if release != "" {
+			releases = append(releases, release)
+		}
+	}
+	return releases, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.KubernetesStrategyStable && conf.Strategy != v1alpha1.KubernetesStrategyLatest {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	// repo can point to a mirror of the release bucket. any other value uses the upstream releases
+	releaseURL := DefaultReleaseURL
+	if strings.HasPrefix(conf.Repo, "http://") || strings.HasPrefix(conf.Repo, "https://") {
+		releaseURL = strings.TrimSuffix(conf.Repo, "/")
+	}
+	releases, err := p.getReleases(conf.Repo, releaseURL, conf.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, releases)
+}
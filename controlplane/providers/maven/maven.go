@@ -0,0 +1,134 @@
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultRepositoryURL = "https://repo.maven.apache.org/maven2"
+
+// Config contains configuration for Maven provider
+type Config struct {
+	// URL of the maven repository (e.g. https://maven.example.com/releases)
+	RepositoryURL string
+	// Basic authentication credentials for private repositories. They are only sent to the host of the repository URL
+	Username string
+	Password string
+}
+
+// Provider is a maven provider for getting remote versions from the maven-metadata.xml of artifacts
+type Provider struct {
+	client        *http.Client
+	repositoryURL string
+	username      string
+	password      string
+	cache         *cache.Cache
+	log           logr.Logger
+}
+
+type Metadata struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	repositoryURL := c.RepositoryURL
+	if repositoryURL == "" {
+		repositoryURL = DefaultRepositoryURL
+	}
+	return &Provider{
+		client:        &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		repositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+		username:      c.Username,
+		password:      c.Password,
+		cache:         cache,
+		log:           logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("maven/%s/versions", repo)
+}
+
+// metadataURL returns the URL of the maven-metadata.xml of the artifact. repo is either the
+// artifact coordinates (e.g. org.apache.commons:commons-lang3) or the coordinates prefixed
+// by a custom repository URL (e.g. https://maven.example.com/releases/com.example:app)
+func (p *Provider) metadataURL(repo string) (string, error) {
+	repositoryURL, coordinates := p.repositoryURL, repo
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		i := strings.LastIndex(repo, "/")
+		repositoryURL, coordinates = repo[:i], repo[i+1:]
+	}
+	parts := strings.Split(coordinates, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid repo: %s. it must be in the format of: groupId:artifactId", repo)
+	}
+	return fmt.Sprintf("%s/%s/%s/maven-metadata.xml", repositoryURL, strings.ReplaceAll(parts[0], ".", "/"), parts[1]), nil
+}
+
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	u, err := p.metadataURL(repo)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.username != "" && p.password != "" && utils.SameHost(p.repositoryURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var metadata Metadata
+	if err := xml.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, err
+	}
+	versions := metadata.Versioning.Versions
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.MavenStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
@@ -0,0 +1,159 @@
+package nexus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Config contains configuration for Nexus provider
+type Config struct {
+	// Base URL of the Nexus Repository Manager (e.g. https://nexus.example.com)
+	BaseURL string
+	// Basic authentication credentials (or user token)
+	Username string
+	Password string
+}
+
+// Provider is a Nexus provider for getting remote versions from the components of hosted repositories
+type Provider struct {
+	client   *http.Client
+	baseURL  string
+	username string
+	password string
+	cache    *cache.Cache
+	log      logr.Logger
+}
+
+type Component struct {
+	Repository string `json:"repository"`
+	Format     string `json:"format"`
+	Group      string `json:"group"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+}
+
+type componentsPage struct {
+	Items             []Component `json:"items"`
+	ContinuationToken string      `json:"continuationToken"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	if c.Username == "" || c.Password == "" {
+		logger.V(1).Info("no authentication provided. only repositories with anonymous access will be accessible.")
+	}
+	return &Provider{
+		client:   &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL:  strings.TrimSuffix(c.BaseURL, "/"),
+		username: c.Username,
+		password: c.Password,
+		cache:    cache,
+		log:      logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("nexus/%s/versions", repo)
+}
+
+// searchQuery returns the search query of the component. repo is in the format of repository/component
+// where component is groupId:artifactId for maven, the package name for npm (e.g. @scope/name)
+// or the image name for docker (e.g. team/app)
+func searchQuery(repo string) (url.Values, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: repository/component", repo)
+	}
+	q := url.Values{}
+	q.Set("repository", parts[0])
+	name := parts[1]
+	if coordinates := strings.Split(name, ":"); len(coordinates) == 2 {
+		q.Set("group", coordinates[0])
+		name = coordinates[1]
+	} else if strings.HasPrefix(name, "@") {
+		scope := strings.SplitN(name, "/", 2)
+		if len(scope) == 2 {
+			q.Set("group", strings.TrimPrefix(scope[0], "@"))
+			name = scope[1]
+		}
+	}
+	q.Set("name", name)
+	return q, nil
+}
+
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	if p.baseURL == "" {
+		return nil, fmt.Errorf("base url is required for the nexus provider")
+	}
+	q, err := searchQuery(repo)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/service/rest/v1/search?%s", p.baseURL, q.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if p.username != "" && p.password != "" {
+			req.SetBasicAuth(p.username, p.password)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var page componentsPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page.Items {
+			versions = append(versions, c.Version)
+		}
+		if page.ContinuationToken == "" {
+			break
+		}
+		q.Set("continuationToken", page.ContinuationToken)
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.NexusStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
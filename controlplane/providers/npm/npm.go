@@ -0,0 +1,132 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultRegistryURL = "https://registry.npmjs.org"
+
+// Config contains configuration for npm provider
+type Config struct {
+	// URL of the npm registry (e.g. https://npm.pkg.github.com)
+	RegistryURL string
+	// Auth token for private registries
+	Token string
+}
+
+// Provider is an npm provider for getting remote versions from npm package versions and dist-tags
+type Provider struct {
+	client      *http.Client
+	registryURL string
+	token       string
+	cache       *cache.Cache
+	log         logr.Logger
+}
+
+type Package struct {
+	Name     string            `json:"name"`
+	DistTags map[string]string `json:"dist-tags"`
+	Versions map[string]struct {
+		Version    string `json:"version"`
+		Deprecated string `json:"deprecated,omitempty"`
+	} `json:"versions"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	registryURL := c.RegistryURL
+	if registryURL == "" {
+		registryURL = DefaultRegistryURL
+	}
+	return &Provider{
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		registryURL: strings.TrimSuffix(registryURL, "/"),
+		token:       c.Token,
+		cache:       cache,
+		log:         logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func packageCacheKey(repo string) string {
+	return fmt.Sprintf("npm/%s/package", repo)
+}
+
+// getPackage gets the package metadata. repo is the package name (e.g. react or @types/node)
+func (p *Provider) getPackage(repo string) (*Package, error) {
+	log := p.log.WithValues("repo", repo)
+	if pkg, ok := p.getCacheValue(packageCacheKey(repo)); ok {
+		log.V(1).Info("found package in cache")
+		return pkg.(*Package), nil
+	}
+	log.V(1).Info("getting package")
+	// scoped packages must have their slash escaped
+	name := strings.Replace(repo, "/", "%2f", 1)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", p.registryURL, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	// abbreviated metadata is enough and much smaller than the full document
+	req.Header.Set("Accept", "application/vnd.npm.install-v1+json")
+	if p.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	pkg := &Package{}
+	if err := json.NewDecoder(resp.Body).Decode(pkg); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(packageCacheKey(repo), pkg)
+	return pkg, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	pkg, err := p.getPackage(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	switch conf.Strategy {
+	case v1alpha1.NPMStrategyVersions:
+		for v, meta := range pkg.Versions {
+			if meta.Deprecated != "" {
+				continue
+			}
+			candidates = append(candidates, v)
+		}
+	case v1alpha1.NPMStrategyDistTags:
+		for _, v := range pkg.DistTags {
+			candidates = append(candidates, v)
+		}
+		candidates = utils.RemoveDuplicateStr(candidates)
+	default:
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, "", candidates)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterNpmRange(conf.Constraint, versions)
+}
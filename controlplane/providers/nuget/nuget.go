@@ -0,0 +1,165 @@
+package nuget
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const (
+	DefaultFeedURL = "https://api.nuget.org/v3/index.json"
+	// type of the flat container resource in the service index
+	packageBaseAddressType = "PackageBaseAddress/3.0.0"
+)
+
+// Config contains configuration for NuGet provider
+type Config struct {
+	// URL of the v3 service index of the feed
+	FeedURL string
+	// API key for private feeds. The API key and the credentials are only sent to the host of the feed URL
+	APIKey string
+	// Basic authentication credentials for private feeds (e.g. Azure Artifacts)
+	Username string
+	Password string
+}
+
+// Provider is a NuGet provider for getting remote versions from package versions of NuGet v3 feeds
+type Provider struct {
+	client   *http.Client
+	feedURL  string
+	apiKey   string
+	username string
+	password string
+	cache    *cache.Cache
+	log      logr.Logger
+}
+
+type ServiceIndex struct {
+	Resources []struct {
+		ID   string `json:"@id"`
+		Type string `json:"@type"`
+	} `json:"resources"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	feedURL := c.FeedURL
+	if feedURL == "" {
+		feedURL = DefaultFeedURL
+	}
+	return &Provider{
+		client:   &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		feedURL:  feedURL,
+		apiKey:   c.APIKey,
+		username: c.Username,
+		password: c.Password,
+		cache:    cache,
+		log:      logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("nuget/%s/versions", repo)
+}
+
+func baseAddressCacheKey(feed string) string {
+	return fmt.Sprintf("nuget/%s/base-address", feed)
+}
+
+func (p *Provider) get(u string, v interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	// the resources of the service index can be on other hosts
+	if utils.SameHost(p.feedURL, req.URL) {
+		if p.apiKey != "" {
+			req.Header.Set("X-NuGet-ApiKey", p.apiKey)
+		}
+		if p.username != "" && p.password != "" {
+			req.SetBasicAuth(p.username, p.password)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getBaseAddress gets the URL of the flat container resource from the service index of the feed
+func (p *Provider) getBaseAddress(feed string) (string, error) {
+	if a, ok := p.getCacheValue(baseAddressCacheKey(feed)); ok {
+		return a.(string), nil
+	}
+	var index ServiceIndex
+	if err := p.get(feed, &index); err != nil {
+		return "", err
+	}
+	for _, r := range index.Resources {
+		if r.Type == packageBaseAddressType {
+			address := strings.TrimSuffix(r.ID, "/")
+			p.setCacheValue(baseAddressCacheKey(feed), address)
+			return address, nil
+		}
+	}
+	return "", fmt.Errorf("feed %s does not have a %s resource", feed, packageBaseAddressType)
+}
+
+// getVersions gets the versions of the package. repo is the package ID (e.g. Newtonsoft.Json)
+// optionally prefixed by the URL of a service index (e.g. https://nuget.example.com/v3/index.json/Example.App)
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	feed, id := p.feedURL, repo
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		i := strings.LastIndex(repo, "/")
+		feed, id = repo[:i], repo[i+1:]
+	}
+	address, err := p.getBaseAddress(feed)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Versions []string `json:"versions"`
+	}
+	// package IDs are lowercased in the flat container
+	if err := p.get(fmt.Sprintf("%s/%s/index.json", address, strings.ToLower(id)), &result); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(versionsCacheKey(repo), result.Versions)
+	return result.Versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.NuGetStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
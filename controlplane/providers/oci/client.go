@@ -0,0 +1,253 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const DefaultRegistry = "registry-1.docker.io"
+
+// dockerHubRealm is the host of the token service of Docker Hub, which is not on the host of the registry
+const dockerHubRealm = "auth.docker.io"
+
+// manifestMediaTypes are the media types of the image manifests and indexes
+var manifestMediaTypes = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+var (
+	challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	linkNextRegex       = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// Client is a minimal client of the OCI distribution (Docker Registry v2) API
+type Client struct {
+	HTTPClient *http.Client
+	// Username and password for basic authentication
+	// they are also used to request a bearer token if the registry asks for it
+	Username string
+	Password string
+	// Static bearer token. if empty and the registry requires a bearer token
+	// it will be requested from the registry's token service
+	Token string
+	// Hosts of the registries the credentials and the static token belong to. They are never sent to another host
+	Registries []string
+}
+
+type tagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// ParseRepo splits an image repository (e.g. ghcr.io/owner/image) into the registry host and the repository name.
+// Repositories without a registry host are assumed to be on Docker Hub
+func ParseRepo(repo string) (registry string, name string) {
+	repo = strings.TrimPrefix(strings.TrimPrefix(repo, "https://"), "http://")
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0], parts[1]
+	}
+	if len(parts) == 1 {
+		return DefaultRegistry, fmt.Sprintf("library/%s", repo)
+	}
+	return DefaultRegistry, repo
+}
+
+// ListTags returns all the tags of a repository in the registry by following the pagination
+func (c *Client) ListTags(registry, name string) ([]string, error) {
+	var tags []string
+	token := c.TokenFor(registry)
+	next := fmt.Sprintf("https://%s/v2/%s/tags/list?n=1000", registry, name)
+	for next != "" {
+		resp, err := c.get(next, &token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var list tagList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, list.Tags...)
+		next, err = nextPage(next, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// TokenFor returns the static token if it belongs to the registry
+func (c *Client) TokenFor(registry string) string {
+	if !c.trusted(registry) {
+		return ""
+	}
+	return c.Token
+}
+
+// trusted returns true if the credentials belong to the registry, i.e. its host is one of the configured registries
+func (c *Client) trusted(registry string) bool {
+	for _, r := range c.Registries {
+		if strings.EqualFold(r, registry) {
+			return true
+		}
+	}
+	return false
+}
+
+// ManifestDigest returns the digest of the manifest of a tag. The token is reused between the requests
+func (c *Client) ManifestDigest(registry, name, tag string, token *string) (string, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, name, tag)
+	resp, err := c.do("HEAD", u, manifestMediaTypes, token)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// get sends a GET request to the registry
+func (c *Client) get(u string, token *string) (*http.Response, error) {
+	return c.do("GET", u, "application/json", token)
+}
+
+// do sends a request to the registry. If the registry responds with a bearer challenge,
+// a token is requested from the registry's token service and the request is retried with it
+func (c *Client) do(method, u, accept string, token *string) (*http.Response, error) {
+	resp, err := c.request(method, u, accept, *token)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, fmt.Errorf("unauthorized to access %s", u)
+	}
+	req, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	t, err := c.fetchToken(req.Host, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry token: %v", err)
+	}
+	*token = t
+	return c.request(method, u, accept, t)
+}
+
+func (c *Client) request(method, u, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	} else if c.Username != "" && c.Password != "" && c.trusted(req.URL.Host) {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// fetchToken requests a token from the realm in the bearer challenge of the registry
+// e.g Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:owner/image:pull"
+// The credentials are only sent to a realm served over https on the host of the registry, so a registry can't
+// forward them to another host
+func (c *Client) fetchToken(registry, challenge string) (string, error) {
+	params := map[string]string{}
+	for _, m := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("missing realm in challenge: %s", challenge)
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if service, ok := params["service"]; ok {
+		q.Set("service", service)
+	}
+	if scope, ok := params["scope"]; ok {
+		q.Set("scope", scope)
+	}
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Username != "" && c.Password != "" && c.trusted(registry) && trustedRealm(registry, u) {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	if tr.Token != "" {
+		return tr.Token, nil
+	}
+	return tr.AccessToken, nil
+}
+
+// trustedRealm returns true if the credentials of the registry can be sent to the realm of its challenge
+func trustedRealm(registry string, realm *url.URL) bool {
+	if realm.Scheme != "https" {
+		return false
+	}
+	return strings.EqualFold(realm.Host, registry) || (registry == DefaultRegistry && realm.Host == dockerHubRealm)
+}
+
+// nextPage returns the absolute url of the next page from the Link header (empty if there is no next page). The
+// next page must be on the host of the current one, as the token of the registry is sent with it
+func nextPage(current, link string) (string, error) {
+	m := linkNextRegex.FindStringSubmatch(link)
+	if m == nil {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(m[1])
+	if err != nil {
+		return "", err
+	}
+	next := base.ResolveReference(ref)
+	if next.Scheme != base.Scheme || !strings.EqualFold(next.Host, base.Host) {
+		return "", fmt.Errorf("the next page is on %s instead of the registry %s", next.Host, base.Host)
+	}
+	return next.String(), nil
+}
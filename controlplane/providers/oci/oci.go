@@ -0,0 +1,161 @@
+package oci
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-version"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Config contains configuration for OCI registry provider
+type Config struct {
+	Username string
+	Password string
+	Token    string
+	// Hosts of the registries the credentials belong to, e.g. ghcr.io. Defaults to Docker Hub
+	Registries []string
+	// Maximum number of tags to check when resolving a digest to its tags. 0 disables the resolution
+	DigestLookups int
+}
+
+// Provider is an OCI registry provider for getting remote versions from image tags
+type Provider struct {
+	client        *Client
+	cache         *cache.Cache
+	log           logr.Logger
+	digestLookups int
+}
+
+// NewClient returns a registry client with the configured credentials, sending the requests with the transport
+func (c *Config) NewClient(tr *http.Transport) *Client {
+	registries := c.Registries
+	if len(registries) == 0 {
+		registries = []string{DefaultRegistry}
+	}
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		Username:   c.Username,
+		Password:   c.Password,
+		Token:      c.Token,
+		Registries: registries,
+	}
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client:        c.NewClient(tr),
+		cache:         cache,
+		log:           logger,
+		digestLookups: c.DigestLookups,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("oci/%s/tags", repo)
+}
+
+func (p *Provider) getTags(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]string), nil
+	}
+	log.V(1).Info("getting tags")
+	registry, name := ParseRepo(repo)
+	tags, err := p.client.ListTags(registry, name)
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.OCIStrategyTags {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, tags)
+}
+
+func digestCacheKey(repo, digest string) string {
+	return fmt.Sprintf("oci/%s/digests/%s", repo, digest)
+}
+
+// ResolvesDigests returns true if the digests are resolved to their tags
+func (p *Provider) ResolvesDigests() bool {
+	return p.digestLookups > 0
+}
+
+// ResolveDigest returns the tags of the repo pointing to the digest. The manifests of the most recent tags are
+// compared with the digest, up to the digest lookups
+func (p *Provider) ResolveDigest(repo, digest string) ([]string, error) {
+	log := p.log.WithValues("repo", repo, "digest", digest)
+	if t, ok := p.getCacheValue(digestCacheKey(repo, digest)); ok {
+		log.V(1).Info("found digest tags in cache")
+		return t.([]string), nil
+	}
+	tags, err := p.getTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info("resolving digest")
+	registry, name := ParseRepo(repo)
+	token := p.client.TokenFor(registry)
+	resolved := []string{}
+	for i, tag := range newestFirst(tags) {
+		if i >= p.digestLookups {
+			break
+		}
+		d, err := p.client.ManifestDigest(registry, name, tag, &token)
+		if err != nil {
+			return nil, err
+		}
+		if d == digest {
+			resolved = append(resolved, tag)
+		}
+	}
+	p.setCacheValue(digestCacheKey(repo, digest), resolved)
+	return resolved, nil
+}
+
+// newestFirst returns the tags sorted from the highest version to the lowest. The registries list the tags in
+// lexical order, so the last tags of the list are not the most recent ones. The tags that are not versions (e.g.
+// latest) come after the versions, in the reverse order of the list
+func newestFirst(tags []string) []string {
+	versions := make([]*version.Version, len(tags))
+	order := make([]int, len(tags))
+	for i, tag := range tags {
+		versions[i], _ = version.NewVersion(tag)
+		order[i] = len(tags) - 1 - i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := versions[order[a]], versions[order[b]]
+		if va == nil || vb == nil {
+			return va != nil
+		}
+		return va.GreaterThan(vb)
+	})
+	sorted := make([]string, len(tags))
+	for i, j := range order {
+		sorted[i] = tags[j]
+	}
+	return sorted
+}
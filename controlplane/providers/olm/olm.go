@@ -0,0 +1,140 @@
+package olm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultBaseURL = "https://operatorhub.io"
+
+// version part of a ClusterServiceVersion name (e.g. etcdoperator.v0.9.4 or prometheusoperator.0.47.0)
+var csvVersionRegex = regexp.MustCompile(`\.v?([0-9]+\..*)$`)
+
+// Config contains configuration for OLM provider
+type Config struct {
+	// Base URL of the OperatorHub instance
+	BaseURL string
+}
+
+// Provider is an OLM provider for getting remote versions from the channels of operators listed in OperatorHub
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+type Channel struct {
+	Name       string `json:"name"`
+	CurrentCSV string `json:"currentCSV"`
+}
+
+type Operator struct {
+	Name           string    `json:"name"`
+	Version        string    `json:"version"`
+	DefaultChannel string    `json:"defaultChannel"`
+	Channels       []Channel `json:"channels"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Provider{
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		cache:   cache,
+		log:     logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func operatorCacheKey(repo string) string {
+	return fmt.Sprintf("olm/%s/operator", repo)
+}
+
+// getOperator gets the operator of the package. It is cached under the repo so it is dropped with the repo
+func (p *Provider) getOperator(repo, pkg string) (*Operator, error) {
+	log := p.log.WithValues("package", pkg)
+	if o, ok := p.getCacheValue(operatorCacheKey(repo)); ok {
+		log.V(1).Info("found operator in cache")
+		return o.(*Operator), nil
+	}
+	log.V(1).Info("getting operator")
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/operator?packageName=%s", p.baseURL, url.QueryEscape(pkg)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var result struct {
+		Operator *Operator `json:"operator"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Operator == nil {
+		return nil, fmt.Errorf("operator %s not found", pkg)
+	}
+	p.setCacheValue(operatorCacheKey(repo), result.Operator)
+	return result.Operator, nil
+}
+
+// getChannelVersions gets the head version of the channels of the operator. repo is the package
+// name optionally followed by a channel to only track that channel (e.g. etcd or etcd/clusterwide-alpha)
+func (p *Provider) getChannelVersions(repo string) ([]string, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	operator, err := p.getOperator(repo, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, c := range operator.Channels {
+		if len(parts) == 2 && c.Name != parts[1] {
+			continue
+		}
+		if m := csvVersionRegex.FindStringSubmatch(c.CurrentCSV); m != nil {
+			versions = append(versions, m[1])
+		}
+	}
+	if len(parts) == 2 && len(versions) == 0 {
+		return nil, fmt.Errorf("channel %s not found in operator %s", parts[1], parts[0])
+	}
+	return utils.RemoveDuplicateStr(versions), nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.OLMStrategyChannels {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getChannelVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
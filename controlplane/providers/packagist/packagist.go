@@ -0,0 +1,169 @@
+package packagist
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultRepositoryURL = "https://repo.packagist.org"
+
+// Config contains configuration for Packagist provider
+type Config struct {
+	// URL of the Composer repository (e.g. https://repo.packagist.com/example or a Satis repository)
+	RepositoryURL string
+	// Basic authentication credentials for private repositories. They are only sent to the host of the repository URL
+	Username string
+	Password string
+}
+
+// Provider is a Packagist provider for getting remote versions from Composer package versions
+type Provider struct {
+	client        *http.Client
+	repositoryURL string
+	username      string
+	password      string
+	cache         *cache.Cache
+	log           logr.Logger
+}
+
+type Version struct {
+	Version string `json:"version"`
+}
+
+// RepositoryMetadata is the packages.json of a Composer repository
+type RepositoryMetadata struct {
+	MetadataURL string `json:"metadata-url"`
+	// packages listed inline by repositories without a metadata-url (e.g. Satis)
+	Packages map[string]map[string]Version `json:"packages"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	repositoryURL := c.RepositoryURL
+	if repositoryURL == "" {
+		repositoryURL = DefaultRepositoryURL
+	}
+	return &Provider{
+		client:        &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		repositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+		username:      c.Username,
+		password:      c.Password,
+		cache:         cache,
+		log:           logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("packagist/%s/versions", repo)
+}
+
+func repositoryCacheKey(repositoryURL string) string {
+	return fmt.Sprintf("packagist/%s/repository", repositoryURL)
+}
+
+func (p *Provider) get(u string, v interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	// the metadata URL of the repository can be on another host
+	if p.username != "" && p.password != "" && utils.SameHost(p.repositoryURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (p *Provider) getRepository(repositoryURL string) (*RepositoryMetadata, error) {
+	if r, ok := p.getCacheValue(repositoryCacheKey(repositoryURL)); ok {
+		return r.(*RepositoryMetadata), nil
+	}
+	repository := &RepositoryMetadata{}
+	if err := p.get(fmt.Sprintf("%s/packages.json", repositoryURL), repository); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(repositoryCacheKey(repositoryURL), repository)
+	return repository, nil
+}
+
+// getVersions gets the versions of the package. repo is the package name (e.g. laravel/framework)
+// optionally prefixed by the URL of a Composer repository (e.g. https://satis.example.com/example/app)
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	repositoryURL, name := p.repositoryURL, repo
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		parts := strings.Split(repo, "/")
+		if len(parts) < 5 {
+			return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: [repository url/]vendor/package", repo)
+		}
+		repositoryURL, name = strings.Join(parts[:len(parts)-2], "/"), strings.Join(parts[len(parts)-2:], "/")
+	}
+	repository, err := p.getRepository(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	if repository.MetadataURL != "" {
+		u := strings.Replace(repository.MetadataURL, "%package%", name, 1)
+		if strings.HasPrefix(u, "/") {
+			u = repositoryURL + u
+		}
+		var metadata struct {
+			Packages map[string][]Version `json:"packages"`
+		}
+		if err := p.get(u, &metadata); err != nil {
+			return nil, err
+		}
+		for _, v := range metadata.Packages[name] {
+			versions = append(versions, v.Version)
+		}
+	} else {
+		pkg, ok := repository.Packages[name]
+		if !ok {
+			return nil, fmt.Errorf("package %s not found in %s", name, repositoryURL)
+		}
+		for _, v := range pkg {
+			versions = append(versions, v.Version)
+		}
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.PackagistStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
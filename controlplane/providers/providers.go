@@ -7,13 +7,72 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/patrickmn/go-cache"
 	"github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers/acr"
+	"github.com/skillz/opvic/controlplane/providers/apt"
+	"github.com/skillz/opvic/controlplane/providers/artifacthub"
+	"github.com/skillz/opvic/controlplane/providers/artifactory"
+	"github.com/skillz/opvic/controlplane/providers/azuredevops"
+	"github.com/skillz/opvic/controlplane/providers/bitbucket"
+	"github.com/skillz/opvic/controlplane/providers/crates"
+	"github.com/skillz/opvic/controlplane/providers/ecr"
+	"github.com/skillz/opvic/controlplane/providers/eks"
+	"github.com/skillz/opvic/controlplane/providers/feed"
+	"github.com/skillz/opvic/controlplane/providers/flathub"
+	"github.com/skillz/opvic/controlplane/providers/gar"
+	"github.com/skillz/opvic/controlplane/providers/git"
 	"github.com/skillz/opvic/controlplane/providers/github"
+	"github.com/skillz/opvic/controlplane/providers/gitlab"
+	"github.com/skillz/opvic/controlplane/providers/gke"
 	"github.com/skillz/opvic/controlplane/providers/helm"
+	"github.com/skillz/opvic/controlplane/providers/html"
+	"github.com/skillz/opvic/controlplane/providers/kubernetes"
+	"github.com/skillz/opvic/controlplane/providers/maven"
+	"github.com/skillz/opvic/controlplane/providers/nexus"
+	"github.com/skillz/opvic/controlplane/providers/npm"
+	"github.com/skillz/opvic/controlplane/providers/nuget"
+	"github.com/skillz/opvic/controlplane/providers/oci"
+	"github.com/skillz/opvic/controlplane/providers/olm"
+	"github.com/skillz/opvic/controlplane/providers/packagist"
+	"github.com/skillz/opvic/controlplane/providers/pypi"
+	"github.com/skillz/opvic/controlplane/providers/quay"
+	"github.com/skillz/opvic/controlplane/providers/s3"
+	"github.com/skillz/opvic/controlplane/providers/snapcraft"
+	"github.com/skillz/opvic/controlplane/providers/yum"
+	"github.com/skillz/opvic/utils"
 )
 
 const (
-	Github ProviderType = "github"
-	Helm   ProviderType = "helm"
+	Github      ProviderType = "github"
+	Helm        ProviderType = "helm"
+	Gitlab      ProviderType = "gitlab"
+	Bitbucket   ProviderType = "bitbucket"
+	OCI         ProviderType = "oci"
+	ECR         ProviderType = "ecr"
+	GAR         ProviderType = "gar"
+	ACR         ProviderType = "acr"
+	Quay        ProviderType = "quay"
+	ArtifactHub ProviderType = "artifacthub"
+	PyPI        ProviderType = "pypi"
+	NPM         ProviderType = "npm"
+	Maven       ProviderType = "maven"
+	Crates      ProviderType = "crates"
+	HTML        ProviderType = "html"
+	Git         ProviderType = "git"
+	AzureDevOps ProviderType = "azuredevops"
+	S3          ProviderType = "s3"
+	Artifactory ProviderType = "artifactory"
+	Nexus       ProviderType = "nexus"
+	NuGet       ProviderType = "nuget"
+	Packagist   ProviderType = "packagist"
+	APT         ProviderType = "apt"
+	YUM         ProviderType = "yum"
+	OLM         ProviderType = "olm"
+	Kubernetes  ProviderType = "kubernetes"
+	Feed        ProviderType = "feed"
+	GKE         ProviderType = "gke"
+	EKS         ProviderType = "eks"
+	Snapcraft   ProviderType = "snapcraft"
+	Flathub     ProviderType = "flathub"
 )
 
 type ProviderType string
@@ -23,29 +82,161 @@ func (p ProviderType) String() string {
 }
 
 type Config struct {
-	Logger logr.Logger
-	Github *github.Config
+	Logger      logr.Logger
+	ProxyURL    string
+	CAFile      string
+	Github      *github.Config
+	Helm        *helm.Config
+	Gitlab      *gitlab.Config
+	Bitbucket   *bitbucket.Config
+	OCI         *oci.Config
+	ECR         *ecr.Config
+	GAR         *gar.Config
+	ACR         *acr.Config
+	Quay        *quay.Config
+	PyPI        *pypi.Config
+	NPM         *npm.Config
+	Maven       *maven.Config
+	Crates      *crates.Config
+	HTML        *html.Config
+	Git         *git.Config
+	AzureDevOps *azuredevops.Config
+	S3          *s3.Config
+	Artifactory *artifactory.Config
+	Nexus       *nexus.Config
+	NuGet       *nuget.Config
+	Packagist   *packagist.Config
+	APT         *apt.Config
+	YUM         *yum.Config
+	OLM         *olm.Config
+	Kubernetes  *kubernetes.Config
+	GKE         *gke.Config
+	EKS         *eks.Config
 }
 
 type Provider struct {
-	log    logr.Logger
-	Github *github.Provider
-	Helm   *helm.Provider
+	log logr.Logger
+	// cache of the remote versions with a TTL, shared with the providers
+	cache       *cache.Cache
+	Github      *github.Provider
+	Helm        *helm.Provider
+	Gitlab      *gitlab.Provider
+	Bitbucket   *bitbucket.Provider
+	OCI         *oci.Provider
+	ECR         *ecr.Provider
+	GAR         *gar.Provider
+	ACR         *acr.Provider
+	Quay        *quay.Provider
+	ArtifactHub *artifacthub.Provider
+	PyPI        *pypi.Provider
+	NPM         *npm.Provider
+	Maven       *maven.Provider
+	Crates      *crates.Provider
+	HTML        *html.Provider
+	Git         *git.Provider
+	AzureDevOps *azuredevops.Provider
+	S3          *s3.Provider
+	Artifactory *artifactory.Provider
+	Nexus       *nexus.Provider
+	NuGet       *nuget.Provider
+	Packagist   *packagist.Provider
+	APT         *apt.Provider
+	YUM         *yum.Provider
+	OLM         *olm.Provider
+	Kubernetes  *kubernetes.Provider
+	Feed        *feed.Provider
+	GKE         *gke.Provider
+	EKS         *eks.Provider
+	Snapcraft   *snapcraft.Provider
+	Flathub     *flathub.Provider
 }
 
 func (c *Config) Init(ctx context.Context, cache *cache.Cache) (*Provider, error) {
-	var err error
+	// the providers share the transport of the proxy and the CA bundle
+	tr, err := utils.NewHTTPTransport(c.ProxyURL, c.CAFile)
+	if err != nil {
+		return nil, err
+	}
 	p := &Provider{}
 	logger := c.Logger.WithName("provider")
-	p.Github, err = c.Github.NewProvider(ctx, cache, logger.WithName("github"))
+	p.Github, err = c.Github.NewProvider(ctx, cache, tr, logger.WithName("github"))
+	if err != nil {
+		return nil, err
+	}
+	p.Helm, err = c.Helm.NewProvider(cache, tr, logger.WithName("helm"))
+	if err != nil {
+		return nil, err
+	}
+	p.Gitlab = c.Gitlab.NewProvider(cache, tr, logger.WithName("gitlab"))
+	p.Bitbucket = c.Bitbucket.NewProvider(cache, tr, logger.WithName("bitbucket"))
+	p.OCI = c.OCI.NewProvider(cache, tr, logger.WithName("oci"))
+	p.ECR, err = c.ECR.NewProvider(cache, tr, logger.WithName("ecr"))
+	if err != nil {
+		return nil, err
+	}
+	p.GAR, err = c.GAR.NewProvider(ctx, cache, tr, logger.WithName("gar"))
+	if err != nil {
+		return nil, err
+	}
+	p.ACR = c.ACR.NewProvider(cache, tr, logger.WithName("acr"))
+	p.Quay = c.Quay.NewProvider(cache, tr, logger.WithName("quay"))
+	p.ArtifactHub = artifacthub.NewProvider(cache, tr, logger.WithName("artifacthub"))
+	p.PyPI = c.PyPI.NewProvider(cache, tr, logger.WithName("pypi"))
+	p.NPM = c.NPM.NewProvider(cache, tr, logger.WithName("npm"))
+	p.Maven = c.Maven.NewProvider(cache, tr, logger.WithName("maven"))
+	p.Crates = c.Crates.NewProvider(cache, tr, logger.WithName("crates"))
+	p.HTML, err = c.HTML.NewProvider(cache, tr, logger.WithName("html"))
 	if err != nil {
 		return nil, err
 	}
-	p.Helm = helm.NewProvider(cache, logger.WithName("helm"))
+	p.Git, err = c.Git.NewProvider(cache, tr, logger.WithName("git"))
+	if err != nil {
+		return nil, err
+	}
+	p.AzureDevOps = c.AzureDevOps.NewProvider(cache, tr, logger.WithName("azuredevops"))
+	p.S3, err = c.S3.NewProvider(cache, tr, logger.WithName("s3"))
+	if err != nil {
+		return nil, err
+	}
+	p.Artifactory = c.Artifactory.NewProvider(cache, tr, logger.WithName("artifactory"))
+	p.Nexus = c.Nexus.NewProvider(cache, tr, logger.WithName("nexus"))
+	p.NuGet = c.NuGet.NewProvider(cache, tr, logger.WithName("nuget"))
+	p.Packagist = c.Packagist.NewProvider(cache, tr, logger.WithName("packagist"))
+	p.APT = c.APT.NewProvider(cache, tr, logger.WithName("apt"))
+	p.YUM = c.YUM.NewProvider(cache, tr, logger.WithName("yum"))
+	p.OLM = c.OLM.NewProvider(cache, tr, logger.WithName("olm"))
+	p.Kubernetes = c.Kubernetes.NewProvider(cache, tr, logger.WithName("kubernetes"))
+	p.Feed = feed.NewProvider(cache, tr, logger.WithName("feed"))
+	p.GKE, err = c.GKE.NewProvider(ctx, cache, tr, logger.WithName("gke"))
+	if err != nil {
+		return nil, err
+	}
+	p.EKS, err = c.EKS.NewProvider(cache, tr, logger.WithName("eks"))
+	if err != nil {
+		return nil, err
+	}
+	p.Snapcraft = snapcraft.NewProvider(cache, tr, logger.WithName("snapcraft"))
+	p.Flathub = flathub.NewProvider(cache, tr, logger.WithName("flathub"))
 	p.log = logger
+	p.cache = cache
 	return p, nil
 }
 
+// GetPublishedVersions returns the remote versions with their publish dates. The dates are zero for the
+// providers that don't expose them. The remote versions are cached for the cache TTL of the remote version if set
+func (p *Provider) GetPublishedVersions(conf v1alpha1.RemoteVersion) ([]utils.PublishedVersion, error) {
+	return p.cachedPublishedVersions(conf, func() ([]utils.PublishedVersion, error) {
+		if conf.Provider == Github.String() && conf.Repo != "" {
+			return p.Github.GetPublishedVersions(conf)
+		}
+		versions, err := p.GetVersions(conf)
+		if err != nil {
+			return nil, err
+		}
+		return utils.NewPublishedVersions(versions), nil
+	})
+}
+
 func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
 	if conf.Provider == "" || conf.Repo == "" {
 		p.log.V(1).Info("no remoteVersion configuration provided, skipping remote version lookup")
@@ -56,6 +247,64 @@ func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
 		return p.Github.GetVersions(conf)
 	case Helm.String():
 		return p.Helm.GetVersions(conf)
+	case Gitlab.String():
+		return p.Gitlab.GetVersions(conf)
+	case Bitbucket.String():
+		return p.Bitbucket.GetVersions(conf)
+	case OCI.String():
+		return p.OCI.GetVersions(conf)
+	case ECR.String():
+		return p.ECR.GetVersions(conf)
+	case GAR.String():
+		return p.GAR.GetVersions(conf)
+	case ACR.String():
+		return p.ACR.GetVersions(conf)
+	case Quay.String():
+		return p.Quay.GetVersions(conf)
+	case ArtifactHub.String():
+		return p.ArtifactHub.GetVersions(conf)
+	case PyPI.String():
+		return p.PyPI.GetVersions(conf)
+	case NPM.String():
+		return p.NPM.GetVersions(conf)
+	case Maven.String():
+		return p.Maven.GetVersions(conf)
+	case Crates.String():
+		return p.Crates.GetVersions(conf)
+	case HTML.String():
+		return p.HTML.GetVersions(conf)
+	case Git.String():
+		return p.Git.GetVersions(conf)
+	case AzureDevOps.String():
+		return p.AzureDevOps.GetVersions(conf)
+	case S3.String():
+		return p.S3.GetVersions(conf)
+	case Artifactory.String():
+		return p.Artifactory.GetVersions(conf)
+	case Nexus.String():
+		return p.Nexus.GetVersions(conf)
+	case NuGet.String():
+		return p.NuGet.GetVersions(conf)
+	case Packagist.String():
+		return p.Packagist.GetVersions(conf)
+	case APT.String():
+		return p.APT.GetVersions(conf)
+	case YUM.String():
+		return p.YUM.GetVersions(conf)
+	case OLM.String():
+		return p.OLM.GetVersions(conf)
+	case Kubernetes.String():
+		return p.Kubernetes.GetVersions(conf)
+	case Feed.String():
+		return p.Feed.GetVersions(conf)
+	case GKE.String():
+		return p.GKE.GetVersions(conf)
+	case EKS.String():
+		return p.EKS.GetVersions(conf)
+	case Snapcraft.String():
+		return p.Snapcraft.GetVersions(conf)
+	case Flathub.String():
+		return p.Flathub.GetVersions(conf)
 	default:
 		return nil, fmt.Errorf("unknown provider %s", conf.Provider)
 	}
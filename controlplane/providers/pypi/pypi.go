@@ -0,0 +1,142 @@
+package pypi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultIndexURL = "https://pypi.org"
+
+// Config contains configuration for PyPI provider
+type Config struct {
+	// Base URL of the package index that serves the PyPI JSON API (e.g. https://pypi.example.com)
+	IndexURL string
+	// Basic authentication credentials for private indexes. They are only sent to the host of the index URL
+	Username string
+	Password string
+}
+
+// Provider is a PyPI provider for getting remote versions from python package releases
+type Provider struct {
+	client   *http.Client
+	indexURL string
+	username string
+	password string
+	cache    *cache.Cache
+	log      logr.Logger
+}
+
+type File struct {
+	Filename string `json:"filename"`
+	Yanked   bool   `json:"yanked"`
+}
+
+type Package struct {
+	Releases map[string][]File `json:"releases"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	indexURL := c.IndexURL
+	if indexURL == "" {
+		indexURL = DefaultIndexURL
+	}
+	return &Provider{
+		client:   &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		indexURL: strings.TrimSuffix(indexURL, "/"),
+		username: c.Username,
+		password: c.Password,
+		cache:    cache,
+		log:      logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func releasesCacheKey(repo string) string {
+	return fmt.Sprintf("pypi/%s/releases", repo)
+}
+
+// parseRepo splits the repo into the index URL and the package name.
+// repo is either the package name (e.g. requests) or the package
+// prefixed by a custom index URL (e.g. https://pypi.example.com/requests)
+func (p *Provider) parseRepo(repo string) (indexURL, name string) {
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		i := strings.LastIndex(repo, "/")
+		return repo[:i], repo[i+1:]
+	}
+	return p.indexURL, repo
+}
+
+func (p *Provider) getReleases(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if r, ok := p.getCacheValue(releasesCacheKey(repo)); ok {
+		log.V(1).Info("found releases in cache")
+		return r.([]string), nil
+	}
+	log.V(1).Info("getting releases")
+	indexURL, name := p.parseRepo(repo)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/pypi/%s/json", indexURL, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.username != "" && p.password != "" && utils.SameHost(p.indexURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var pkg Package
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, err
+	}
+	var releases []string
+	for version, files := range pkg.Releases {
+		if isYanked(files) {
+			continue
+		}
+		releases = append(releases, version)
+	}
+	p.setCacheValue(releasesCacheKey(repo), releases)
+	return releases, nil
+}
+
+// isYanked returns true if the release has no files or all of its files are yanked
+func isYanked(files []File) bool {
+	for _, f := range files {
+		if !f.Yanked {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.PyPIStrategyReleases {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	releases, err := p.getReleases(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, releases)
+}
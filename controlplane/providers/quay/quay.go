@@ -0,0 +1,139 @@
+package quay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const DefaultBaseURL = "https://quay.io"
+
+// Config contains configuration for Quay provider
+type Config struct {
+	// Base URL of the Quay instance (e.g. https://quay.example.com)
+	BaseURL string
+	// OAuth or robot account token
+	Token string
+	// Skip the tags that are expired
+	SkipExpired bool
+}
+
+// Provider is a quay provider for getting remote versions from Quay repository tags
+type Provider struct {
+	client      *http.Client
+	baseURL     string
+	token       string
+	skipExpired bool
+	cache       *cache.Cache
+	log         logr.Logger
+}
+
+type Tag struct {
+	Name string `json:"name"`
+	// Unix timestamp of when the tag expires. Only set on the tags with an expiration
+	EndTS int64 `json:"end_ts,omitempty"`
+}
+
+type tagsPage struct {
+	Tags          []Tag `json:"tags"`
+	HasAdditional bool  `json:"has_additional"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if c.Token == "" {
+		logger.V(1).Info("no authentication provided. private repositories will not be accessible.")
+	}
+	return &Provider{
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		token:       c.Token,
+		skipExpired: c.SkipExpired,
+		cache:       cache,
+		log:         logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func tagsCacheKey(repo string) string {
+	return fmt.Sprintf("quay/%s/tags", repo)
+}
+
+func (p *Provider) getTags(repo string) ([]Tag, error) {
+	log := p.log.WithValues("repo", repo)
+	if t, ok := p.getCacheValue(tagsCacheKey(repo)); ok {
+		log.V(1).Info("found tags in cache")
+		return t.([]Tag), nil
+	}
+	log.V(1).Info("getting tags")
+	name := strings.TrimPrefix(repo, "quay.io/")
+	var tags []Tag
+	for page := 1; ; page++ {
+		// the history of the tags, e.g. the deleted tags, is left out
+		u := fmt.Sprintf("%s/api/v1/repository/%s/tag/?limit=100&page=%d&onlyActiveTags=true", p.baseURL, name, page)
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if p.token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+		}
+		var tp tagsPage
+		err = json.NewDecoder(resp.Body).Decode(&tp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tp.Tags...)
+		if !tp.HasAdditional {
+			break
+		}
+	}
+	p.setCacheValue(tagsCacheKey(repo), tags)
+	return tags, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.QuayStrategyTags {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	tags, err := p.getTags(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	var names []string
+	for _, tag := range tags {
+		// the tags are cached, so a tag can expire after it is listed
+		if p.skipExpired && tag.EndTS != 0 && tag.EndTS <= now {
+			continue
+		}
+		names = append(names, tag.Name)
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, names)
+}
@@ -0,0 +1,141 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Config contains configuration for S3 provider
+type Config struct {
+	// Region used to look up the region of the buckets.
+	// Defaults to the region of the AWS environment (e.g. AWS_REGION)
+	Region string
+}
+
+// Provider is an S3 provider for getting remote versions from the object keys of S3 buckets.
+// Credentials are resolved by the default AWS credential chain (environment, IRSA, instance profile, etc.)
+type Provider struct {
+	session *session.Session
+	region  string
+	clients map[string]*s3.S3
+	mutex   sync.Mutex
+	cache   *cache.Cache
+	log     logr.Logger
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) (*Provider, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{HTTPClient: &http.Client{Transport: tr}},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %v", err)
+	}
+	region := c.Region
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &Provider{
+		session: sess,
+		region:  region,
+		clients: map[string]*s3.S3{},
+		cache:   cache,
+		log:     logger,
+	}, nil
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func objectsCacheKey(repo string) string {
+	return fmt.Sprintf("s3/%s/objects", repo)
+}
+
+// client returns the S3 client of the bucket region
+func (p *Provider) client(bucket string) (*s3.S3, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if c, ok := p.clients[bucket]; ok {
+		return c, nil
+	}
+	region, err := s3manager.GetBucketRegion(aws.BackgroundContext(), p.session, bucket, p.region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the region of bucket %s: %v", bucket, err)
+	}
+	c := s3.New(p.session, aws.NewConfig().WithRegion(region))
+	p.clients[bucket] = c
+	return c, nil
+}
+
+// getObjects gets the object keys under the prefix. repo is in the format of
+// bucket/prefix (e.g. my-artifacts/releases/app or s3://my-artifacts/releases/app)
+func (p *Provider) getObjects(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if o, ok := p.getCacheValue(objectsCacheKey(repo)); ok {
+		log.V(1).Info("found objects in cache")
+		return o.([]string), nil
+	}
+	log.V(1).Info("getting objects")
+	parts := strings.SplitN(strings.TrimPrefix(repo, "s3://"), "/", 2)
+	bucket := parts[0]
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: bucket/prefix", repo)
+	}
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	}
+	if len(parts) == 2 && parts[1] != "" {
+		input.Prefix = aws.String(parts[1])
+	}
+	client, err := p.client(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range page.Contents {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(objectsCacheKey(repo), keys)
+	return keys, nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.S3StrategyObjects {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	keys, err := p.getObjects(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, keys)
+	if err != nil {
+		return nil, err
+	}
+	// many objects (e.g. releases/v1.2.3/app.tar.gz and releases/v1.2.3/app.sha256) can share the same version
+	return utils.RemoveDuplicateStr(versions), nil
+}
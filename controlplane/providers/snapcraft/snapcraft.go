@@ -0,0 +1,114 @@
+package snapcraft
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+const apiURL = "https://api.snapcraft.io/v2"
+
+// Provider is a snapcraft provider for getting remote versions from the channels of snaps
+type Provider struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    logr.Logger
+}
+
+type ChannelMapEntry struct {
+	Channel struct {
+		Name         string `json:"name"`
+		Track        string `json:"track"`
+		Risk         string `json:"risk"`
+		Architecture string `json:"architecture"`
+	} `json:"channel"`
+	Version string `json:"version"`
+}
+
+func NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tr},
+		cache:  cache,
+		log:    logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func channelMapCacheKey(repo string) string {
+	return fmt.Sprintf("snapcraft/%s/channels", repo)
+}
+
+// getChannelMap gets the channels of the snap. They are cached under the repo so they are dropped with the repo
+func (p *Provider) getChannelMap(repo, name string) ([]ChannelMapEntry, error) {
+	log := p.log.WithValues("snap", name)
+	if c, ok := p.getCacheValue(channelMapCacheKey(repo)); ok {
+		log.V(1).Info("found channels in cache")
+		return c.([]ChannelMapEntry), nil
+	}
+	log.V(1).Info("getting channels")
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/snaps/info/%s", apiURL, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	// the store API requires the device series
+	req.Header.Set("Snap-Device-Series", "16")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	var info struct {
+		ChannelMap []ChannelMapEntry `json:"channel-map"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	p.setCacheValue(channelMapCacheKey(repo), info.ChannelMap)
+	return info.ChannelMap, nil
+}
+
+// getChannelVersions gets the versions released to the channels of the snap. repo is the snap name
+// optionally followed by a channel (e.g. microk8s or microk8s/1.22/stable or microk8s/stable)
+func (p *Provider) getChannelVersions(repo string) ([]string, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	channelMap, err := p.getChannelMap(repo, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range channelMap {
+		if len(parts) == 2 && entry.Channel.Name != parts[1] && fmt.Sprintf("%s/%s", entry.Channel.Track, entry.Channel.Risk) != parts[1] {
+			continue
+		}
+		versions = append(versions, entry.Version)
+	}
+	return utils.RemoveDuplicateStr(versions), nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.SnapcraftStrategyChannels {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getChannelVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
@@ -0,0 +1,197 @@
+package yum
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/patrickmn/go-cache"
+	v1alpha1 "github.com/skillz/opvic/agent/api/v1alpha1"
+	"github.com/skillz/opvic/utils"
+)
+
+// Config contains configuration for YUM provider
+type Config struct {
+	// Base URL of the private repository (e.g. https://rpm.example.com)
+	RepositoryURL string
+	// Basic authentication credentials for the private repository. They are only sent to the host of the repository URL
+	Username string
+	Password string
+}
+
+// Provider is a YUM provider for getting remote versions from the primary metadata of RPM repositories
+type Provider struct {
+	client        *http.Client
+	repositoryURL string
+	username      string
+	password      string
+	cache         *cache.Cache
+	log           logr.Logger
+}
+
+// RepoMD is the repodata/repomd.xml index of the repository metadata
+type RepoMD struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+type Package struct {
+	Name    string `xml:"name"`
+	Arch    string `xml:"arch"`
+	Version struct {
+		Epoch string `xml:"epoch,attr"`
+		Ver   string `xml:"ver,attr"`
+		Rel   string `xml:"rel,attr"`
+	} `xml:"version"`
+}
+
+func (c *Config) NewProvider(cache *cache.Cache, tr *http.Transport, logger logr.Logger) *Provider {
+	return &Provider{
+		client:        &http.Client{Timeout: 60 * time.Second, Transport: tr},
+		repositoryURL: c.RepositoryURL,
+		username:      c.Username,
+		password:      c.Password,
+		cache:         cache,
+		log:           logger,
+	}
+}
+
+func (p *Provider) getCacheValue(key string) (interface{}, bool) {
+	return p.cache.Get(key)
+}
+
+func (p *Provider) setCacheValue(key string, value interface{}) {
+	p.cache.Set(key, value, cache.DefaultExpiration)
+}
+
+func versionsCacheKey(repo string) string {
+	return fmt.Sprintf("yum/%s/versions", repo)
+}
+
+// get requests the URL, with the credentials when it is on the host of the repository URL
+func (p *Provider) get(u string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.username != "" && p.password != "" && utils.SameHost(p.repositoryURL, req.URL) {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d status: %s", resp.StatusCode, resp.Status)
+	}
+	return resp, nil
+}
+
+// primaryLocation gets the location of the primary metadata from repomd.xml
+func (p *Provider) primaryLocation(baseURL string) (string, error) {
+	resp, err := p.get(fmt.Sprintf("%s/repodata/repomd.xml", baseURL))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var repomd RepoMD
+	if err := xml.NewDecoder(resp.Body).Decode(&repomd); err != nil {
+		return "", err
+	}
+	for _, d := range repomd.Data {
+		if d.Type == "primary" {
+			return fmt.Sprintf("%s/%s", baseURL, d.Location.Href), nil
+		}
+	}
+	return "", fmt.Errorf("primary metadata not found in %s", baseURL)
+}
+
+// getVersions gets the versions (version-release) of the package. repo is the base URL
+// of the repository followed by the package name (e.g. "https://rpm.example.com/el8/x86_64 nodectl")
+func (p *Provider) getVersions(repo string) ([]string, error) {
+	log := p.log.WithValues("repo", repo)
+	if v, ok := p.getCacheValue(versionsCacheKey(repo)); ok {
+		log.V(1).Info("found versions in cache")
+		return v.([]string), nil
+	}
+	log.V(1).Info("getting versions")
+	fields := strings.Fields(repo)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid repo: %s. it must be in the format of: <baseurl> <package>", repo)
+	}
+	baseURL, name := strings.TrimSuffix(fields[0], "/"), fields[1]
+	location, err := p.primaryLocation(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var r io.Reader = resp.Body
+	if strings.HasSuffix(location, ".gz") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if !strings.HasSuffix(location, ".xml") {
+		return nil, fmt.Errorf("unsupported compression of the primary metadata: %s", location)
+	}
+	versions, err := packageVersions(r, name)
+	if err != nil {
+		return nil, err
+	}
+	p.setCacheValue(versionsCacheKey(repo), versions)
+	return versions, nil
+}
+
+// packageVersions streams the primary metadata and returns the versions of the package
+func packageVersions(r io.Reader, name string) ([]string, error) {
+	var versions []string
+	decoder := xml.NewDecoder(r)
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "package" {
+			continue
+		}
+		var pkg Package
+		if err := decoder.DecodeElement(&pkg, &se); err != nil {
+			return nil, err
+		}
+		if pkg.Name == name && pkg.Arch != "src" {
+			versions = append(versions, fmt.Sprintf("%s-%s", pkg.Version.Ver, pkg.Version.Rel))
+		}
+	}
+	return utils.RemoveDuplicateStr(versions), nil
+}
+
+func (p *Provider) GetVersions(conf v1alpha1.RemoteVersion) ([]string, error) {
+	if conf.Strategy != v1alpha1.YUMStrategyVersions {
+		return nil, fmt.Errorf("strategy %s is not supported", conf.Strategy)
+	}
+	versions, err := p.getVersions(conf.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FilterVersions(conf.Extraction.Regex.Pattern, conf.Extraction.Regex.Result, conf.Constraint, versions)
+}
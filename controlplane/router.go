@@ -22,8 +22,12 @@ func (cp *ControlPlane) SetupRouter() *gin.Engine {
 	// Remove the extra slash anywhere in the path (e.g. /api/v1//foo -> /api/v1/foo)
 	r.RemoveExtraSlash = true
 
-	// Add AuthMiddleware to all API routes
-	v1alpha1 := r.Group(api.APIGroup).Use(cp.AuthMiddleware())
+	// Add AuthMiddleware to all API routes. A verified client certificate is also required with mutual TLS
+	apiGroup := r.Group(api.APIGroup)
+	if cp.clientAuth {
+		apiGroup.Use(cp.ClientCertMiddleware())
+	}
+	v1alpha1 := apiGroup.Use(cp.AuthMiddleware(), DecompressMiddleware(), CompressMiddleware())
 
 	// Metrics router
 	r.GET(api.MetricsPath, PrometheusHandler())
@@ -36,9 +40,18 @@ func (cp *ControlPlane) SetupRouter() *gin.Engine {
 	v1alpha1.GET(api.AgentAPIPath, cp.AgentGet())
 	v1alpha1.GET(api.AgentsSubjectVersionPath, cp.AgentsSubjectVersionGet())
 	v1alpha1.GET(api.AgentsSubjectVersionInfoPath, cp.AgentsSubjectVersionsInfoGet())
+	v1alpha1.POST(api.AgentRefreshPath, cp.AgentRefreshPost())
+
+	// Stream router of the agents reporting over a websocket
+	v1alpha1.GET(api.StreamAPIPath, cp.StreamHandler())
 
 	// Overview router
 	v1alpha1.GET(api.OverviewAPIPath, cp.OverviewGet())
 
+	// Webhook routers are authenticated by their signatures
+	if cp.githubWebhookSecret != "" {
+		r.POST(api.GithubWebhookEndpoint, cp.GithubWebhookPost())
+	}
+
 	return r
 }
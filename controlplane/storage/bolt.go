@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const defaultBoltTimeout = 10 * time.Second
+
+var boltStateBucket = []byte("state")
+
+// BoltConfig is the config of the bbolt store
+type BoltConfig struct {
+	// Path of the database file. It is created when it doesn't exist
+	Path string
+	// Expiration of the values
+	Expiration time.Duration
+	// Timeout to get the lock of the database file, which is held by a single process at a time
+	Timeout time.Duration
+}
+
+// BoltStore keeps the state in an embedded bbolt database file so it survives the restarts of the control plane
+// without running a database server. The file can only be opened by one control plane at a time
+type BoltStore struct {
+	config BoltConfig
+	db     *bolt.DB
+	// lock of all the keys
+	mutex sync.Mutex
+}
+
+// boltEntry is a value of the store with its expiration, in unix nanoseconds. The value never expires when it is 0
+type boltEntry struct {
+	Expires int64           `json:"expires,omitempty"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// NewBoltStore opens the database file of the bbolt store
+func NewBoltStore(config BoltConfig) (*BoltStore, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("the path of the database file is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultBoltTimeout
+	}
+	db, err := bolt.Open(config.Path, 0600, &bolt.Options{Timeout: config.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", config.Path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltStateBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the buckets of %s: %v", config.Path, err)
+	}
+	return &BoltStore{config: config, db: db}, nil
+}
+
+func (s *BoltStore) Get(key string, value interface{}) (bool, error) {
+	var entry boltEntry
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltStateBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &entry)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read the value of %s: %v", key, err)
+	}
+	if !found || entry.expired(time.Now()) {
+		return false, nil
+	}
+	if err := json.Unmarshal(entry.Value, value); err != nil {
+		return false, fmt.Errorf("failed to decode the value of %s: %v", key, err)
+	}
+	return true, nil
+}
+
+func (s *BoltStore) Set(key string, value interface{}) error {
+	entry := boltEntry{}
+	if s.config.Expiration > 0 {
+		entry.Expires = time.Now().Add(s.config.Expiration).UnixNano()
+	}
+	var err error
+	if entry.Value, err = json.Marshal(value); err != nil {
+		return fmt.Errorf("failed to encode the value of %s: %v", key, err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode the value of %s: %v", key, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltStateBucket).Put([]byte(key), data)
+	})
+}
+
+// DeleteExpired deletes the expired values. The values failing to be deleted are deleted by the next call
+func (s *BoltStore) DeleteExpired() {
+	now := time.Now()
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltStateBucket)
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var entry boltEntry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.expired(now) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Lock(key string) (func(), error) {
+	s.mutex.Lock()
+	return s.mutex.Unlock, nil
+}
+
+func (e boltEntry) expired(now time.Time) bool {
+	return e.Expires != 0 && now.UnixNano() >= e.Expires
+}
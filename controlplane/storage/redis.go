@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	defaultRedisPoolSize = 10
+	defaultRedisTimeout  = 5 * time.Second
+	// the locks expire when the replica holding them is gone
+	redisLockTTL   = 30 * time.Second
+	redisLockRetry = 20 * time.Millisecond
+)
+
+// redisUnlockScript only deletes the lock if it is still held with the token, i.e. it did not expire meanwhile
+var redisUnlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// RedisConfig is the config of the Redis store
+type RedisConfig struct {
+	// Address of the Redis server (host:port)
+	Address  string
+	Username string
+	Password string
+	DB       int
+	// TLS config of the connections. The connections are not encrypted when it is nil
+	TLSConfig *tls.Config
+	// Prefix of the keys, to share a Redis server between several control planes
+	Prefix string
+	// Expiration of the values
+	Expiration time.Duration
+	// Maximum number of connections
+	PoolSize int
+	// Timeout of the connections and of the commands
+	Timeout time.Duration
+}
+
+// RedisStore keeps the state in Redis so it survives the restarts of the control plane and is shared between its
+// replicas. The values are encoded in JSON and expire with the Redis expiration
+type RedisStore struct {
+	config RedisConfig
+	client *redis.Client
+}
+
+// NewRedisStore returns a Redis store. The connection to the server is checked with a PING
+func NewRedisStore(config RedisConfig) (*RedisStore, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("the address of the Redis server is required")
+	}
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultRedisPoolSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultRedisTimeout
+	}
+	options := &redis.Options{
+		Addr:         config.Address,
+		Password:     config.Password,
+		DB:           config.DB,
+		TLSConfig:    config.TLSConfig,
+		PoolSize:     config.PoolSize,
+		DialTimeout:  config.Timeout,
+		ReadTimeout:  config.Timeout,
+		WriteTimeout: config.Timeout,
+		PoolTimeout:  config.Timeout,
+	}
+	if config.Username != "" {
+		// the client only authenticates with the password, so the ACL users authenticate and select the database
+		// when the connection opens
+		options.Password = ""
+		options.DB = 0
+		options.OnConnect = func(conn *redis.Conn) error {
+			if err := conn.Do("AUTH", config.Username, config.Password).Err(); err != nil {
+				return fmt.Errorf("AUTH failed: %v", err)
+			}
+			if config.DB != 0 {
+				return conn.Select(config.DB).Err()
+			}
+			return nil
+		}
+	}
+	s := &RedisStore{
+		config: config,
+		client: redis.NewClient(options),
+	}
+	if err := s.client.Ping().Err(); err != nil {
+		s.client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %v", config.Address, err)
+	}
+	return s, nil
+}
+
+func (s *RedisStore) Get(key string, value interface{}) (bool, error) {
+	data, err := s.client.Get(s.config.Prefix + key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to decode the value of %s: %v", key, err)
+	}
+	return true, nil
+}
+
+func (s *RedisStore) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode the value of %s: %v", key, err)
+	}
+	return s.client.Set(s.config.Prefix+key, data, s.config.Expiration).Err()
+}
+
+// Lock acquires the lock of the key with a SET NX, retried until the timeout of the store
+func (s *RedisStore) Lock(key string) (func(), error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	lockKey := s.config.Prefix + "locks/" + key
+	value := hex.EncodeToString(token)
+	deadline := time.Now().Add(s.config.Timeout)
+	for {
+		acquired, err := s.client.SetNX(lockKey, value, redisLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock of %s", key)
+		}
+		time.Sleep(redisLockRetry)
+	}
+	return func() {
+		// the lock expires if it fails to be released
+		_ = redisUnlockScript.Run(s.client, []string{lockKey}, value).Err()
+	}, nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/patrickmn/go-cache"
+	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+)
+
+// Backends of the store
+const (
+	BackendMemory   = "memory"
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+	BackendBolt     = "bolt"
+)
+
+// Backends are the supported backends of the store
+var Backends = []string{BackendMemory, BackendRedis, BackendPostgres, BackendBolt}
+
+// Store keeps the state of the control plane, i.e. the reports of the agents and the version infos of their
+// subjects. The values expire after the expiration of the store
+type Store interface {
+	// Get reads the value of the key into value, which must be a pointer. It returns false when the key is missing
+	// or expired
+	Get(key string, value interface{}) (bool, error)
+	// Set stores the value of the key
+	Set(key string, value interface{}) error
+}
+
+// Expirer is implemented by the stores whose expired values must be deleted periodically
+type Expirer interface {
+	DeleteExpired()
+}
+
+// Locker is implemented by the stores to serialize the updates of the keys read and written back by the control
+// plane, e.g. the list of the agents. The locks of the stores shared by several replicas are shared too
+type Locker interface {
+	// Lock blocks until the lock of the key is acquired and returns the function releasing it
+	Lock(key string) (func(), error)
+}
+
+// History keeps the history of the subjects reported by the agents and of their versions
+type History interface {
+	// RecordSubjectVersion records a subject reported by an agent and its running versions
+	RecordSubjectVersion(agentID string, subject api.SubjectVersion) error
+	// RecordVersionInfos records the remote versions detected for a subject
+	RecordVersionInfos(infos api.VersionInfos) error
+}
+
+// MemoryStore keeps the state in memory. The state is lost when the control plane restarts
+type MemoryStore struct {
+	cache *cache.Cache
+	// lock of all the keys
+	mutex sync.Mutex
+}
+
+// NewMemoryStore returns a store keeping the values in the cache with its default expiration
+func NewMemoryStore(c *cache.Cache) *MemoryStore {
+	return &MemoryStore{cache: c}
+}
+
+func (s *MemoryStore) Get(key string, value interface{}) (bool, error) {
+	v, found := s.cache.Get(key)
+	if !found {
+		return false, nil
+	}
+	out := reflect.ValueOf(value)
+	if out.Kind() != reflect.Ptr || out.IsNil() {
+		return false, fmt.Errorf("value of %s must be a non-nil pointer", key)
+	}
+	in := reflect.ValueOf(v)
+	if !in.Type().AssignableTo(out.Elem().Type()) {
+		return false, fmt.Errorf("value of %s is a %s, not a %s", key, in.Type(), out.Elem().Type())
+	}
+	out.Elem().Set(in)
+	return true, nil
+}
+
+func (s *MemoryStore) Set(key string, value interface{}) error {
+	s.cache.Set(key, value, cache.DefaultExpiration)
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpired() {
+	s.cache.DeleteExpired()
+}
+
+func (s *MemoryStore) Lock(key string) (func(), error) {
+	s.mutex.Lock()
+	return s.mutex.Unlock, nil
+}
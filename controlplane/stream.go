@@ -0,0 +1,133 @@
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// refreshPollInterval is the interval to poll the refresh requests of the agents connected to the replica. The
+	// replicas also record that their agents are still connected at this interval
+	refreshPollInterval = 5 * time.Second
+	// streamSeenTimeout is the time after which an agent not seen by any replica is considered disconnected
+	streamSeenTimeout = 3 * refreshPollInterval
+)
+
+// agentStream is the websocket of a connected agent. An agent tracking many clusters reports
+// the subjects of all its clusters on the same stream
+type agentStream struct {
+	conn *websocket.Conn
+	// serializes the writes of the acknowledgements and the refresh requests
+	mutex sync.Mutex
+}
+
+func (s *agentStream) send(msg api.StreamMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return websocket.JSON.Send(s.conn, msg)
+}
+
+// StreamHandler handles the websocket of the agents streaming their reports. Every report is acknowledged once it is
+// stored so the agents only send the next report when the control plane keeps up
+func (cp *ControlPlane) StreamHandler() gin.HandlerFunc {
+	// the agents are authenticated by the auth middleware so the origin is not checked
+	server := websocket.Server{Handler: cp.serveStream}
+	return func(c *gin.Context) {
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+func (cp *ControlPlane) serveStream(conn *websocket.Conn) {
+	stream := &agentStream{conn: conn}
+	agents := map[string]bool{}
+	defer func() {
+		for agentID := range agents {
+			// only remove the stream if the agent did not reconnect meanwhile
+			if s, ok := cp.streams.Load(agentID); ok && s == stream {
+				cp.streams.Delete(agentID)
+				if cp.elector != nil {
+					cp.set(AgentStreamCacheKey(agentID), int64(0))
+				}
+			}
+		}
+		conn.Close()
+	}()
+	for {
+		var msg api.StreamMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if err != io.EOF {
+				cp.log.Error(err, "failed to receive agent stream message")
+			}
+			return
+		}
+		ack := api.StreamMessage{Type: api.StreamMessageAck, Seq: msg.Seq}
+		if msg.Type != api.StreamMessageReport || msg.Payload == nil {
+			ack.Error = fmt.Sprintf("unexpected message type: %s", msg.Type)
+		} else if msg.Payload.AgentID == "" || msg.Payload.Version.ID == "" {
+			ack.Error = "agentId and version.id are required"
+		} else {
+			ap := *msg.Payload
+			cp.log.V(1).Info(
+				"received agent payload from stream",
+				"agent_id", ap.AgentID,
+				"version_id", ap.Version.ID,
+			)
+			cp.processAgentPayload(ap)
+			if !agents[ap.AgentID] {
+				agents[ap.AgentID] = true
+				cp.streams.Store(ap.AgentID, stream)
+				if cp.elector != nil {
+					cp.set(AgentStreamCacheKey(ap.AgentID), time.Now().UnixNano())
+				}
+			}
+		}
+		if err := stream.send(ack); err != nil {
+			cp.log.Error(err, "failed to acknowledge agent report")
+			return
+		}
+	}
+}
+
+// RequestRefresh requests a connected agent to report all its subjects. With several replicas, the agents connected
+// to another replica are requested to refresh by that replica when it polls the refresh requests of the store. An
+// error is returned when the agent is not connected to any replica
+func (cp *ControlPlane) RequestRefresh(agentID string) error {
+	s, ok := cp.streams.Load(agentID)
+	if !ok {
+		var seen int64
+		if cp.elector == nil || !cp.get(AgentStreamCacheKey(agentID), &seen) || time.Since(time.Unix(0, seen)) > streamSeenTimeout {
+			return fmt.Errorf("agent %s is not connected", agentID)
+		}
+		cp.set(AgentRefreshCacheKey(agentID), time.Now().UnixNano())
+		return nil
+	}
+	return s.(*agentStream).send(api.StreamMessage{Type: api.StreamMessageRefresh})
+}
+
+// pollRefreshRequests sends the refresh requests stored by the other replicas to the agents connected to this
+// replica and records that they are still connected. Only the requests made after the replica started are sent
+func (cp *ControlPlane) pollRefreshRequests() {
+	handled := map[string]int64{}
+	started := time.Now().UnixNano()
+	for range time.Tick(refreshPollInterval) {
+		cp.streams.Range(func(key, value interface{}) bool {
+			agentID := key.(string)
+			cp.set(AgentStreamCacheKey(agentID), time.Now().UnixNano())
+			var requested int64
+			if !cp.get(AgentRefreshCacheKey(agentID), &requested) || requested <= started || requested <= handled[agentID] {
+				return true
+			}
+			handled[agentID] = requested
+			if err := value.(*agentStream).send(api.StreamMessage{Type: api.StreamMessageRefresh}); err != nil {
+				cp.log.Error(err, "failed to request the agent to refresh", "agent_id", agentID)
+			}
+			return true
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package controlplane
 
 import (
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/skillz/opvic/agent/api/v1alpha1"
 	api "github.com/skillz/opvic/controlplane/api/v1alpha1"
+	"github.com/skillz/opvic/controlplane/providers"
 	"github.com/skillz/opvic/controlplane/version"
 	"github.com/skillz/opvic/utils"
 )
@@ -19,11 +24,15 @@ func (cp *ControlPlane) GetSubjectVersionInfos(agentID string, ver *api.SubjectV
 	)
 	log.V(1).Info("getting version infos")
 	var latest string
-	remoteversions, err := cp.provider.GetVersions(ver.RemoteVersion)
+	published, err := cp.provider.GetPublishedVersions(ver.RemoteVersion)
 	if err != nil {
 		log.Error(err, "failed to get remote versions")
 		return api.VersionInfos{}, err
 	}
+	var remoteversions []string
+	for _, v := range published {
+		remoteversions = append(remoteversions, v.Version)
+	}
 	subV, err := version.NewVersions("", remoteversions)
 	if err != nil {
 		return api.VersionInfos{}, err
@@ -43,24 +52,51 @@ func (cp *ControlPlane) GetSubjectVersionInfos(agentID string, ver *api.SubjectV
 		RemoteProvider: ver.RemoteVersion.Provider,
 		RemoteRepo:     ver.RemoteVersion.Repo,
 	}
+	dates := publishDates(published)
+	if date, ok := dates[latest]; ok {
+		verInfos.LatestPublishedAt = &date
+	}
+	if latest != MissingLatest && ver.RemoteVersion.Provider == providers.Github.String() && ver.RemoteVersion.Strategy == v1alpha1.GithubStrategyAssets {
+		for _, asset := range cp.provider.Github.GetAssets(ver.RemoteVersion, latest) {
+			verInfos.LatestAssets = append(verInfos.LatestAssets, api.Asset{
+				Name:   asset.Name,
+				URL:    asset.URL,
+				Digest: asset.Digest,
+			})
+		}
+	}
 	for _, v := range ver.Versions {
 		if err := subV.SetRunningVersion(v.RunningVersion); err != nil {
 			log.Error(err, "failed to set running version")
 			return api.VersionInfos{}, err
 		}
+		var runningPublishedAt *time.Time
+		var daysBehind *int
+		if date, ok := dates[subV.GetRunningVersion().String()]; ok {
+			runningPublishedAt = &date
+			if verInfos.LatestPublishedAt != nil {
+				days := 0
+				if verInfos.LatestPublishedAt.After(date) {
+					days = int(verInfos.LatestPublishedAt.Sub(date).Hours() / 24)
+				}
+				daysBehind = &days
+			}
+		}
 		verInfos.Versions = append(verInfos.Versions, api.VersionInfo{
-			RunningVersion:    subV.GetRunningVersion().String(),
-			ResourceCount:     v.ResourceCount,
-			ResourceKind:      v.ResourceKind,
-			ExtractedFrom:     v.ExtractedFrom,
-			LatestVersion:     latest,
-			AvailableVersions: subV.GreaterThan().StringList(),
-			AvailableMajors:   subV.LastMajorsGreaterThan().StringList(),
-			AvailableMinors:   subV.MinorsGreaterThan().StringList(),
-			AvailablePatches:  subV.PatchesGreaterThan().StringList(),
-			MajorAvailable:    subV.MajorAvailable(),
-			MinorAvailable:    subV.MinorAvailable(),
-			PatchAvailable:    subV.PatchAvailable(),
+			RunningVersion:     subV.GetRunningVersion().String(),
+			ResourceCount:      v.ResourceCount,
+			ResourceKind:       v.ResourceKind,
+			ExtractedFrom:      v.ExtractedFrom,
+			LatestVersion:      latest,
+			AvailableVersions:  subV.GreaterThan().StringList(),
+			AvailableMajors:    subV.LastMajorsGreaterThan().StringList(),
+			AvailableMinors:    subV.MinorsGreaterThan().StringList(),
+			AvailablePatches:   subV.PatchesGreaterThan().StringList(),
+			MajorAvailable:     subV.MajorAvailable(),
+			MinorAvailable:     subV.MinorAvailable(),
+			PatchAvailable:     subV.PatchAvailable(),
+			RunningPublishedAt: runningPublishedAt,
+			DaysBehind:         daysBehind,
 		})
 		if !utils.Contains(verInfos.RunningVersions, v.RunningVersion) {
 			verInfos.RunningVersions = append(verInfos.RunningVersions, v.RunningVersion)
@@ -69,6 +105,24 @@ func (cp *ControlPlane) GetSubjectVersionInfos(agentID string, ver *api.SubjectV
 	return verInfos, nil
 }
 
+// publishDates returns the known publish dates of the remote versions by normalized version
+func publishDates(published []utils.PublishedVersion) map[string]time.Time {
+	dates := map[string]time.Time{}
+	for _, v := range published {
+		if v.PublishedAt.IsZero() {
+			continue
+		}
+		key := v.Version
+		if parsed, err := goversion.NewVersion(v.Version); err == nil {
+			key = parsed.String()
+		}
+		if _, ok := dates[key]; !ok {
+			dates[key] = v.PublishedAt
+		}
+	}
+	return dates
+}
+
 func (cp *ControlPlane) GetAgentOverallVersionInfos(agentID string) ([]string, api.AgentVersionInfos) {
 	subverIds := cp.GetAgentSubjectVersionListCache(agentID)
 	versionIdList := []string{}
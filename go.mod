@@ -3,23 +3,30 @@ module github.com/skillz/opvic
 go 1.16
 
 require (
+	github.com/andybalholm/cascadia v1.2.0
+	github.com/aws/aws-sdk-go v1.40.43
 	github.com/bradleyfalzon/ghinstallation v1.1.1
+	github.com/containerd/containerd v1.5.18
 	github.com/gin-gonic/gin v1.7.7
+	github.com/go-git/go-git/v5 v5.4.2
 	github.com/go-logr/logr v0.4.0
+	github.com/go-redis/redis v6.15.5+incompatible
 	github.com/google/go-github/v39 v39.2.0
 	github.com/google/uuid v1.2.0 // indirect
 	github.com/hashicorp/go-version v1.3.0
 	github.com/jasonlvhit/gocron v0.0.1
+	github.com/lib/pq v1.10.3
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.13.0
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/prometheus/client_golang v1.11.0
 	github.com/spf13/cobra v1.2.1 // indirect
+	go.etcd.io/bbolt v1.3.6
 	go.uber.org/zap v1.19.1
-	golang.org/x/net v0.0.0-20210913180222-943fd674d43e // indirect
+	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
+	golang.org/x/net v0.0.0-20210913180222-943fd674d43e
 	golang.org/x/oauth2 v0.0.0-20210402161424-2e8d93401602
-	golang.org/x/sys v0.0.0-20210910150752-751e447fb3d0 // indirect
 	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
@@ -30,4 +37,5 @@ require (
 	k8s.io/client-go v0.22.3
 	k8s.io/kubectl v0.22.3
 	sigs.k8s.io/controller-runtime v0.9.3
+	sigs.k8s.io/yaml v1.2.0
 )
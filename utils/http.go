@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewHTTPTransport returns a transport for the outbound requests of a provider.
+// proxyURL overrides the proxy from the environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY) and
+// the certificates of caFile are trusted in addition to the system roots (e.g. for TLS interception)
+func NewHTTPTransport(proxyURL, caFile string) (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %s: %v", proxyURL, err)
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %v", caFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse CA file %s", caFile)
+		}
+		tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return tr, nil
+}
+
+// SameHost returns true if the URL has the scheme and the host of the base URL, so the credentials configured for
+// the base URL can be sent with it
+func SameHost(base string, u *url.URL) bool {
+	b, err := url.Parse(base)
+	if err != nil || b.Host == "" {
+		return false
+	}
+	return strings.EqualFold(b.Scheme, u.Scheme) && strings.EqualFold(b.Host, u.Host)
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// FilterNpmRange only keeps the versions that satisfy the constraint. The constraint can be
+// an npm-style range (e.g. ^1.2.0 || ~2.0.0, 1.x, 1.0.0 - 1.5.0) or a regular version constraint
+func FilterNpmRange(constraint string, versions []string) ([]string, error) {
+	if constraint == "" {
+		return versions, nil
+	}
+	sets, err := TranslateNpmRange(constraint)
+	if err != nil {
+		// not an npm range, e.g. != 1.2.3, so it is checked as a regular version constraint
+		sets = []string{constraint}
+	}
+	var filtered []string
+	for _, v := range versions {
+		for _, set := range sets {
+			meet := true
+			if set != "" {
+				meet, err = MeetConstraint(set, v)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if meet {
+				filtered = append(filtered, v)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// ValidateNpmRange checks the constraint is an npm-style range or a regular version constraint,
+// i.e. a constraint FilterNpmRange accepts
+func ValidateNpmRange(constraint string) error {
+	sets, err := TranslateNpmRange(constraint)
+	if err != nil {
+		sets = []string{constraint}
+	}
+	for _, set := range sets {
+		if set == "" {
+			continue
+		}
+		if _, err := version.NewConstraint(set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TranslateNpmRange translates an npm-style range to a list of version constraints.
+// A version satisfies the range if it meets any of the returned constraints.
+// An empty constraint matches any version
+func TranslateNpmRange(r string) ([]string, error) {
+	// already a regular version constraint
+	if strings.Contains(r, ",") || strings.Contains(r, "~>") {
+		return []string{r}, nil
+	}
+	var sets []string
+	for _, alt := range strings.Split(r, "||") {
+		var comparators []string
+		if bounds := strings.Split(alt, " - "); len(bounds) == 2 {
+			lower, err := npmComparator(">=" + strings.TrimSpace(bounds[0]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %s: %v", r, err)
+			}
+			upper, err := npmComparator("<=" + strings.TrimSpace(bounds[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %s: %v", r, err)
+			}
+			comparators = append(comparators, lower, upper)
+		} else {
+			fields := strings.Fields(alt)
+			for i := 0; i < len(fields); i++ {
+				tok := fields[i]
+				// operators can be separated from their version by a space (e.g. >= 1.2.3)
+				if strings.Trim(tok, "<>=^~") == "" && i+1 < len(fields) {
+					i++
+					tok += fields[i]
+				}
+				c, err := npmComparator(tok)
+				if err != nil {
+					return nil, fmt.Errorf("invalid range %s: %v", r, err)
+				}
+				comparators = append(comparators, c)
+			}
+		}
+		var nonEmpty []string
+		for _, c := range comparators {
+			if c != "" {
+				nonEmpty = append(nonEmpty, c)
+			}
+		}
+		sets = append(sets, strings.Join(nonEmpty, ", "))
+	}
+	return sets, nil
+}
+
+// npmComparator translates a single npm comparator to a version constraint
+func npmComparator(tok string) (string, error) {
+	var op string
+	for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(tok, o) {
+			op = o
+			break
+		}
+	}
+	nums, pre, err := parsePartialVersion(strings.TrimPrefix(tok[len(op):], "v"))
+	if err != nil {
+		return "", err
+	}
+	n := len(nums)
+	if n == 0 {
+		// *, x or an empty version matches any version
+		return "", nil
+	}
+	full := fillVersion(nums) + pre
+	switch op {
+	case "^":
+		upper := fillVersion([]int{nums[0] + 1})
+		if nums[0] == 0 && n > 1 {
+			if n == 2 || nums[1] > 0 {
+				upper = fillVersion([]int{0, nums[1] + 1})
+			} else {
+				upper = fillVersion([]int{0, 0, nums[2] + 1})
+			}
+		}
+		return fmt.Sprintf(">= %s, < %s", full, upper), nil
+	case "~":
+		if n == 1 {
+			return fmt.Sprintf(">= %s, < %s", full, bumpVersion(nums, 0)), nil
+		}
+		return fmt.Sprintf(">= %s, < %s", full, bumpVersion(nums, 1)), nil
+	case ">":
+		if n < 3 {
+			return fmt.Sprintf(">= %s", bumpVersion(nums, n-1)), nil
+		}
+		return fmt.Sprintf("> %s", full), nil
+	case ">=":
+		return fmt.Sprintf(">= %s", full), nil
+	case "<":
+		return fmt.Sprintf("< %s", full), nil
+	case "<=":
+		if n < 3 {
+			return fmt.Sprintf("< %s", bumpVersion(nums, n-1)), nil
+		}
+		return fmt.Sprintf("<= %s", full), nil
+	default:
+		if n < 3 {
+			return fmt.Sprintf(">= %s, < %s", full, bumpVersion(nums, n-1)), nil
+		}
+		return fmt.Sprintf("= %s", full), nil
+	}
+}
+
+// parsePartialVersion parses a full or partial version (e.g. 1.2.3-beta.1, 1.2, 1.x)
+// and returns its numeric parts and the prerelease suffix
+func parsePartialVersion(v string) ([]int, string, error) {
+	var pre string
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v, pre = v[:i], v[i:]
+	}
+	var nums []int
+	if v == "" {
+		return nums, "", nil
+	}
+	for _, part := range strings.SplitN(v, ".", 3) {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid version %s", v)
+		}
+		nums = append(nums, num)
+	}
+	if len(nums) < 3 {
+		pre = ""
+	}
+	return nums, pre, nil
+}
+
+// fillVersion pads the version parts with zeros
+func fillVersion(nums []int) string {
+	parts := []string{"0", "0", "0"}
+	for i, num := range nums {
+		parts[i] = strconv.Itoa(num)
+	}
+	return strings.Join(parts, ".")
+}
+
+// bumpVersion increments the version part at index i and resets the ones after it
+func bumpVersion(nums []int, i int) string {
+	bumped := append([]int{}, nums[:i+1]...)
+	bumped[i]++
+	return fillVersion(bumped)
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateNpmRange(t *testing.T) {
+	tests := []struct {
+		r    string
+		want []string
+	}{
+		// caret
+		{"^1.2.3", []string{">= 1.2.3, < 2.0.0"}},
+		{"^1.2", []string{">= 1.2.0, < 2.0.0"}},
+		{"^0.2.3", []string{">= 0.2.3, < 0.3.0"}},
+		{"^0.0.3", []string{">= 0.0.3, < 0.0.4"}},
+		{"^0.x", []string{">= 0.0.0, < 1.0.0"}},
+		// tilde
+		{"~1.2.3", []string{">= 1.2.3, < 1.3.0"}},
+		{"~1.2", []string{">= 1.2.0, < 1.3.0"}},
+		{"~1", []string{">= 1.0.0, < 2.0.0"}},
+		// x-ranges
+		{"*", []string{""}},
+		{"1.x", []string{">= 1.0.0, < 2.0.0"}},
+		{"1.2.X", []string{">= 1.2.0, < 1.3.0"}},
+		{"1.2.*", []string{">= 1.2.0, < 1.3.0"}},
+		{"1.2", []string{">= 1.2.0, < 1.3.0"}},
+		// hyphen ranges
+		{"1.0.0 - 1.5.0", []string{">= 1.0.0, <= 1.5.0"}},
+		{"1.0 - 1.5", []string{">= 1.0.0, < 1.6.0"}},
+		// comparators
+		{">= 1.2.3 < 2", []string{">= 1.2.3, < 2.0.0"}},
+		{">1.2", []string{">= 1.3.0"}},
+		{"<=1.2", []string{"< 1.3.0"}},
+		{"v1.2.3", []string{"= 1.2.3"}},
+		{"=1.2.3-beta.1", []string{"= 1.2.3-beta.1"}},
+		// alternatives
+		{"^1.2.0 || ~2.0.0", []string{">= 1.2.0, < 2.0.0", ">= 2.0.0, < 2.1.0"}},
+		{"1.x || >=3.0.0", []string{">= 1.0.0, < 2.0.0", ">= 3.0.0"}},
+		// regular version constraints
+		{"~> 1.2", []string{"~> 1.2"}},
+		{">= 1.0, < 2.0", []string{">= 1.0, < 2.0"}},
+	}
+	for _, tt := range tests {
+		got, err := TranslateNpmRange(tt.r)
+		if err != nil {
+			t.Errorf("TranslateNpmRange(%q) returned an error: %v", tt.r, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TranslateNpmRange(%q) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateNpmRangeInvalid(t *testing.T) {
+	for _, r := range []string{"!= 1.2.3", "^foo", "1.0.0 - bar"} {
+		if got, err := TranslateNpmRange(r); err == nil {
+			t.Errorf("TranslateNpmRange(%q) = %q, want an error", r, got)
+		}
+	}
+}
+
+func TestFilterNpmRange(t *testing.T) {
+	versions := []string{"1.2.3", "1.3.0", "2.0.0", "2.0.1"}
+	tests := []struct {
+		constraint string
+		want       []string
+	}{
+		{"", versions},
+		{"~1.2", []string{"1.2.3"}},
+		{"^1.2.0 || ~2.0.0", versions},
+		{"1.0.0 - 1.5.0", []string{"1.2.3", "1.3.0"}},
+		{">= 1.3, < 2.0.1", []string{"1.3.0", "2.0.0"}},
+		// not an npm range, checked as a regular version constraint
+		{"!= 1.2.3", []string{"1.3.0", "2.0.0", "2.0.1"}},
+	}
+	for _, tt := range tests {
+		got, err := FilterNpmRange(tt.constraint, versions)
+		if err != nil {
+			t.Errorf("FilterNpmRange(%q) returned an error: %v", tt.constraint, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterNpmRange(%q) = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+	if _, err := FilterNpmRange("foo", versions); err == nil {
+		t.Error("FilterNpmRange(\"foo\") returned no error")
+	}
+}
+
+func TestValidateNpmRange(t *testing.T) {
+	for _, c := range []string{"^1.2.0", "~1.2", "1.x", "*", "1.0.0 - 1.5.0", "^1.2.0 || ~2.0.0", ">= 1.0, < 2.0", "!= 1.2.3"} {
+		if err := ValidateNpmRange(c); err != nil {
+			t.Errorf("ValidateNpmRange(%q) returned an error: %v", c, err)
+		}
+	}
+	for _, c := range []string{"foo", "^1.2.0 || bar"} {
+		if err := ValidateNpmRange(c); err == nil {
+			t.Errorf("ValidateNpmRange(%q) returned no error", c)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileReloader caches a value loaded from files and loads it again when one of the files changes
+// (e.g. a certificate rotated by cert-manager in a mounted secret)
+type fileReloader struct {
+	files   []string
+	load    func() (interface{}, error)
+	mutex   sync.Mutex
+	value   interface{}
+	modTime time.Time
+}
+
+func newFileReloader(load func() (interface{}, error), files ...string) (*fileReloader, error) {
+	r := &fileReloader{files: files, load: load}
+	if _, err := r.get(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// get returns the cached value unless the files changed. The previous value is kept if the new files can not be
+// loaded, which happens while the files of a secret are being updated
+func (r *fileReloader) get() (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var modTime time.Time
+	for _, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if r.value != nil {
+				return r.value, nil
+			}
+			return nil, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if r.value != nil && modTime.Equal(r.modTime) {
+		return r.value, nil
+	}
+	value, err := r.load()
+	if err != nil {
+		if r.value != nil {
+			return r.value, nil
+		}
+		return nil, err
+	}
+	r.value, r.modTime = value, modTime
+	return value, nil
+}
+
+func newKeyPairReloader(certFile, keyFile string) (*fileReloader, error) {
+	return newFileReloader(func() (interface{}, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key pair %s %s: %v", certFile, keyFile, err)
+		}
+		return &cert, nil
+	}, certFile, keyFile)
+}
+
+// LoadCertPool reads the certificates of a CA bundle. The system roots are included when system is true
+func LoadCertPool(caFile string, system bool) (*x509.CertPool, error) {
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file %s: %v", caFile, err)
+	}
+	pool := x509.NewCertPool()
+	if system {
+		if systemPool, err := x509.SystemCertPool(); err == nil {
+			pool = systemPool
+		}
+	}
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse CA file %s", caFile)
+	}
+	return pool, nil
+}
+
+// NewServerTLSConfig returns the TLS config of a server serving the certificate and key. Client certificates are
+// verified against the client CA when it is set. The files are reloaded when they change
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	keyPair, err := newKeyPairReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := keyPair.get()
+			if err != nil {
+				return nil, err
+			}
+			return cert.(*tls.Certificate), nil
+		},
+	}
+	if clientCAFile == "" {
+		return config, nil
+	}
+	clientCAs, err := newFileReloader(func() (interface{}, error) {
+		return LoadCertPool(clientCAFile, false)
+	}, clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs.get()
+		if err != nil {
+			return nil, err
+		}
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = pool.(*x509.CertPool)
+		// the certificates are verified when they are sent. the routes requiring one check it was verified
+		c.ClientAuth = tls.VerifyClientCertIfGiven
+		return c, nil
+	}
+	return config, nil
+}
+
+// NewClientTLSConfig returns the TLS config of a client presenting the certificate and key, which are reloaded
+// when they change. The certificates of the CA file are trusted in addition to the system roots
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile, true)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		keyPair, err := newKeyPairReloader(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := keyPair.get()
+			if err != nil {
+				return nil, err
+			}
+			return cert.(*tls.Certificate), nil
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// TokenFunc returns the current token
+type TokenFunc func() (string, error)
+
+// NewTokenFunc returns the token, or the token of the file when it is set (e.g. a mounted secret). The file is read
+// again when it changes so the token can be rotated without restarting
+func NewTokenFunc(token, file string) (TokenFunc, error) {
+	if file == "" {
+		return func() (string, error) { return token, nil }, nil
+	}
+	reloader, err := newFileReloader(func() (interface{}, error) {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file %s: %v", file, err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return nil, fmt.Errorf("token file %s is empty", file)
+		}
+		return token, nil
+	}, file)
+	if err != nil {
+		return nil, err
+	}
+	return func() (string, error) {
+		token, err := reloader.get()
+		if err != nil {
+			return "", err
+		}
+		return token.(string), nil
+	}, nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"regexp"
 	"sort"
+	"time"
 
 	"github.com/hashicorp/go-version"
 )
@@ -13,6 +14,9 @@ func GetResultsFromRegex(pattern, tmpl, content string) string {
 	}
 	regex := regexp.MustCompile(pattern)
 	matches := regex.FindStringSubmatchIndex(content)
+	if matches == nil {
+		return ""
+	}
 	result := regex.ExpandString([]byte{}, tmpl, content, matches)
 	return string(result)
 }
@@ -34,6 +38,51 @@ func MeetConstraint(constraint, ver string) (bool, error) {
 	return constraints.Check(v), nil
 }
 
+// FilterVersions extracts a version from every candidate using the regex pattern and
+// result template and only keeps the ones that meet the constraint (if any)
+func FilterVersions(pattern, tmpl, constraint string, candidates []string) ([]string, error) {
+	var matchedVersions []string
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		matched, v := MatchPattern(pattern, tmpl, candidate)
+		if matched {
+			matchedVersions = append(matchedVersions, v)
+		}
+	}
+	if constraint == "" {
+		return matchedVersions, nil
+	}
+	var versions []string
+	for _, version := range matchedVersions {
+		meet, err := MeetConstraint(constraint, version)
+		if err != nil {
+			return nil, err
+		}
+		if meet {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// PublishedVersion is a remote version and the date it was published. PublishedAt is zero if the
+// provider doesn't know when the version was published
+type PublishedVersion struct {
+	Version     string
+	PublishedAt time.Time
+}
+
+// NewPublishedVersions returns the versions without publish dates
+func NewPublishedVersions(versions []string) []PublishedVersion {
+	published := make([]PublishedVersion, 0, len(versions))
+	for _, v := range versions {
+		published = append(published, PublishedVersion{Version: v})
+	}
+	return published
+}
+
 func Contains(l []string, s string) bool {
 	for _, a := range l {
 		if a == s {